@@ -2,34 +2,27 @@ package config
 
 import (
 	"fmt"
-	"os"
-
-	"github.com/linkedin/goavro"
 )
 
+// EncodeJSON converts the data received into the native values described by the schema of the
+// resource, filling the default values. When the schema is an array, the data is handled as one
+// of its items. A *schema.ValidationError is returned when the data doesn't fit the schema.
 func (res *ResourceItem) EncodeJSON(data map[string]interface{}) (interface{}, error) {
-	jsonSchema, err := os.ReadFile(res.ObjectPathSchema)
-	if err != nil {
-		return nil, fmt.Errorf("error when creating avro codec: %v", err)
-	}
-
-	// Create an avro codec using the scheme
-	codec, err := goavro.NewCodec(string(jsonSchema))
+	s, err := res.Schema()
 	if err != nil {
-		return nil, fmt.Errorf("error when serialize data for avro: %v", err)
+		return nil, fmt.Errorf("error when loading schema: %v", err)
 	}
 
-	// Serialize data to Avro using the codec
-	binaryData, err := codec.BinaryFromNative(nil, data)
-	if err != nil {
-		return nil, fmt.Errorf("error when serialize data for avro: %v", err)
-	}
+	return s.Element().Coerce(data)
+}
 
-	// Develop Avro's data back to a Go Map in GO
-	nativeData, _, err := codec.NativeFromBinary(binaryData)
+// EncodePartialJSON converts the data received like EncodeJSON, but ignores the missing fields. It is
+// used on the data that only identifies the items, like the keys of a query.
+func (res *ResourceItem) EncodePartialJSON(data map[string]interface{}) (interface{}, error) {
+	s, err := res.Schema()
 	if err != nil {
-		return nil, fmt.Errorf("error when deserving avro data: %v", err)
+		return nil, fmt.Errorf("error when loading schema: %v", err)
 	}
 
-	return nativeData, nil
+	return s.Element().CoercePartial(data)
 }
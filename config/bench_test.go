@@ -0,0 +1,56 @@
+package config
+
+import "testing"
+
+var orderRecord = map[string]interface{}{"UserID": "42", "OrderDate": "2024-01-02", "OrderID": 7.0, "Total": 10.0}
+
+// BenchmarkEncodeJSON measures the cost of typing a record by the schema of the resource, which is
+// parsed once when the configuration is compiled, against the cost of parsing it on every request
+func BenchmarkEncodeJSON(b *testing.B) {
+	res := loadConnector(b, "Keys:\n  UserID: EQ\n")
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := res.EncodeJSON(orderRecord); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("parsed per request", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := res.Compile(); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := res.EncodeJSON(orderRecord); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkValidate(b *testing.B) {
+	res := loadConnector(b, "Keys:\n  UserID: EQ\n")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := res.Validate(orderRecord); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkComposeKeys(b *testing.B) {
+	res := loadConnector(b, orderKeys)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		record, err := res.ComposeKeys(orderRecord)
+		if err != nil {
+			b.Fatal(err)
+		}
+		res.DecomposeItem(record.(map[string]interface{}))
+	}
+}
@@ -1,12 +1,11 @@
 package config
 
 import (
+	"fmt"
+
 	"gopkg.in/yaml.v2"
 )
 
-// Global is the object that contains your configuration
-var Global = Config{}
-
 // Load is the function responsible for unmarshaling the configuration YAML file into an object that can be
 // used by the DynamoDBClient.
 func (config *Config) Load(data []byte) error {
@@ -17,3 +16,48 @@ func (config *Config) Load(data []byte) error {
 
 	return nil
 }
+
+// LoadFrom reads the configuration file called name from the source received and loads it into a new
+// Config, whose resources read and compile their schema files from the same source.
+func LoadFrom(src Source, name string) (*Config, error) {
+	data, err := src.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading configuration file %s: %v", name, err)
+	}
+
+	config := &Config{}
+	if err := config.Load(data); err != nil {
+		return nil, err
+	}
+
+	config.SetSource(src)
+	if err := config.Compile(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// SetSource defines the source from which the resources read their schema files. When no source is
+// defined, the files are read from the local file system. Schemas already compiled are discarded.
+func (config *Config) SetSource(src Source) {
+	config.Resources.Receiver.source = src
+	config.Resources.Receiver.schema = nil
+	config.Resources.Connector.source = src
+	config.Resources.Connector.schema = nil
+	config.Resources.Connector.history = nil
+}
+
+// ReadSchema returns the content of the file indicated by 'ObjectPathSchema'
+func (res *ResourceItem) ReadSchema() ([]byte, error) {
+	return res.readFile(res.ObjectPathSchema)
+}
+
+// readFile reads a file from the source of the resource
+func (res *ResourceItem) readFile(name string) ([]byte, error) {
+	if res.source == nil {
+		return OSSource{}.ReadFile(name)
+	}
+
+	return res.source.ReadFile(name)
+}
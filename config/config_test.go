@@ -0,0 +1,48 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const orderSchema = `{
+  "type": "object",
+  "properties": {
+    "UserID": { "type": "string" },
+    "OrderID": { "type": "integer" },
+    "OrderDate": { "type": "string" },
+    "Total": { "type": "number" }
+  }
+}`
+
+// loadConnector compiles the configuration of a connector whose Properties are received, with the
+// schema of the orders
+func loadConnector(t testing.TB, properties string) *ResourceItem {
+	t.Helper()
+
+	config, err := loadConfig(properties)
+	if err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+
+	return &config.Resources.Connector
+}
+
+func loadConfig(properties string) (*Config, error) {
+	document := "Resources:\n  Connector:\n    ResourceType: DynamoDB\n    ObjectPathSchema: order.json\n" +
+		"    Properties:\n      TableName: app\n" + indent(properties, "      ")
+
+	return LoadFrom(NewMemorySource(map[string][]byte{
+		"config.yaml": []byte(document),
+		"order.json":  []byte(orderSchema),
+	}), "config.yaml")
+}
+
+func indent(text, prefix string) string {
+	lines := strings.Split(strings.Trim(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
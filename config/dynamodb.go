@@ -3,13 +3,19 @@ package config
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
 )
 
+// attributeName matches the attribute names, like '#Status', used by the filters
+var attributeName = regexp.MustCompile(`#([A-Za-z0-9_]+)`)
+
 // Recovers the primary key from the table
 func (res *ResourceItem) GetPrimaryKeyAttributeValue(data interface{}) (map[string]*dynamodb.AttributeValue, error) {
 	if res.ResourceType != "DynamoDB" {
@@ -20,19 +26,118 @@ func (res *ResourceItem) GetPrimaryKeyAttributeValue(data interface{}) (map[stri
 	// 	return nil, errors.New("unsupported data structure")
 	// }
 
+	return res.marshalAttributes(res.KeyValues(data), "")
+}
+
+// KeyValues returns the values of the primary key attributes found on the data.
+func (res *ResourceItem) KeyValues(data interface{}) map[string]interface{} {
+	record, _ := data.(map[string]interface{})
+
 	keys := make(map[string]interface{})
 	for key := range res.Properties.Keys {
-		if value, ok := data.(map[string]interface{})[key]; ok {
+		if value, ok := record[key]; ok {
 			keys[key] = value
 		}
 	}
 
-	response, err := dynamodbattribute.MarshalMap(keys)
+	return keys
+}
+
+// HasFullKey reports whether the data carries a value for every attribute of the primary key, so
+// it identifies a single item.
+func (res *ResourceItem) HasFullKey(data interface{}) bool {
+	record, ok := data.(map[string]interface{})
+	if !ok || len(res.Properties.Keys) == 0 {
+		return false
+	}
+
+	for key := range res.Properties.Keys {
+		if value, found := record[key]; !found || value == nil {
+			return false
+		}
+		if _, prefix := record[key].(KeyPrefix); prefix {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HasPartitionKey reports whether the data carries the whole value of the partition key, which the
+// queries require. When the partition key is unknown, any key attribute is accepted.
+func (res *ResourceItem) HasPartitionKey(data interface{}) bool {
+	if res.Properties.HashKey == "" {
+		return res.HasKey(data)
+	}
+
+	record, _ := data.(map[string]interface{})
+	value, ok := record[res.Properties.HashKey]
+	if _, prefix := value.(KeyPrefix); prefix {
+		return false
+	}
+
+	return ok && value != nil
+}
+
+// HasKey reports whether the data carries a value for any attribute of the primary key.
+func (res *ResourceItem) HasKey(data interface{}) bool {
+	return len(res.KeyValues(data)) > 0
+}
+
+// CanScan reports whether the table can be listed by the reads without any key attribute.
+func (res *ResourceItem) CanScan() bool {
+	return res.Properties.Scan != nil && res.Properties.Scan.Enabled
+}
+
+// marshalAttributes converts the values into DynamoDB attributes of the types mapped from the schema
+// of the resource, prefixing their names. Without a schema, the values are marshalled as received.
+func (res *ResourceItem) marshalAttributes(data map[string]interface{}, prefix string) (map[string]*dynamodb.AttributeValue, error) {
+	var (
+		values map[string]*dynamodb.AttributeValue
+		err    error
+	)
+
+	// the key prefixes are marshalled as strings
+	plain := make(map[string]interface{}, len(data))
+	for name, value := range data {
+		if p, ok := value.(KeyPrefix); ok {
+			value = string(p)
+		}
+		plain[name] = value
+	}
+
+	if s, schemaErr := res.Schema(); schemaErr == nil {
+		values, err = s.AttributeValues(plain)
+	} else {
+		values, err = dynamodbattribute.MarshalMap(plain)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return response, nil
+	if prefix == "" {
+		return values, nil
+	}
+
+	prefixed := make(map[string]*dynamodb.AttributeValue, len(values))
+	for key, value := range values {
+		prefixed[prefix+key] = value
+	}
+
+	return prefixed, nil
+}
+
+// AttributeValueAt converts the value of the attribute found on the path into the DynamoDB
+// attribute of the type mapped from the schema of the resource. The values of the attributes the
+// schema doesn't declare, or of the resources without a schema, are marshalled as received.
+func (res *ResourceItem) AttributeValueAt(path []string, value interface{}) (*dynamodb.AttributeValue, error) {
+	if s, err := res.Schema(); err == nil {
+		if target := s.Element().At(path); target != nil {
+			return target.AttributeValue(value)
+		}
+	}
+
+	return dynamodbattribute.Marshal(value)
 }
 
 // Returns the names of all attributes
@@ -55,17 +160,7 @@ func (res *ResourceItem) GetAllAttributeValues(data interface{}) (map[string]*dy
 		return nil, errors.New("the resource is not a dynamodb table")
 	}
 
-	temp := make(map[string]interface{})
-	for key, value := range data.(map[string]interface{}) {
-		temp[fmt.Sprintf(":%s", key)] = aws.String(fmt.Sprintf("%v", value))
-	}
-
-	values, err := dynamodbattribute.MarshalMap(temp)
-	if err != nil {
-		return nil, err
-	}
-
-	return values, nil
+	return res.marshalAttributes(data.(map[string]interface{}), ":")
 }
 
 // Returns the names of all attributes, except those who make up the primary key
@@ -93,16 +188,11 @@ func (res *ResourceItem) GetAttributeValues(data interface{}) (map[string]*dynam
 	temp := make(map[string]interface{})
 	for key, value := range data.(map[string]interface{}) {
 		if _, ok := res.Properties.Keys[key]; !ok {
-			temp[fmt.Sprintf(":%s", key)] = aws.String(fmt.Sprintf("%v", value))
+			temp[key] = value
 		}
 	}
 
-	values, err := dynamodbattribute.MarshalMap(temp)
-	if err != nil {
-		return nil, err
-	}
-
-	return values, nil
+	return res.marshalAttributes(temp, ":")
 }
 
 func (res *ResourceItem) GetKeyAttributeNames(data interface{}) (map[string]*string, error) {
@@ -128,16 +218,11 @@ func (res *ResourceItem) GetKeyAttributeValues(data interface{}) (map[string]*dy
 	temp := make(map[string]interface{})
 	for key, value := range data.(map[string]interface{}) {
 		if _, ok := res.Properties.Keys[key]; ok {
-			temp[fmt.Sprintf(":%s", key)] = aws.String(fmt.Sprintf("%v", value))
+			temp[key] = value
 		}
 	}
 
-	values, err := dynamodbattribute.MarshalMap(temp)
-	if err != nil {
-		return nil, err
-	}
-
-	return values, nil
+	return res.marshalAttributes(temp, ":")
 }
 
 func (res *ResourceItem) GetUpdateExpression(data interface{}) (string, error) {
@@ -152,14 +237,88 @@ func (res *ResourceItem) GetUpdateExpression(data interface{}) (string, error) {
 	return fmt.Sprintf("SET %s", strings.Join(commands, ",")), nil
 }
 
+// GetKeyConditions returns the key condition of the queries by the key attributes of the data. The
+// sort keys composed only up to a missing field select the items starting with them, while the
+// partition key must be informed completely.
 func (res *ResourceItem) GetKeyConditions(data interface{}) (string, error) {
+	if !res.HasPartitionKey(data) {
+		return "", fmt.Errorf("the partition key %s is required to query the table", res.Properties.HashKey)
+	}
+
 	conditions := []string{}
 
-	for key := range data.(map[string]interface{}) {
-		if _, ok := res.Properties.Keys[key]; ok {
+	for key, value := range data.(map[string]interface{}) {
+		if _, ok := res.Properties.Keys[key]; !ok {
+			continue
+		}
+
+		if _, prefix := value.(KeyPrefix); prefix {
+			conditions = append(conditions, fmt.Sprintf("begins_with(#%s, :%s)", key, key))
+		} else {
 			conditions = append(conditions, fmt.Sprintf("#%s = :%s", key, key))
 		}
 	}
+	sort.Strings(conditions)
 
 	return strings.Join(conditions, " AND "), nil
 }
+
+// GetFilterExpression returns the conditions of 'Filters' combined, including the one hiding the
+// deleted items, or an empty string when there are none
+func (res *ResourceItem) GetFilterExpression() string {
+	filters := res.Properties.Filter
+	if condition := res.TombstoneCondition(); condition != "" {
+		filters = append(filters[:len(filters):len(filters)], condition)
+	}
+
+	return strings.Join(filters, " AND ")
+}
+
+// GetProjectionExpression returns the attributes of 'OutputColumns' and their names, or an empty
+// expression when all the attributes are read
+func (res *ResourceItem) GetProjectionExpression() (string, map[string]*string) {
+	names := make(map[string]*string, len(res.Properties.OutputColumns))
+	columns := make([]string, 0, len(res.Properties.OutputColumns))
+
+	for _, column := range res.Properties.OutputColumns {
+		names["#"+column] = aws.String(column)
+		columns = append(columns, "#"+column)
+	}
+
+	// the version of the items projected is read to resolve them from the schema they were written
+	if attribute := res.SchemaVersionAttribute(); len(columns) > 0 && attribute != "" && names["#"+attribute] == nil {
+		names["#"+attribute] = aws.String(attribute)
+		columns = append(columns, "#"+attribute)
+	}
+
+	return strings.Join(columns, ", "), names
+}
+
+// GetFilterAttributeNames returns the attribute names referenced by the filters, like '#Status'
+func (res *ResourceItem) GetFilterAttributeNames() map[string]*string {
+	names := make(map[string]*string)
+	for _, match := range attributeName.FindAllStringSubmatch(res.GetFilterExpression(), -1) {
+		names[match[0]] = aws.String(match[1])
+	}
+
+	return names
+}
+
+// GetFilterAttributeValues returns the values used by the filters: the fixed 'FilterValues' and the
+// 'FilterExpressions' evaluated with the request received, typed by the schema of the resource
+func (res *ResourceItem) GetFilterAttributeValues(req mapping.Request) (map[string]*dynamodb.AttributeValue, error) {
+	values := make(map[string]interface{}, len(res.Properties.FilterValues)+len(res.filters))
+	for name, value := range res.Properties.FilterValues {
+		values[name] = value
+	}
+
+	for name, program := range res.filters {
+		value, err := program.Eval(req)
+		if err != nil {
+			return nil, fmt.Errorf("filter value %s: %v", name, err)
+		}
+		values[name] = value
+	}
+
+	return res.marshalAttributes(values, ":")
+}
@@ -2,13 +2,11 @@ package config
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
-	schema "github.com/xeipuuv/gojsonschema"
 	"gopkg.in/yaml.v2"
 )
 
@@ -26,52 +24,85 @@ func (c *Config) FromFile(path string) error {
 		return err
 	}
 
-	return nil
+	c.SetSource(OSSource{})
+	return c.Compile()
 }
 
-// Valida formato do json de acordo com o schema
-func (res *ResourceItem) Validate(data map[string]interface{}) bool {
-	content, err := os.ReadFile(res.ObjectPathSchema)
+// Valida formato do json de acordo com o schema, retornando um *schema.ValidationError com os
+// campos inválidos
+func (res *ResourceItem) Validate(data map[string]interface{}) error {
+	s, err := res.Schema()
 	if err != nil {
-		fmt.Printf("failed open config file: %v", err)
-		return false
-	}
-
-	schemaLoader := schema.NewStringLoader(string(content))
-	documentLoader := schema.NewGoLoader(data)
-
-	result, err := schema.Validate(schemaLoader, documentLoader)
-	if err != nil {
-		fmt.Println("Erro durante a validação:", err)
-		return false
+		return err
 	}
 
-	return result.Valid()
+	return s.Element().Validate(data)
 }
 
 // Pega o schema do arquivo json
 func (res *ResourceItem) UnmarshalSchema() (map[string]interface{}, error) {
-	model, err := os.ReadFile(res.ObjectPathSchema)
+	s, err := res.Schema()
 	if err != nil {
 		return nil, err
 	}
 
 	var jsonMap map[string]interface{}
-	json.Unmarshal(model, &jsonMap)
+	json.Unmarshal(s.Document(), &jsonMap)
 
 	return jsonMap, nil
 }
 
-// Estrutura dados para criação de registro
+// MarshalItem converts the data into an item typed by the schema of the resource, validating it, or
+// marshals it as received when the resource has no schema. The key attributes composed only up to a
+// missing field are refused, since they don't identify an item.
+func (res *ResourceItem) MarshalItem(data interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	record, ok := data.(map[string]interface{})
+	if !ok && res.HasSchema() {
+		return nil, fmt.Errorf("unsupported data structure: %T", data)
+	}
+
+	for name, value := range record {
+		if _, prefix := value.(KeyPrefix); prefix {
+			return nil, fmt.Errorf("the key attribute %s is incomplete", name)
+		}
+	}
+
+	if !res.HasSchema() {
+		return dynamodbattribute.MarshalMap(data)
+	}
+
+	return res.MarshalMap(record)
+}
+
+// Estrutura dados para criação de registro, usando os tipos do DynamoDB definidos pelo schema
 func (res *ResourceItem) MarshalMap(data map[string]interface{}) (map[string]*dynamodb.AttributeValue, error) {
-	if !res.Validate(data) {
-		return nil, errors.New("unsupported data structure")
+	s, err := res.Schema()
+	if err != nil {
+		return nil, err
 	}
 
-	response, err := dynamodbattribute.MarshalMap(data)
+	// the attributes composed by the key templates are kept, even when the schema doesn't declare them
+	fields, composed := data, map[string]interface{}{}
+	if res.hasTemplates() {
+		fields = make(map[string]interface{}, len(data))
+		for name, value := range data {
+			if res.isComposed(name) && s.Element().Field(name) == nil {
+				composed[name] = value
+			} else {
+				fields[name] = value
+			}
+		}
+	}
+
+	record, err := s.Element().Coerce(fields)
 	if err != nil {
 		return nil, err
 	}
 
-	return response, nil
+	item := record.(map[string]interface{})
+	for name, value := range composed {
+		item[name] = value
+	}
+
+	return s.AttributeValues(item)
 }
@@ -0,0 +1,515 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// keyField matches the fields of the key templates, like '{UserID}'
+var keyField = regexp.MustCompile(`\{([^{}]+)\}`)
+
+type (
+	// Entity is a type of item stored on a table shared by several types (single-table design).
+	// Each key attribute of the table is composed from the fields of the records by a template of
+	// 'Keys', like 'USER#{UserID}' or 'ORDER#{OrderDate}#{OrderID}', or is a constant, like 'PROFILE'.
+	Entity struct {
+		Keys map[string]string `yaml:"Keys"`
+
+		templates map[string]*keyTemplate
+	}
+
+	// keyTemplate composes the value of a key attribute, alternating its literals and the values of
+	// its fields. The separators are the characters of the literals other than letters and digits.
+	keyTemplate struct {
+		literals   []string
+		fields     []string
+		separators string
+		pattern    *regexp.Regexp
+	}
+
+	// KeyPrefix is the value of a key attribute whose template was composed only up to its first
+	// missing field, which is read with begins_with.
+	KeyPrefix string
+)
+
+// parseKeyTemplate parses the template of a key attribute
+func parseKeyTemplate(template string) (*keyTemplate, error) {
+	t := &keyTemplate{}
+	pattern := strings.Builder{}
+	pattern.WriteString("^")
+
+	last := 0
+	for _, match := range keyField.FindAllStringSubmatchIndex(template, -1) {
+		literal := template[last:match[0]]
+		if len(t.fields) > 0 && literal == "" {
+			return nil, fmt.Errorf("the fields of the key template %q must be separated", template)
+		}
+
+		t.literals = append(t.literals, literal)
+		t.fields = append(t.fields, template[match[2]:match[3]])
+		pattern.WriteString(regexp.QuoteMeta(literal) + "(.*?)")
+		last = match[1]
+	}
+	t.literals = append(t.literals, template[last:])
+	pattern.WriteString(regexp.QuoteMeta(template[last:]) + "$")
+
+	if strings.ContainsAny(strings.Join(t.literals, ""), "{}") {
+		return nil, fmt.Errorf("invalid key template %q", template)
+	}
+
+	for _, r := range strings.Join(t.literals, "") {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(t.separators, r) {
+			t.separators += string(r)
+		}
+	}
+
+	var err error
+	t.pattern, err = regexp.Compile(pattern.String())
+	return t, err
+}
+
+// informed reports whether the record informs any field of the template
+func (t *keyTemplate) informed(record map[string]interface{}) bool {
+	for _, field := range t.fields {
+		if value, ok := record[field]; ok && value != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// check fails when a field of the record holds a separator of the template, which would make the
+// value composed ambiguous, like the OrderDate '2024-01-02#7' of 'ORDER#{OrderDate}#{OrderID}'
+func (t *keyTemplate) check(record map[string]interface{}) error {
+	if t.separators == "" {
+		return nil
+	}
+
+	for _, field := range t.fields {
+		if value, ok := record[field]; ok && value != nil && strings.ContainsAny(fmt.Sprint(value), t.separators) {
+			return fmt.Errorf("the field %s can't hold the characters %q, which separate the fields of the keys", field, t.separators)
+		}
+	}
+
+	return nil
+}
+
+// compose returns the value of the key attribute composed from the record, which is complete when
+// the record informs every field of the template, or only a prefix otherwise
+func (t *keyTemplate) compose(record map[string]interface{}) (string, bool) {
+	value := strings.Builder{}
+
+	for i, field := range t.fields {
+		value.WriteString(t.literals[i])
+
+		v, ok := record[field]
+		if !ok || v == nil {
+			return value.String(), false
+		}
+		value.WriteString(fmt.Sprint(v))
+	}
+	value.WriteString(t.literals[len(t.fields)])
+
+	return value.String(), true
+}
+
+// value returns the value of the key attribute composed from the record, like compose, except that
+// a template made of a single field keeps the type of its value
+func (t *keyTemplate) value(record map[string]interface{}) (interface{}, bool) {
+	if len(t.fields) == 1 && t.literals[0] == "" && t.literals[1] == "" {
+		value, ok := record[t.fields[0]]
+		if !ok || value == nil {
+			return "", false
+		}
+		return value, true
+	}
+
+	return t.compose(record)
+}
+
+// compile parses the templates of the entity
+func (e *Entity) compile() error {
+	e.templates = make(map[string]*keyTemplate, len(e.Keys))
+
+	for attribute, template := range e.Keys {
+		t, err := parseKeyTemplate(template)
+		if err != nil {
+			return err
+		}
+		e.templates[attribute] = t
+	}
+
+	return nil
+}
+
+// informed reports whether the record informs every field of the templates of the entity
+func (e *Entity) informed(record map[string]interface{}) bool {
+	for _, t := range e.templates {
+		if _, complete := t.compose(record); !complete {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compileKeys parses the key templates of the connector and of its entities, and identifies the
+// partition and the sort keys of the table. The entities share the key attributes of the table,
+// which are taken from the templates when 'Keys' is not informed.
+func (res *ResourceItem) compileKeys() error {
+	props := &res.Properties
+	res.entity = nil
+
+	// the templates are declared apart, so the values of 'Keys' are never read as templates
+	for attribute, value := range props.Keys {
+		if keyField.MatchString(value) {
+			return fmt.Errorf("the key %s has the template %q, which must be declared by KeyTemplates", attribute, value)
+		}
+	}
+
+	if len(props.KeyTemplates) > 0 {
+		if len(props.Entities) > 0 {
+			return fmt.Errorf("the key templates can't be declared by both KeyTemplates and Entities")
+		}
+
+		res.entity = &Entity{Keys: props.KeyTemplates}
+		if err := res.entity.compile(); err != nil {
+			return err
+		}
+
+		if len(props.Keys) == 0 {
+			props.Keys = make(map[string]string, len(props.KeyTemplates))
+			for attribute := range props.KeyTemplates {
+				props.Keys[attribute] = "EQ"
+			}
+		}
+		for attribute := range props.KeyTemplates {
+			if _, ok := props.Keys[attribute]; !ok {
+				return fmt.Errorf("the template of %s doesn't compose a key attribute of the table", attribute)
+			}
+		}
+	}
+
+	if len(props.Entities) > 0 {
+		if err := res.compileEntities(); err != nil {
+			return err
+		}
+	}
+
+	return res.compileKeySchema()
+}
+
+// compileEntities parses the key templates of the entities sharing the table
+func (res *ResourceItem) compileEntities() error {
+	props := &res.Properties
+
+	if props.EntityAttribute == "" {
+		return fmt.Errorf("the entities require an EntityAttribute")
+	}
+	if props.EntityType != "" && props.Entities[props.EntityType] == nil {
+		return fmt.Errorf("the entity type %s is not declared", props.EntityType)
+	}
+
+	names := make([]string, 0, len(props.Entities))
+	for name := range props.Entities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		entity := props.Entities[name]
+		if entity == nil || len(entity.Keys) == 0 {
+			return fmt.Errorf("entity %s has no keys", name)
+		}
+		if err := entity.compile(); err != nil {
+			return fmt.Errorf("entity %s: %v", name, err)
+		}
+
+		if len(props.Keys) == 0 {
+			props.Keys = make(map[string]string, len(entity.Keys))
+			for attribute := range entity.Keys {
+				props.Keys[attribute] = "EQ"
+			}
+		}
+
+		if len(entity.Keys) != len(props.Keys) {
+			return fmt.Errorf("entity %s doesn't compose the key attributes of the table", name)
+		}
+		for attribute := range entity.Keys {
+			if _, ok := props.Keys[attribute]; !ok {
+				return fmt.Errorf("entity %s composes %s, which is not a key attribute of the table", name, attribute)
+			}
+		}
+	}
+
+	return nil
+}
+
+// compileKeySchema identifies the partition and the sort keys among the key attributes. They are
+// inferred from a single key attribute, or from one of them, and are required by the templates.
+func (res *ResourceItem) compileKeySchema() error {
+	props := &res.Properties
+
+	if len(props.Keys) > 2 {
+		return fmt.Errorf("the table has %d key attributes, the limit is a partition and a sort key", len(props.Keys))
+	}
+	for _, name := range []string{props.HashKey, props.RangeKey} {
+		if _, ok := props.Keys[name]; name != "" && !ok {
+			return fmt.Errorf("the key %s is not one of the Keys", name)
+		}
+	}
+	if props.HashKey != "" && props.HashKey == props.RangeKey {
+		return fmt.Errorf("the key %s can't be both the HashKey and the RangeKey", props.HashKey)
+	}
+
+	for name := range props.Keys {
+		switch {
+		case props.HashKey == "" && name != props.RangeKey && (len(props.Keys) == 1 || props.RangeKey != ""):
+			props.HashKey = name
+		case props.RangeKey == "" && props.HashKey != "" && name != props.HashKey:
+			props.RangeKey = name
+		}
+	}
+
+	if props.HashKey == "" && props.RangeKey != "" {
+		return fmt.Errorf("the RangeKey %s requires a HashKey", props.RangeKey)
+	}
+	if props.HashKey == "" && res.hasTemplates() {
+		return fmt.Errorf("the HashKey is required by the key templates")
+	}
+
+	return nil
+}
+
+// recordEntity returns the entity of a record received: the 'EntityType' of the resource, which
+// the 'EntityAttribute' of the record can't contradict, the entity named by the record, the only
+// entity declared or the only entity whose fields are all informed by the record. The entity is
+// nil when the keys are not composed by templates or the record matches several entities.
+func (res *ResourceItem) recordEntity(record map[string]interface{}) (string, *Entity, error) {
+	props := &res.Properties
+
+	named := ""
+	if value, ok := record[props.EntityAttribute]; props.EntityAttribute != "" && ok && value != nil {
+		named = fmt.Sprint(value)
+	}
+	if props.EntityType != "" && named != "" && named != props.EntityType {
+		return "", nil, fmt.Errorf("the %s %s doesn't match the entity %s of the resource", props.EntityAttribute, named, props.EntityType)
+	}
+
+	if len(props.Entities) == 0 {
+		return props.EntityType, res.entity, nil
+	}
+
+	switch {
+	case props.EntityType != "":
+		return props.EntityType, props.Entities[props.EntityType], nil
+	case named != "":
+		entity := props.Entities[named]
+		if entity == nil {
+			return "", nil, fmt.Errorf("the entity %s is not declared", named)
+		}
+		return named, entity, nil
+	}
+
+	found := ""
+	for name, entity := range props.Entities {
+		if len(props.Entities) > 1 && !entity.informed(record) {
+			continue
+		}
+		if found != "" {
+			return "", nil, nil
+		}
+		found = name
+	}
+
+	return found, props.Entities[found], nil
+}
+
+// entityOf returns the entity of an item read from the table: the one named by its
+// 'EntityAttribute' or, when it is missing, the entity of the records of the resource
+func (res *ResourceItem) entityOf(item map[string]interface{}) (string, *Entity) {
+	props := &res.Properties
+	if value, ok := item[props.EntityAttribute]; len(props.Entities) > 0 && ok {
+		name := fmt.Sprint(value)
+		return name, props.Entities[name]
+	}
+
+	name, entity, _ := res.recordEntity(item)
+	return name, entity
+}
+
+// ComposeKeys returns the record with the key attributes composed by the templates of its entity,
+// and the entity in the 'EntityAttribute'. The key attributes informed by the record are replaced,
+// so the items are always addressed by the fields of their templates. The keys are only composed
+// when the record informs a field of their templates; the other templates are composed up to their
+// first missing field, so the reads of a partition can select the items of an entity, like
+// 'ORDER#'. The records of resources without templates are kept. It fails when the record names
+// another entity than the one of the resource or a field holds a separator of its templates.
+func (res *ResourceItem) ComposeKeys(data interface{}) (interface{}, error) {
+	record, ok := data.(map[string]interface{})
+	if !ok || !res.hasTemplates() {
+		return data, nil
+	}
+
+	name, entity, err := res.recordEntity(record)
+	if err != nil {
+		return nil, err
+	}
+
+	composed := make(map[string]interface{}, len(record)+len(res.Properties.Keys)+1)
+	for field, value := range record {
+		if _, key := res.Properties.Keys[field]; !key {
+			composed[field] = value
+		}
+	}
+	if entity == nil {
+		return composed, nil
+	}
+
+	informed := false
+	for _, t := range entity.templates {
+		if err := t.check(record); err != nil {
+			return nil, err
+		}
+		informed = informed || t.informed(record)
+	}
+	if !informed {
+		return composed, nil
+	}
+
+	for attribute, t := range entity.templates {
+		switch value, complete := t.compose(record); {
+		case complete:
+			composed[attribute] = value
+		case value != "":
+			composed[attribute] = KeyPrefix(value)
+		}
+	}
+
+	if attribute := res.Properties.EntityAttribute; attribute != "" && name != "" {
+		composed[attribute] = name
+	}
+
+	return composed, nil
+}
+
+// DecomposeItem fills the fields of an item read from the table with the values parsed from its key
+// attributes, by the templates of its entity, and removes the composed attributes. The fields
+// stored by the item are kept, and the values parsed are typed by the schema of the resource.
+func (res *ResourceItem) DecomposeItem(item map[string]interface{}) map[string]interface{} {
+	_, entity := res.entityOf(item)
+	if entity == nil {
+		return item
+	}
+
+	for attribute, t := range entity.templates {
+		value, ok := item[attribute].(string)
+		if !ok {
+			continue
+		}
+
+		matches := t.pattern.FindStringSubmatch(value)
+		if matches == nil {
+			continue
+		}
+
+		for i, field := range t.fields {
+			if _, found := item[field]; !found {
+				item[field] = res.fieldValue(field, matches[i+1])
+			}
+		}
+		delete(item, attribute)
+	}
+
+	return item
+}
+
+// fieldValue converts the value of a field parsed from a key into the type of the field
+func (res *ResourceItem) fieldValue(field string, value string) interface{} {
+	s, err := res.Schema()
+	if err != nil {
+		return value
+	}
+
+	f := s.Element().Field(field)
+	if f == nil {
+		return value
+	}
+
+	typed, err := f.Schema.Coerce(value)
+	if err != nil {
+		return value
+	}
+
+	return typed
+}
+
+// ItemKey returns the key of the item of the record in the shape of the records: the key attributes
+// or, when they are composed by templates, the fields composing them and the entity.
+func (res *ResourceItem) ItemKey(data interface{}) map[string]interface{} {
+	keys := res.KeyValues(data)
+
+	record, _ := data.(map[string]interface{})
+	if attribute := res.Properties.EntityAttribute; attribute != "" {
+		if value, ok := record[attribute]; ok {
+			keys[attribute] = value
+		}
+	}
+
+	return res.DecomposeItem(keys)
+}
+
+// hasTemplates reports whether the key attributes are composed by templates
+func (res *ResourceItem) hasTemplates() bool {
+	return res.entity != nil || len(res.Properties.Entities) > 0
+}
+
+// isComposed reports whether the attribute is composed from the fields of the records, like the
+// key attributes composed by templates and the entity
+func (res *ResourceItem) isComposed(name string) bool {
+	if name == res.Properties.EntityAttribute {
+		return true
+	}
+	if len(res.Properties.Entities) > 0 {
+		_, ok := res.Properties.Keys[name]
+		return ok
+	}
+
+	return res.entity != nil && res.entity.templates[name] != nil
+}
+
+// KeyFields returns the key attributes of the table and the fields composing them, which identify
+// the items and can't be changed by the updates.
+func (res *ResourceItem) KeyFields() []string {
+	fields := map[string]bool{}
+	for attribute := range res.Properties.Keys {
+		fields[attribute] = true
+	}
+
+	entities := []*Entity{res.entity}
+	for _, entity := range res.Properties.Entities {
+		entities = append(entities, entity)
+	}
+	for _, entity := range entities {
+		if entity == nil {
+			continue
+		}
+		for _, t := range entity.templates {
+			for _, field := range t.fields {
+				fields[field] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,274 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const orderKeys = `
+HashKey: PK
+KeyTemplates:
+  PK: USER#{UserID}
+  SK: ORDER#{OrderDate}#{OrderID}
+`
+
+func TestCompileKeys(t *testing.T) {
+	res := loadConnector(t, orderKeys)
+
+	props := res.Properties
+	if props.HashKey != "PK" || props.RangeKey != "SK" || len(props.Keys) != 2 {
+		t.Errorf("compileKeys() = %s, %s, %v, want the PK and SK keys", props.HashKey, props.RangeKey, props.Keys)
+	}
+	if got := res.KeyFields(); !reflect.DeepEqual(got, []string{"OrderDate", "OrderID", "PK", "SK", "UserID"}) {
+		t.Errorf("KeyFields() = %v, want the keys and the fields composing them", got)
+	}
+
+	single := loadConnector(t, "Keys:\n  UserID: EQ\n")
+	if single.Properties.HashKey != "UserID" || single.Properties.RangeKey != "" {
+		t.Errorf("compileKeys() = %s, %s, want the single key as the HashKey", single.Properties.HashKey, single.Properties.RangeKey)
+	}
+}
+
+func TestCompileKeysErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties string
+		want       string
+	}{
+		{name: "template in keys", properties: "Keys:\n  PK: USER#{UserID}\n", want: "must be declared by KeyTemplates"},
+		{name: "templates without hash key", properties: "KeyTemplates:\n  PK: USER#{UserID}\n  SK: PROFILE\n", want: "HashKey is required"},
+		{name: "unknown hash key", properties: "HashKey: ID\nKeys:\n  PK: EQ\n", want: "is not one of the Keys"},
+		{name: "same hash and range", properties: "HashKey: PK\nRangeKey: PK\nKeys:\n  PK: EQ\n", want: "both the HashKey and the RangeKey"},
+		{name: "too many keys", properties: "Keys:\n  A: EQ\n  B: EQ\n  C: EQ\n", want: "the limit is a partition and a sort key"},
+		{name: "adjacent fields", properties: "HashKey: PK\nKeyTemplates:\n  PK: '{UserID}{OrderID}'\n", want: "must be separated"},
+		{name: "template of another key", properties: "HashKey: PK\nKeys:\n  PK: EQ\nKeyTemplates:\n  SK: X#{OrderID}\n", want: "doesn't compose a key attribute"},
+		{name: "entities without attribute", properties: "HashKey: PK\nEntities:\n  User:\n    Keys:\n      PK: USER#{UserID}\n", want: "require an EntityAttribute"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadConfig(tt.properties); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadFrom() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposeKeys(t *testing.T) {
+	res := loadConnector(t, orderKeys)
+
+	tests := []struct {
+		name    string
+		record  map[string]interface{}
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name:   "whole key",
+			record: map[string]interface{}{"UserID": "42", "OrderDate": "2024-01-02", "OrderID": 7.0},
+			want: map[string]interface{}{
+				"UserID": "42", "OrderDate": "2024-01-02", "OrderID": 7.0,
+				"PK": "USER#42", "SK": "ORDER#2024-01-02#7",
+			},
+		},
+		{
+			name:   "prefix of the sort key",
+			record: map[string]interface{}{"UserID": "42", "OrderDate": "2024-01-02"},
+			want: map[string]interface{}{
+				"UserID": "42", "OrderDate": "2024-01-02",
+				"PK": "USER#42", "SK": KeyPrefix("ORDER#2024-01-02#"),
+			},
+		},
+		{
+			name:   "keys informed are replaced",
+			record: map[string]interface{}{"UserID": "42", "PK": "USER#1", "SK": "ORDER#x#1"},
+			want:   map[string]interface{}{"UserID": "42", "PK": "USER#42", "SK": KeyPrefix("ORDER#")},
+		},
+		{
+			name:   "keys informed without their fields",
+			record: map[string]interface{}{"PK": "USER#1", "Total": 10.0},
+			want:   map[string]interface{}{"Total": 10.0},
+		},
+		{
+			name:   "no field of the templates",
+			record: map[string]interface{}{"Total": 10.0},
+			want:   map[string]interface{}{"Total": 10.0},
+		},
+		{
+			name:    "separator in a field",
+			record:  map[string]interface{}{"UserID": "42", "OrderDate": "2024-01-02#9", "OrderID": 7.0},
+			wantErr: "can't hold the characters",
+		},
+		{
+			name:    "separator in the partition",
+			record:  map[string]interface{}{"UserID": "42#ORDER"},
+			wantErr: "can't hold the characters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := res.ComposeKeys(tt.record)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ComposeKeys() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ComposeKeys() = %#v, %v, want %#v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecomposeItem(t *testing.T) {
+	res := loadConnector(t, orderKeys)
+
+	got := res.DecomposeItem(map[string]interface{}{"PK": "USER#42", "SK": "ORDER#2024-01-02#7", "Total": 10.0})
+
+	// the values parsed are typed by the schema
+	want := map[string]interface{}{"UserID": "42", "OrderDate": "2024-01-02", "OrderID": int64(7), "Total": 10.0}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecomposeItem() = %#v, want %#v", got, want)
+	}
+
+	// the keys not written by the templates are kept
+	got = res.DecomposeItem(map[string]interface{}{"PK": "USER#42", "SK": "INVOICE#1"})
+	if want := map[string]interface{}{"UserID": "42", "SK": "INVOICE#1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DecomposeItem() = %#v, want %#v", got, want)
+	}
+}
+
+const userEntities = `
+HashKey: PK
+EntityAttribute: Type
+Entities:
+  User:
+    Keys:
+      PK: USER#{UserID}
+      SK: PROFILE
+  Order:
+    Keys:
+      PK: USER#{UserID}
+      SK: ORDER#{OrderID}
+`
+
+func TestEntities(t *testing.T) {
+	res := loadConnector(t, userEntities)
+
+	tests := []struct {
+		name    string
+		record  map[string]interface{}
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name:   "entity of the record",
+			record: map[string]interface{}{"Type": "Order", "UserID": "42", "OrderID": 7.0},
+			want:   map[string]interface{}{"UserID": "42", "OrderID": 7.0, "PK": "USER#42", "SK": "ORDER#7", "Type": "Order"},
+		},
+		{
+			name:   "constant sort key",
+			record: map[string]interface{}{"Type": "User", "UserID": "42"},
+			want:   map[string]interface{}{"UserID": "42", "PK": "USER#42", "SK": "PROFILE", "Type": "User"},
+		},
+		{
+			// the fields of both entities are informed, so the entity is unknown
+			name:   "ambiguous",
+			record: map[string]interface{}{"UserID": "42", "OrderID": 7.0, "SK": "PROFILE"},
+			want:   map[string]interface{}{"UserID": "42", "OrderID": 7.0},
+		},
+		{
+			name:    "undeclared entity",
+			record:  map[string]interface{}{"Type": "Invoice", "UserID": "42"},
+			wantErr: "is not declared",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := res.ComposeKeys(tt.record)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ComposeKeys() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ComposeKeys() = %#v, %v, want %#v", got, err, tt.want)
+			}
+		})
+	}
+
+	item := res.DecomposeItem(map[string]interface{}{"PK": "USER#42", "SK": "PROFILE", "Type": "User"})
+	if want := map[string]interface{}{"UserID": "42", "Type": "User"}; !reflect.DeepEqual(item, want) {
+		t.Errorf("DecomposeItem() = %#v, want %#v", item, want)
+	}
+}
+
+func TestEntityTypeWinsOverTheRecord(t *testing.T) {
+	res := loadConnector(t, userEntities+"EntityType: Order\n")
+
+	got, err := res.ComposeKeys(map[string]interface{}{"UserID": "42", "OrderID": 7.0})
+	if want := map[string]interface{}{"UserID": "42", "OrderID": 7.0, "PK": "USER#42", "SK": "ORDER#7", "Type": "Order"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ComposeKeys() = %#v, %v, want %#v", got, err, want)
+	}
+
+	if _, err := res.ComposeKeys(map[string]interface{}{"Type": "User", "UserID": "42"}); err == nil ||
+		!strings.Contains(err.Error(), "doesn't match the entity Order") {
+		t.Errorf("ComposeKeys() error = %v, want the entity of the record refused", err)
+	}
+
+	// the items read keep the entity they were written with
+	item := res.DecomposeItem(map[string]interface{}{"PK": "USER#42", "SK": "PROFILE", "Type": "User"})
+	if want := map[string]interface{}{"UserID": "42", "Type": "User"}; !reflect.DeepEqual(item, want) {
+		t.Errorf("DecomposeItem() = %#v, want %#v", item, want)
+	}
+}
+
+func TestPartitionKeyAndConditions(t *testing.T) {
+	res := loadConnector(t, orderKeys)
+
+	tests := []struct {
+		name      string
+		record    map[string]interface{}
+		full      bool
+		partition bool
+		condition string
+	}{
+		{name: "whole key", record: map[string]interface{}{"UserID": "42", "OrderDate": "d", "OrderID": 1.0}, full: true, partition: true, condition: "#PK = :PK AND #SK = :SK"},
+		{name: "partition", record: map[string]interface{}{"UserID": "42"}, partition: true, condition: "#PK = :PK AND begins_with(#SK, :SK)"},
+		{name: "no partition", record: map[string]interface{}{"OrderID": 1.0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, err := res.ComposeKeys(tt.record)
+			if err != nil {
+				t.Fatalf("ComposeKeys() error = %v", err)
+			}
+
+			if got := res.HasFullKey(record); got != tt.full {
+				t.Errorf("HasFullKey() = %v, want %v", got, tt.full)
+			}
+			if got := res.HasPartitionKey(record); got != tt.partition {
+				t.Errorf("HasPartitionKey() = %v, want %v", got, tt.partition)
+			}
+
+			condition, err := res.GetKeyConditions(record)
+			if (err != nil) == tt.partition || condition != tt.condition {
+				t.Errorf("GetKeyConditions() = %q, %v, want %q", condition, err, tt.condition)
+			}
+		})
+	}
+
+	// the partition key composed only up to a missing field is not a partition
+	prefix := map[string]interface{}{"PK": KeyPrefix("USER#")}
+	if res.HasPartitionKey(prefix) {
+		t.Errorf("HasPartitionKey() = true for a prefix of the partition key")
+	}
+}
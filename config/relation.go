@@ -0,0 +1,225 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Types of the relations between the items.
+const (
+	RelationOneToMany = "OneToMany"
+	RelationOneToOne  = "OneToOne"
+)
+
+// DefaultRelationLimit is the number of items related to each item read when 'Limit' is not
+// informed.
+const DefaultRelationLimit = 100
+
+// Relation declares the items related to the items of the connector, which are nested in the
+// responses of the reads asking to expand it, like '?expand=orders'. The related items are the
+// ones whose key attributes match the values composed from the fields of each item by the templates
+// of 'Keys', like 'USER#{UserID}'.
+type Relation struct {
+	// Type is OneToMany (default), matching the items sharing a partition of the table or of the
+	// index, or OneToOne, matching a single item by a foreign key
+	Type string `yaml:"Type"`
+	// TableName is the table of the related items, the table of the connector by default
+	TableName string `yaml:"TableName"`
+	// IndexName is the global secondary index queried for the related items
+	IndexName string `yaml:"IndexName"`
+	// Keys are the key attributes of the related items and the templates of their values. The
+	// sort key templates missing fields of the item select the related items starting with their
+	// prefix, while the partition key template must be composed completely.
+	Keys map[string]string `yaml:"Keys"`
+	// HashKey is the partition key of the related items, on the table or on the index. It is
+	// required by the relations composing two key attributes of another table or of an index.
+	HashKey string `yaml:"HashKey"`
+	// Entity is the entity of the related items, declared by the 'Entities' of the connector,
+	// whose templates are used when 'Keys' is not informed
+	Entity string `yaml:"Entity"`
+	// Limit is the number of items related to each item
+	Limit int64 `yaml:"Limit"`
+
+	templates map[string]*keyTemplate
+	shared    bool
+}
+
+// compileRelations validates the relations of the connector and parses their key templates
+func (res *ResourceItem) compileRelations() error {
+	props := &res.Properties
+
+	names := make([]string, 0, len(props.Relations))
+	for name := range props.Relations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		rel := props.Relations[name]
+		if rel == nil {
+			return fmt.Errorf("relation %s is empty", name)
+		}
+
+		switch rel.Type {
+		case "":
+			rel.Type = RelationOneToMany
+		case RelationOneToMany, RelationOneToOne:
+		default:
+			return fmt.Errorf("relation %s has an invalid type %s", name, rel.Type)
+		}
+
+		if rel.TableName == "" {
+			rel.TableName = props.TableName
+		}
+		rel.shared = rel.TableName == props.TableName
+
+		if rel.Limit < 0 {
+			return fmt.Errorf("relation %s has an invalid limit %d", name, rel.Limit)
+		}
+		if rel.Limit == 0 {
+			rel.Limit = DefaultRelationLimit
+		}
+
+		keys := rel.Keys
+		if rel.Entity != "" {
+			entity := props.Entities[rel.Entity]
+			if entity == nil || !rel.shared {
+				return fmt.Errorf("relation %s: the entity %s is not declared by the table", name, rel.Entity)
+			}
+			if len(keys) == 0 {
+				keys = entity.Keys
+			}
+		}
+		if len(keys) == 0 {
+			return fmt.Errorf("relation %s has no keys", name)
+		}
+
+		rel.templates = make(map[string]*keyTemplate, len(keys))
+		for attribute, template := range keys {
+			t, err := parseKeyTemplate(template)
+			if err != nil {
+				return fmt.Errorf("relation %s: %v", name, err)
+			}
+			rel.templates[attribute] = t
+		}
+
+		if err := rel.compileHashKey(props); err != nil {
+			return fmt.Errorf("relation %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// compileHashKey identifies the partition key of the related items: the only key attribute of the
+// relation or, on the table of the connector, its partition key
+func (rel *Relation) compileHashKey(props *Properties) error {
+	if len(rel.templates) > 2 {
+		return fmt.Errorf("the relation has %d keys, the limit is a partition and a sort key", len(rel.templates))
+	}
+
+	if rel.HashKey == "" {
+		for attribute := range rel.templates {
+			if len(rel.templates) == 1 {
+				rel.HashKey = attribute
+			}
+		}
+		if rel.HashKey == "" && rel.shared && rel.IndexName == "" {
+			rel.HashKey = props.HashKey
+		}
+	}
+
+	if rel.HashKey == "" {
+		return fmt.Errorf("the HashKey of the related items is required")
+	}
+	if rel.templates[rel.HashKey] == nil {
+		return fmt.Errorf("the partition key %s is not composed by the keys", rel.HashKey)
+	}
+
+	return nil
+}
+
+// Relation returns the relation of the connector with the name received, or nil when it is not
+// declared.
+func (res *ResourceItem) Relation(name string) *Relation {
+	return res.Properties.Relations[name]
+}
+
+// Expansions parses the names of the relations asked to be expanded, separated by commas, like
+// 'orders,customer'.
+func (res *ResourceItem) Expansions(value string) ([]string, error) {
+	names := []string{}
+	seen := map[string]bool{}
+
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if res.Relation(name) == nil {
+			return nil, fmt.Errorf("unknown relation %q", name)
+		}
+
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// Shared reports whether the related items are stored by the table of the connector, so they are
+// read like its own items.
+func (rel *Relation) Shared() bool {
+	return rel.shared
+}
+
+// KeyAttributes returns the key attributes of the related items, sorted.
+func (rel *Relation) KeyAttributes() []string {
+	attributes := make([]string, 0, len(rel.templates))
+	for attribute := range rel.templates {
+		attributes = append(attributes, attribute)
+	}
+	sort.Strings(attributes)
+
+	return attributes
+}
+
+// Key returns the values of the key attributes of the items related to the item, composed by the
+// templates of the relation. The sort key template missing fields of the item is composed up to the
+// first one, as a KeyPrefix, and a template made of a single field keeps the type of its value. The
+// item has no related items when it doesn't inform the whole partition key.
+func (rel *Relation) Key(item map[string]interface{}) (map[string]interface{}, bool) {
+	key := make(map[string]interface{}, len(rel.templates))
+
+	for attribute, t := range rel.templates {
+		value, complete := t.value(item)
+		switch {
+		case complete:
+			key[attribute] = value
+		case attribute == rel.HashKey:
+			// the related items are queried within a single partition
+			return nil, false
+		case value != "":
+			key[attribute] = KeyPrefix(value.(string))
+		}
+	}
+
+	return key, true
+}
+
+// Complete reports whether the key composed for an item identifies a single related item, which is
+// read by its primary key instead of being queried.
+func (rel *Relation) Complete(key map[string]interface{}) bool {
+	if rel.Type != RelationOneToOne || rel.IndexName != "" || len(key) != len(rel.templates) {
+		return false
+	}
+
+	for _, value := range key {
+		if _, prefix := value.(KeyPrefix); prefix {
+			return false
+		}
+	}
+
+	return true
+}
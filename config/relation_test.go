@@ -0,0 +1,146 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const userRelations = `
+HashKey: PK
+EntityAttribute: Type
+Entities:
+  User:
+    Keys:
+      PK: USER#{UserID}
+      SK: PROFILE
+  Order:
+    Keys:
+      PK: USER#{UserID}
+      SK: ORDER#{OrderDate}#{OrderID}
+Relations:
+  orders:
+    Entity: Order
+    Limit: 10
+  customer:
+    Type: OneToOne
+    TableName: customers
+    Keys:
+      CustomerID: '{UserID}'
+  invoices:
+    TableName: invoices
+    IndexName: ByUser
+    HashKey: Owner
+    Keys:
+      Owner: USER#{UserID}
+      Issued: '{OrderDate}'
+`
+
+func TestCompileRelations(t *testing.T) {
+	res := loadConnector(t, userRelations)
+
+	orders := res.Relation("orders")
+	if orders.Type != RelationOneToMany || !orders.Shared() || orders.HashKey != "PK" || orders.Limit != 10 {
+		t.Errorf("orders = %+v, want a relation on the table of the connector", orders)
+	}
+	if got := orders.KeyAttributes(); !reflect.DeepEqual(got, []string{"PK", "SK"}) {
+		t.Errorf("KeyAttributes() = %v, want [PK SK]", got)
+	}
+
+	customer := res.Relation("customer")
+	if customer.Shared() || customer.HashKey != "CustomerID" || customer.Limit != DefaultRelationLimit {
+		t.Errorf("customer = %+v, want the single key as the HashKey", customer)
+	}
+}
+
+func TestCompileRelationsErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		relations string
+		want      string
+	}{
+		{name: "invalid type", relations: "  r:\n    Type: ManyToMany\n    Keys:\n      PK: X\n", want: "invalid type"},
+		{name: "no keys", relations: "  r:\n    TableName: other\n", want: "has no keys"},
+		{name: "unknown entity", relations: "  r:\n    Entity: Invoice\n", want: "is not declared"},
+		{name: "negative limit", relations: "  r:\n    Limit: -1\n    Keys:\n      PK: X\n", want: "invalid limit"},
+		{name: "hash key of another table", relations: "  r:\n    TableName: other\n    Keys:\n      A: '{UserID}'\n      B: X\n", want: "HashKey of the related items is required"},
+		{name: "hash key not composed", relations: "  r:\n    TableName: other\n    HashKey: C\n    Keys:\n      A: '{UserID}'\n", want: "is not composed by the keys"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadConfig("HashKey: PK\nKeys:\n  PK: EQ\n  SK: EQ\nRelations:\n" + tt.relations)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadFrom() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRelationKey(t *testing.T) {
+	res := loadConnector(t, userRelations)
+
+	tests := []struct {
+		name     string
+		relation string
+		item     map[string]interface{}
+		want     map[string]interface{}
+		found    bool
+		complete bool
+	}{
+		{
+			name:     "prefix of the sort key",
+			relation: "orders",
+			item:     map[string]interface{}{"UserID": "42"},
+			want:     map[string]interface{}{"PK": "USER#42", "SK": KeyPrefix("ORDER#")},
+			found:    true,
+		},
+		{
+			name:     "single field keeps its type",
+			relation: "customer",
+			item:     map[string]interface{}{"UserID": 42.0},
+			want:     map[string]interface{}{"CustomerID": 42.0},
+			found:    true,
+			complete: true,
+		},
+		{
+			name:     "index is queried",
+			relation: "invoices",
+			item:     map[string]interface{}{"UserID": "42", "OrderDate": "2024-01-02"},
+			want:     map[string]interface{}{"Owner": "USER#42", "Issued": "2024-01-02"},
+			found:    true,
+		},
+		{
+			name:     "missing partition",
+			relation: "orders",
+			item:     map[string]interface{}{"OrderID": "7"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rel := res.Relation(tt.relation)
+
+			key, found := rel.Key(tt.item)
+			if found != tt.found || (found && !reflect.DeepEqual(key, tt.want)) {
+				t.Fatalf("Key() = %#v, %v, want %#v, %v", key, found, tt.want, tt.found)
+			}
+			if found && rel.Complete(key) != tt.complete {
+				t.Errorf("Complete() = %v, want %v", !tt.complete, tt.complete)
+			}
+		})
+	}
+}
+
+func TestExpansions(t *testing.T) {
+	res := loadConnector(t, userRelations)
+
+	got, err := res.Expansions(" orders, customer,orders,")
+	if err != nil || !reflect.DeepEqual(got, []string{"orders", "customer"}) {
+		t.Errorf("Expansions() = %v, %v, want [orders customer]", got, err)
+	}
+
+	if _, err := res.Expansions("orders,payments"); err == nil {
+		t.Errorf("Expansions() error = nil, want the unknown relation")
+	}
+}
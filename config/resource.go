@@ -1,6 +1,17 @@
 package config
 
-import "github.com/aws/aws-sdk-go/service/dynamodb"
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/raywall/aws-lowcode-lambda-go/expression"
+	"github.com/raywall/aws-lowcode-lambda-go/generator"
+	"github.com/raywall/aws-lowcode-lambda-go/idempotency"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+	"github.com/raywall/aws-lowcode-lambda-go/registry"
+	"github.com/raywall/aws-lowcode-lambda-go/render"
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
 
 type (
 	Config struct {
@@ -10,15 +21,59 @@ type (
 	}
 
 	Resources struct {
-		Receiver  ResourceItem `yaml:"Receiver"`
-		Connector ResourceItem `yaml:"Connector"`
+		Receiver    ResourceItem            `yaml:"Receiver"`
+		Connector   ResourceItem            `yaml:"Connector"`
+		Mapping     *mapping.Mapping        `yaml:"Mapping"`
+		Expressions *expression.Expressions `yaml:"Expressions"`
+		Generators  generator.Generators    `yaml:"Generators"`
+		Response    *render.Response        `yaml:"Response"`
+		Idempotency *idempotency.Settings   `yaml:"Idempotency"`
 	}
 
 	ResourceItem struct {
 		ObjectPathSchema string `yaml:"ObjectPathSchema"`
-		ResourceType     string `yaml:"ResourceType"`
+		// ProtoMessage is the full name of the message described by the schema file, when it is a
+		// protobuf descriptor set
+		ProtoMessage   string          `yaml:"ProtoMessage"`
+		SchemaRegistry *SchemaRegistry `yaml:"SchemaRegistry"`
+		ResourceType   string          `yaml:"ResourceType"`
 
 		Properties Properties `yaml:"Properties"`
+
+		source   Source
+		schema   *schema.Schema
+		history  map[string]*schema.Schema
+		filters  map[string]*expression.Program
+		registry *registry.Client
+		entity   *Entity
+
+		// registryOnce creates the client of the registry shared by the requests
+		registryOnce sync.Once
+	}
+
+	// SchemaRegistry references the subject of a Confluent-compatible schema registry holding the
+	// schema of a resource. When 'ObjectPathSchema' is also informed, it is the reader schema and the
+	// registry only provides the schemas that wrote the messages. 'WireFormat' tells that the Avro
+	// payloads carry the header of the registry, with the ID of the schema that wrote them.
+	SchemaRegistry struct {
+		URL                string `yaml:"URL"`
+		Subject            string `yaml:"Subject"`
+		Version            string `yaml:"Version"`
+		Username           string `yaml:"Username"`
+		Password           string `yaml:"Password"`
+		CheckCompatibility bool   `yaml:"CheckCompatibility"`
+		WireFormat         bool   `yaml:"WireFormat"`
+	}
+
+	// ScanSettings configures the listing of the whole table. The items are read by 'TotalSegments'
+	// parallel scans, in pages of 'PageSize' items, limited to 'MaxItems' items and 'MaxCapacity'
+	// read capacity units per request.
+	ScanSettings struct {
+		Enabled       bool    `yaml:"Enabled"`
+		TotalSegments int64   `yaml:"TotalSegments"`
+		PageSize      int64   `yaml:"PageSize"`
+		MaxItems      int64   `yaml:"MaxItems"`
+		MaxCapacity   float64 `yaml:"MaxCapacity"`
 	}
 
 	Properties struct {
@@ -27,11 +82,48 @@ type (
 		AllowedPath    map[string]string `yaml:"AllowedPath"`
 
 		// DynamoDB Connector
-		TableName     string                 `yaml:"TableName"`
-		Keys          map[string]string      `yaml:"Keys"`
-		Filter        []string               `yaml:"Filters"`
-		FilterValues  map[string]interface{} `yaml:"FilterValues"`
-		OutputColumns []string               `yaml:"OutputColumns"`
+		TableName string `yaml:"TableName"`
+		// Keys are the key attributes of the table. HashKey and RangeKey name its partition and sort
+		// keys, which are required to tell them apart when the keys are composed by templates
+		Keys     map[string]string `yaml:"Keys"`
+		HashKey  string            `yaml:"HashKey"`
+		RangeKey string            `yaml:"RangeKey"`
+		// KeyTemplates compose the key attributes from the fields of the records, like
+		// 'USER#{UserID}', or hold constants, like 'PROFILE'
+		KeyTemplates map[string]string      `yaml:"KeyTemplates"`
+		Filter       []string               `yaml:"Filters"`
+		FilterValues map[string]interface{} `yaml:"FilterValues"`
+		// FilterExpressions are filter values computed, for each request, by CEL expressions
+		FilterExpressions map[string]string `yaml:"FilterExpressions"`
+		OutputColumns     []string          `yaml:"OutputColumns"`
+		// ConsistentRead makes the reads of the table strongly consistent
+		ConsistentRead bool `yaml:"ConsistentRead"`
+		// Scan enables the listing of the table by the reads that don't inform any key attribute
+		Scan *ScanSettings `yaml:"Scan"`
+		// Transactions replace the single write of the methods, like POST, by the items written
+		// atomically by a transaction
+		Transactions map[string]*Transaction `yaml:"Transactions"`
+		// Single-table design: the attribute holding the entity of the items, the entity of the
+		// records of this function and the entities sharing the table, with their key templates
+		EntityAttribute string             `yaml:"EntityAttribute"`
+		EntityType      string             `yaml:"EntityType"`
+		Entities        map[string]*Entity `yaml:"Entities"`
+		// SoftDelete marks the items as deleted instead of removing them
+		SoftDelete *SoftDelete `yaml:"SoftDelete"`
+		// Relations are the items related to the items of the table, nested in the responses of
+		// the reads that expand them
+		Relations map[string]*Relation `yaml:"Relations"`
+
+		// Schema evolution of the DynamoDB Connector: the version of 'ObjectPathSchema' stored with
+		// each item and the schema files of the previous versions, used to resolve the old items
+		SchemaVersion          string            `yaml:"SchemaVersion"`
+		SchemaVersionAttribute string            `yaml:"SchemaVersionAttribute"`
+		SchemaHistory          map[string]string `yaml:"SchemaHistory"`
+
+		// RESTfulApi Connector
+		Endpoint    string            `yaml:"Endpoint"`
+		ContentType string            `yaml:"ContentType"`
+		Headers     map[string]string `yaml:"Headers"`
 	}
 
 	DynamoAttributes struct {
@@ -0,0 +1,271 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/raywall/aws-lowcode-lambda-go/expression"
+	"github.com/raywall/aws-lowcode-lambda-go/registry"
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+// DefaultRegistryTimeout limits the requests to the schema registry made by the compilation of the
+// configuration.
+const DefaultRegistryTimeout = 10 * time.Second
+
+// Compile reads and compiles the schemas of all resources of the configuration, failing when one
+// of them cannot be read or is not a valid Avro or JSON Schema document, validates the rules of
+// the mapping between them and the key templates, transactions, soft deletes and relations of the
+// connector, type-checks the expressions and validates the generators, the response formats and
+// the idempotency settings.
+func (config *Config) Compile() error {
+	if err := config.Resources.Receiver.Compile(); err != nil {
+		return fmt.Errorf("failed compiling receiver schema: %v", err)
+	}
+
+	if err := config.Resources.Connector.Compile(); err != nil {
+		return fmt.Errorf("failed compiling connector schema: %v", err)
+	}
+
+	if err := config.Resources.Connector.compileHistory(); err != nil {
+		return fmt.Errorf("failed compiling connector schema history: %v", err)
+	}
+
+	if err := config.Resources.Connector.compileKeys(); err != nil {
+		return fmt.Errorf("failed compiling connector keys: %v", err)
+	}
+
+	if err := config.Resources.Connector.compileTransactions(); err != nil {
+		return fmt.Errorf("failed compiling connector transactions: %v", err)
+	}
+
+	if err := config.Resources.Connector.compileSoftDelete(); err != nil {
+		return fmt.Errorf("failed compiling connector soft delete: %v", err)
+	}
+
+	if err := config.Resources.Connector.compileRelations(); err != nil {
+		return fmt.Errorf("failed compiling connector relations: %v", err)
+	}
+
+	if err := config.Resources.Mapping.Compile(); err != nil {
+		return fmt.Errorf("failed compiling mapping: %v", err)
+	}
+
+	if err := config.compileExpressions(); err != nil {
+		return fmt.Errorf("failed compiling expressions: %v", err)
+	}
+
+	if err := config.Resources.Generators.Compile(); err != nil {
+		return fmt.Errorf("failed compiling generators: %v", err)
+	}
+
+	if err := config.Resources.Response.Compile(); err != nil {
+		return fmt.Errorf("failed compiling response: %v", err)
+	}
+
+	if err := config.Resources.Idempotency.Compile(); err != nil {
+		return fmt.Errorf("failed compiling idempotency: %v", err)
+	}
+
+	return nil
+}
+
+// Compile reads the file indicated by 'ObjectPathSchema' and parses it, once, into the schema used
+// by every request to validate, coerce and type the data of the resource. Resources referencing a
+// schema registry without a schema file use the version of the subject registered on it.
+func (res *ResourceItem) Compile() error {
+	res.schema = nil
+
+	if res.SchemaRegistry != nil {
+		return res.compileFromRegistry()
+	}
+
+	if res.ObjectPathSchema == "" {
+		return nil
+	}
+
+	parsed, err := res.parseSchema()
+	if err != nil {
+		return err
+	}
+
+	res.schema = parsed
+	return nil
+}
+
+// HasSchema reports whether the resource has a schema, from a file or from a schema registry.
+func (res *ResourceItem) HasSchema() bool {
+	return res.ObjectPathSchema != "" || res.SchemaRegistry != nil
+}
+
+// parseSchema reads and parses the file indicated by 'ObjectPathSchema'
+func (res *ResourceItem) parseSchema() (*schema.Schema, error) {
+	return res.parseFile(res.ObjectPathSchema)
+}
+
+// parseFile reads and parses a schema file of the resource, which is a protobuf descriptor set
+// when 'ProtoMessage' is informed
+func (res *ResourceItem) parseFile(path string) (*schema.Schema, error) {
+	raw, err := res.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed *schema.Schema
+	if res.ProtoMessage != "" {
+		parsed, err = schema.ParseProto(raw, res.ProtoMessage)
+	} else {
+		parsed, err = schema.Parse(raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %v", path, err)
+	}
+
+	return parsed, nil
+}
+
+// compileFromRegistry defines the schema of the resource from the subject of the schema registry.
+// When there is a schema file, it is used as the reader schema and, if requested, it must be able
+// to read the data written with the registered version of the subject.
+func (res *ResourceItem) compileFromRegistry() error {
+	reg := res.SchemaRegistry
+	if reg.URL == "" || reg.Subject == "" {
+		return fmt.Errorf("schema registry requires the URL and Subject properties")
+	}
+
+	if res.ObjectPathSchema != "" && !reg.CheckCompatibility {
+		parsed, err := res.parseSchema()
+		if err != nil {
+			return err
+		}
+
+		res.schema = parsed
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultRegistryTimeout)
+	defer cancel()
+
+	subject, err := res.Registry().Version(ctx, reg.Subject, reg.Version)
+	if err != nil {
+		return fmt.Errorf("failed getting subject %s from the schema registry: %v", reg.Subject, err)
+	}
+
+	if res.ObjectPathSchema == "" {
+		res.schema = subject.Schema
+		return nil
+	}
+
+	parsed, err := res.parseSchema()
+	if err != nil {
+		return err
+	}
+
+	if err := schema.CheckCompatibility(parsed.Element(), subject.Schema.Element()); err != nil {
+		return fmt.Errorf("schema %s is not backward compatible with %s version %d: %v", res.ObjectPathSchema, subject.Subject, subject.Version, err)
+	}
+
+	res.schema = parsed
+	return nil
+}
+
+// Registry returns the client of the schema registry referenced by the resource, or nil when the
+// resource doesn't use one. The client is created once and shared by the concurrent requests.
+func (res *ResourceItem) Registry() *registry.Client {
+	if res.SchemaRegistry == nil {
+		return nil
+	}
+
+	res.registryOnce.Do(func() {
+		res.registry = registry.NewClient(res.SchemaRegistry.URL, nil)
+		res.registry.Username = res.SchemaRegistry.Username
+		res.registry.Password = res.SchemaRegistry.Password
+	})
+
+	return res.registry
+}
+
+// DecodeAvro decodes an Avro binary payload into a value of the schema of the resource. When the
+// schema registry of the resource declares the 'WireFormat', the payloads are decoded with the
+// schema identified on their header and resolved into the schema of the resource; the others are
+// decoded with the schema of the resource, even when they start with a zero byte.
+func (res *ResourceItem) DecodeAvro(ctx context.Context, data []byte) (interface{}, error) {
+	s, err := res.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	if res.SchemaRegistry == nil || !res.SchemaRegistry.WireFormat {
+		return s.Element().DecodeBinary(data)
+	}
+
+	id, payload, err := registry.DecodeWire(data)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := res.Registry().SchemaByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting schema %d from the schema registry: %v", id, err)
+	}
+
+	value, err := writer.Element().DecodeBinary(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Element().Resolve(writer.Element(), value)
+}
+
+// Schema returns the schema of the resource, compiling it on the first use when the
+// configuration was not compiled on its loading.
+func (res *ResourceItem) Schema() (*schema.Schema, error) {
+	if res.schema == nil {
+		if err := res.Compile(); err != nil {
+			return nil, err
+		}
+	}
+
+	if res.schema == nil {
+		return nil, fmt.Errorf("resource %s has no schema", res.ResourceType)
+	}
+
+	return res.schema, nil
+}
+
+// compileExpressions type-checks the expressions of the configuration, whose variables are the
+// fields of the receiver schema
+func (config *Config) compileExpressions() error {
+	connector := &config.Resources.Connector
+	connector.filters = nil
+
+	if config.Resources.Expressions == nil && len(connector.Properties.FilterExpressions) == 0 {
+		return nil
+	}
+
+	var receiverSchema *schema.Schema
+	if config.Resources.Receiver.HasSchema() {
+		receiverSchema = config.Resources.Receiver.schema
+	}
+
+	env, err := expression.NewEnv(receiverSchema)
+	if err != nil {
+		return err
+	}
+
+	if err := config.Resources.Expressions.Compile(env, receiverSchema); err != nil {
+		return err
+	}
+
+	if len(connector.Properties.FilterExpressions) > 0 {
+		connector.filters = make(map[string]*expression.Program, len(connector.Properties.FilterExpressions))
+		for name, source := range connector.Properties.FilterExpressions {
+			if connector.filters[name], err = env.Compile(source, nil); err != nil {
+				return fmt.Errorf("filter value %s: %v", name, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/raywall/aws-lowcode-lambda-go/registry"
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+const cityAvro = `{
+  "type": "record",
+  "name": "City",
+  "fields": [
+    { "name": "Name", "type": "string" },
+    { "name": "Population", "type": "int" },
+    { "name": "Country", "type": "string" }
+  ]
+}`
+
+// registryServer serves the schema of the cities as the version 1 of the subject cities-value and
+// as the schema 7
+func registryServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	doc, err := json.Marshal(map[string]interface{}{"subject": "cities-value", "id": 7, "version": 1, "schema": cityAvro})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/subjects/cities-value/versions/latest", "/schemas/ids/7":
+			w.Write(doc)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func loadRegistryResource(t *testing.T, url string, wireFormat bool) *ResourceItem {
+	t.Helper()
+
+	document := fmt.Sprintf("Resources:\n  Receiver:\n    ResourceType: SQS\n    SchemaRegistry:\n"+
+		"      URL: %s\n      Subject: cities-value\n      WireFormat: %v\n", url, wireFormat)
+
+	config, err := LoadFrom(NewMemorySource(map[string][]byte{"config.yaml": []byte(document)}), "config.yaml")
+	if err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+
+	return &config.Resources.Receiver
+}
+
+func TestDecodeAvro(t *testing.T) {
+	server := registryServer(t)
+
+	s, err := schema.Parse([]byte(cityAvro))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the empty name is encoded as a zero byte, like the header of the wire format
+	city := map[string]interface{}{"Name": "", "Population": int32(1), "Country": "PT"}
+	payload, err := s.Element().EncodeBinary(city)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !registry.IsWireFormat(payload) {
+		t.Fatalf("the payload %x must look like the wire format", payload)
+	}
+
+	tests := []struct {
+		name       string
+		wireFormat bool
+		data       []byte
+		wantErr    bool
+	}{
+		{name: "plain payload starting with zero", data: payload},
+		{name: "wire format", wireFormat: true, data: registry.EncodeWire(7, payload)},
+		{name: "wire format of an unknown schema", wireFormat: true, data: registry.EncodeWire(8, payload), wantErr: true},
+		{name: "wire format without its header", wireFormat: true, data: []byte{0x02}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := loadRegistryResource(t, server.URL, tt.wireFormat)
+
+			got, err := res.DecodeAvro(context.Background(), tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeAvro() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, city) {
+				t.Errorf("DecodeAvro() = %#v, want %#v", got, city)
+			}
+		})
+	}
+}
+
+func TestRegistryIsCreatedOnce(t *testing.T) {
+	res := loadRegistryResource(t, registryServer(t).URL, true)
+
+	clients := make([]*registry.Client, 8)
+
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			clients[i] = res.Registry()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, client := range clients {
+		if client == nil || client != clients[0] {
+			t.Fatalf("Registry() = %p, want the same client on every call", client)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/raywall/aws-lowcode-lambda-go/generator"
+)
+
+// Defaults of the soft deletes.
+const (
+	DefaultDeletedAttribute = "DeletedAt"
+	DefaultRestorePath      = "/_restore"
+)
+
+// SoftDelete makes the DELETE method of the DynamoDB connector mark the items as deleted, writing
+// the time of the removal on 'Attribute', instead of removing them. The deleted items are hidden
+// from the reads and the updates until they are restored by a POST to the 'RestorePath', like
+// '/users/_restore'. When 'TTLAttribute' is informed, the deleted items also receive the time,
+// in Unix seconds, when the TTL of the table purges them, after the 'Retention' period.
+type SoftDelete struct {
+	Attribute    string `yaml:"Attribute"`
+	TTLAttribute string `yaml:"TTLAttribute"`
+	Retention    string `yaml:"Retention"`
+	RestorePath  string `yaml:"RestorePath"`
+
+	retention time.Duration
+}
+
+// compileSoftDelete validates the soft deletes of the connector and fills their defaults
+func (res *ResourceItem) compileSoftDelete() error {
+	sd := res.Properties.SoftDelete
+	if sd == nil {
+		return nil
+	}
+
+	if sd.Attribute == "" {
+		sd.Attribute = DefaultDeletedAttribute
+	}
+	if sd.RestorePath == "" {
+		sd.RestorePath = DefaultRestorePath
+	}
+	if _, ok := res.Properties.Keys[sd.Attribute]; ok {
+		return fmt.Errorf("the key attribute %s can't mark the deleted items", sd.Attribute)
+	}
+
+	if (sd.TTLAttribute == "") != (sd.Retention == "") {
+		return fmt.Errorf("the TTLAttribute and the Retention must be informed together")
+	}
+	if sd.Retention != "" {
+		retention, err := generator.ParseDuration(sd.Retention)
+		if err != nil || retention <= 0 {
+			return fmt.Errorf("invalid retention %q", sd.Retention)
+		}
+		sd.retention = retention
+	}
+
+	return nil
+}
+
+// SoftDeletes reports whether the items are marked as deleted instead of being removed.
+func (res *ResourceItem) SoftDeletes() bool {
+	return res.Properties.SoftDelete != nil
+}
+
+// RestorePath returns the path that restores the deleted items, or an empty string when the items
+// are removed.
+func (res *ResourceItem) RestorePath() string {
+	if !res.SoftDeletes() {
+		return ""
+	}
+
+	return res.Properties.SoftDelete.RestorePath
+}
+
+// IsDeleted reports whether the item was marked as deleted.
+func (res *ResourceItem) IsDeleted(item map[string]*dynamodb.AttributeValue) bool {
+	if !res.SoftDeletes() {
+		return false
+	}
+
+	_, deleted := item[res.Properties.SoftDelete.Attribute]
+	return deleted
+}
+
+// TombstoneCondition returns the condition matching the items that were not deleted, whose name is
+// '#' followed by the attribute marking the deleted items, or an empty string when the items are
+// removed.
+func (res *ResourceItem) TombstoneCondition() string {
+	if !res.SoftDeletes() {
+		return ""
+	}
+
+	return fmt.Sprintf("attribute_not_exists(#%s)", res.Properties.SoftDelete.Attribute)
+}
+
+// Tombstone returns the attributes written on the items deleted at the time received: the time of
+// the removal and, when it is configured, the expiration of the item.
+func (res *ResourceItem) Tombstone(now time.Time) map[string]*dynamodb.AttributeValue {
+	sd := res.Properties.SoftDelete
+
+	attributes := map[string]*dynamodb.AttributeValue{
+		sd.Attribute: {S: aws.String(now.UTC().Format(time.RFC3339))},
+	}
+	if sd.TTLAttribute != "" {
+		attributes[sd.TTLAttribute] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(now.Add(sd.retention).Unix(), 10))}
+	}
+
+	return attributes
+}
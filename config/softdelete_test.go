@@ -0,0 +1,71 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestCompileSoftDelete(t *testing.T) {
+	res := loadConnector(t, "Keys:\n  UserID: EQ\nSoftDelete:\n  TTLAttribute: PurgeAt\n  Retention: 30d\n")
+
+	sd := res.Properties.SoftDelete
+	if sd.Attribute != DefaultDeletedAttribute || sd.retention != 30*24*time.Hour || res.RestorePath() != DefaultRestorePath {
+		t.Errorf("compileSoftDelete() = %+v, want the defaults and 30 days", sd)
+	}
+
+	tests := []struct {
+		name       string
+		properties string
+		want       string
+	}{
+		{name: "key attribute", properties: "Keys:\n  UserID: EQ\nSoftDelete:\n  Attribute: UserID\n", want: "can't mark the deleted items"},
+		{name: "ttl without retention", properties: "Keys:\n  UserID: EQ\nSoftDelete:\n  TTLAttribute: PurgeAt\n", want: "must be informed together"},
+		{name: "invalid retention", properties: "Keys:\n  UserID: EQ\nSoftDelete:\n  TTLAttribute: PurgeAt\n  Retention: -1d\n", want: "invalid retention"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadConfig(tt.properties); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadFrom() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTombstone(t *testing.T) {
+	res := loadConnector(t, "Keys:\n  UserID: EQ\nSoftDelete:\n  TTLAttribute: PurgeAt\n  Retention: 1d\n")
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tombstone := res.Tombstone(now)
+	if aws.StringValue(tombstone["DeletedAt"].S) != "2024-01-02T03:04:05Z" || aws.StringValue(tombstone["PurgeAt"].N) != "1704251045" {
+		t.Errorf("Tombstone() = %v, want the time of the removal and of the purge", tombstone)
+	}
+
+	if !res.IsDeleted(tombstone) || res.IsDeleted(map[string]*dynamodb.AttributeValue{}) {
+		t.Errorf("IsDeleted() doesn't follow the DeletedAt attribute")
+	}
+	if got := res.TombstoneCondition(); got != "attribute_not_exists(#DeletedAt)" {
+		t.Errorf("TombstoneCondition() = %q, want attribute_not_exists(#DeletedAt)", got)
+	}
+	if got := res.GetFilterExpression(); got != "attribute_not_exists(#DeletedAt)" {
+		t.Errorf("GetFilterExpression() = %q, want the deleted items hidden", got)
+	}
+}
+
+func TestWithoutSoftDelete(t *testing.T) {
+	res := loadConnector(t, "Keys:\n  UserID: EQ\nFilters:\n  - '#Age > :age'\n")
+
+	if res.SoftDeletes() || res.RestorePath() != "" || res.TombstoneCondition() != "" {
+		t.Errorf("the items must be removed without SoftDelete")
+	}
+	if res.IsDeleted(map[string]*dynamodb.AttributeValue{"DeletedAt": {S: aws.String("x")}}) {
+		t.Errorf("IsDeleted() = true without SoftDelete")
+	}
+	if got := res.GetFilterExpression(); got != "#Age > :age" {
+		t.Errorf("GetFilterExpression() = %q, want the filters only", got)
+	}
+}
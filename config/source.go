@@ -0,0 +1,209 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// Source retrieves the content of the configuration and schema files by their name, allowing
+// the function to be configured from the local disk, an embedded file system or a remote
+// location like an S3 bucket or the SSM parameter store.
+type Source interface {
+	ReadFile(name string) ([]byte, error)
+}
+
+// Versioner is implemented by the sources able to inform the current version of a file without
+// reading its content, like the modification time of a local file, the ETag of an S3 object or
+// the version of a SSM parameter. It allows the function to detect changes on its configuration.
+type Versioner interface {
+	Version(name string) (string, error)
+}
+
+// OSSource reads the files from the local file system, like the files of a lambda layer
+// mounted on '/opt'.
+type OSSource struct{}
+
+func (OSSource) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (OSSource) Version(name string) (string, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return "", err
+	}
+
+	return fileVersion(info), nil
+}
+
+// FSSource reads the files from a fs.FS, like an embed.FS compiled into the binary. As fs.FS
+// names cannot be absolute, the Prefix (e.g. '/opt') and the leading slash are removed from the
+// names before opening them.
+type FSSource struct {
+	FS     fs.FS
+	Prefix string
+}
+
+func (src FSSource) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(src.FS, src.name(name))
+}
+
+func (src FSSource) Version(name string) (string, error) {
+	info, err := fs.Stat(src.FS, src.name(name))
+	if err != nil {
+		return "", err
+	}
+
+	return fileVersion(info), nil
+}
+
+func (src FSSource) name(name string) string {
+	if src.Prefix != "" {
+		name = strings.TrimPrefix(name, src.Prefix)
+	}
+
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// S3Source reads the files from the objects of an S3 bucket, using the name of the file, without
+// the leading slash, appended to the Prefix as the object key.
+type S3Source struct {
+	Client s3iface.S3API
+	Bucket string
+	Prefix string
+}
+
+func (src S3Source) ReadFile(name string) ([]byte, error) {
+	output, err := src.Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(src.Bucket),
+		Key:    aws.String(src.key(name)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed getting s3://%s/%s: %v", src.Bucket, src.key(name), err)
+	}
+	defer output.Body.Close()
+
+	return io.ReadAll(output.Body)
+}
+
+func (src S3Source) Version(name string) (string, error) {
+	output, err := src.Client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(src.Bucket),
+		Key:    aws.String(src.key(name)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed getting s3://%s/%s: %v", src.Bucket, src.key(name), err)
+	}
+
+	return aws.StringValue(output.ETag), nil
+}
+
+func (src S3Source) key(name string) string {
+	return src.Prefix + strings.TrimPrefix(name, "/")
+}
+
+// SSMSource reads the files from the parameters of the SSM parameter store, using the name of the
+// file appended to the Prefix as the parameter name.
+type SSMSource struct {
+	Client         ssmiface.SSMAPI
+	Prefix         string
+	WithDecryption bool
+}
+
+func (src SSMSource) ReadFile(name string) ([]byte, error) {
+	output, err := src.Client.GetParameter(&ssm.GetParameterInput{
+		Name:           aws.String(src.parameter(name)),
+		WithDecryption: aws.Bool(src.WithDecryption),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed getting parameter %s: %v", src.parameter(name), err)
+	}
+
+	return []byte(aws.StringValue(output.Parameter.Value)), nil
+}
+
+func (src SSMSource) Version(name string) (string, error) {
+	output, err := src.Client.GetParameter(&ssm.GetParameterInput{
+		Name: aws.String(src.parameter(name)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed getting parameter %s: %v", src.parameter(name), err)
+	}
+
+	return strconv.FormatInt(aws.Int64Value(output.Parameter.Version), 10), nil
+}
+
+func (src SSMSource) parameter(name string) string {
+	return path.Join("/", src.Prefix, name)
+}
+
+// MemorySource keeps the files in memory, and is intended to replace the remote sources on tests
+// or local executions.
+type MemorySource struct {
+	mu        sync.RWMutex
+	files     map[string][]byte
+	revisions map[string]int
+}
+
+// NewMemorySource creates a MemorySource with the files received, indexed by their name.
+func NewMemorySource(files map[string][]byte) *MemorySource {
+	src := &MemorySource{
+		files:     make(map[string][]byte),
+		revisions: make(map[string]int),
+	}
+	for name, content := range files {
+		src.files[name] = content
+	}
+
+	return src
+}
+
+// Set creates or replaces the content of a file.
+func (src *MemorySource) Set(name string, content []byte) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	src.files[name] = bytes.Clone(content)
+	src.revisions[name]++
+}
+
+func (src *MemorySource) ReadFile(name string) ([]byte, error) {
+	src.mu.RLock()
+	defer src.mu.RUnlock()
+
+	content, ok := src.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return bytes.Clone(content), nil
+}
+
+func (src *MemorySource) Version(name string) (string, error) {
+	src.mu.RLock()
+	defer src.mu.RUnlock()
+
+	if _, ok := src.files[name]; !ok {
+		return "", &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return strconv.Itoa(src.revisions[name]), nil
+}
+
+// fileVersion uses the modification time and the size of a file as its version
+func fileVersion(info fs.FileInfo) string {
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const sourceSchema = `{
+  "type": "object",
+  "properties": {
+    "UserID": { "type": "string" },
+    "Age": { "type": "integer" }
+  },
+  "required": ["UserID"]
+}`
+
+const sourceConfig = `Resources:
+  Connector:
+    ResourceType: DynamoDB
+    ObjectPathSchema: %s
+    Properties:
+      TableName: users
+      Keys:
+        UserID: EQ
+`
+
+// writeSources writes the configuration and its schema on a temporary directory, returning the
+// path of the configuration and the sources able to read it
+func writeSources(t *testing.T) (string, map[string]Source) {
+	t.Helper()
+
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "user.json")
+	configPath := filepath.Join(dir, "config.yaml")
+	config := fmt.Sprintf(sourceConfig, schemaPath)
+
+	for name, content := range map[string]string{schemaPath: sourceSchema, configPath: config} {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return configPath, map[string]Source{
+		"os": OSSource{},
+		"fs": FSSource{FS: os.DirFS(dir), Prefix: dir},
+		"memory": NewMemorySource(map[string][]byte{
+			schemaPath: []byte(sourceSchema),
+			configPath: []byte(config),
+		}),
+	}
+}
+
+func TestSourcesReadTheSameConfig(t *testing.T) {
+	configPath, sources := writeSources(t)
+
+	for name, src := range sources {
+		t.Run(name, func(t *testing.T) {
+			conf, err := LoadFrom(src, configPath)
+			if err != nil {
+				t.Fatalf("LoadFrom() error = %v", err)
+			}
+
+			res := &conf.Resources.Connector
+			if res.Properties.TableName != "users" {
+				t.Errorf("TableName = %q, want users", res.Properties.TableName)
+			}
+			if res.Properties.HashKey != "UserID" {
+				t.Errorf("HashKey = %q, want UserID", res.Properties.HashKey)
+			}
+
+			s, err := res.Schema()
+			if err != nil {
+				t.Fatalf("Schema() error = %v", err)
+			}
+			if s.Element().Field("Age") == nil {
+				t.Errorf("the schema read doesn't declare the field Age")
+			}
+		})
+	}
+}
+
+func TestVersionerChangesWithTheContent(t *testing.T) {
+	changed := []byte(fmt.Sprintf(sourceConfig, "other.json") + "      ConsistentRead: true\n")
+
+	// the sources share the files, so each one changes its own copy
+	for _, name := range []string{"os", "fs", "memory"} {
+		t.Run(name, func(t *testing.T) {
+			configPath, sources := writeSources(t)
+			src := sources[name]
+
+			versioner, ok := src.(Versioner)
+			if !ok {
+				t.Fatalf("%T doesn't implement Versioner", src)
+			}
+
+			before, err := versioner.Version(configPath)
+			if err != nil {
+				t.Fatalf("Version() error = %v", err)
+			}
+
+			if memory, ok := src.(*MemorySource); ok {
+				memory.Set(configPath, changed)
+			} else if err := os.WriteFile(configPath, changed, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			after, err := versioner.Version(configPath)
+			if err != nil {
+				t.Fatalf("Version() error = %v", err)
+			}
+			if after == before {
+				t.Errorf("Version() = %q after the change, want a new version", after)
+			}
+
+			again, _ := versioner.Version(configPath)
+			if again != after {
+				t.Errorf("Version() = %q without changes, want %q", again, after)
+			}
+		})
+	}
+}
+
+func TestMissingFileReturnsError(t *testing.T) {
+	configPath, sources := writeSources(t)
+	missing := filepath.Join(filepath.Dir(configPath), "missing.yaml")
+
+	for name, src := range sources {
+		t.Run(name, func(t *testing.T) {
+			if _, err := src.ReadFile(missing); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("ReadFile() error = %v, want fs.ErrNotExist", err)
+			}
+			if _, err := src.(Versioner).Version(missing); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Version() error = %v, want fs.ErrNotExist", err)
+			}
+			if _, err := LoadFrom(src, missing); err == nil {
+				t.Errorf("LoadFrom() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestMemorySourceKeepsCopies(t *testing.T) {
+	content := []byte("Resources: {}")
+	src := NewMemorySource(nil)
+	src.Set("config.yaml", content)
+	content[0] = 'X'
+
+	read, err := src.ReadFile("config.yaml")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	read[1] = 'X'
+
+	again, _ := src.ReadFile("config.yaml")
+	if string(again) != "Resources: {}" {
+		t.Errorf("ReadFile() = %q, want the content set", again)
+	}
+}
@@ -0,0 +1,378 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+)
+
+// Actions of the items of a transaction.
+const (
+	TransactPut            = "Put"
+	TransactUpdate         = "Update"
+	TransactDelete         = "Delete"
+	TransactConditionCheck = "ConditionCheck"
+)
+
+// DefaultTransactionTokenHeader is the header carrying the client token of the transactions when
+// 'TokenHeader' is not informed.
+const DefaultTransactionTokenHeader = "Idempotency-Key"
+
+// attributeValue matches the value placeholders, like ':active', used by the conditions
+var attributeValue = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+type (
+	// Transaction lists the items written atomically, with a single TransactWriteItems call, by a
+	// method of the DynamoDB connector in place of its single write.
+	Transaction struct {
+		Items []*TransactionItem `yaml:"Items"`
+		// TokenHeader is the header carrying the client token, which makes the retries of the same
+		// transaction idempotent for ten minutes
+		TokenHeader string `yaml:"TokenHeader"`
+	}
+
+	// TransactionItem is an item of a transaction. Its record is the connector record or, when
+	// 'Mapping' is informed, the record built by the mapping rules from the connector record, as
+	// 'body', and the parameters of the request.
+	TransactionItem struct {
+		// Action is Put (default), Update, Delete or ConditionCheck
+		Action string `yaml:"Action"`
+		// TableName is the table of the item, the table of the connector by default
+		TableName string `yaml:"TableName"`
+		// Keys are the key attributes of the table, the keys of the connector by default
+		Keys    []string         `yaml:"Keys"`
+		Mapping *mapping.Mapping `yaml:"Mapping"`
+		// Condition is the condition expression of the item, required by ConditionCheck. Its value
+		// placeholders, like ':Status', are read from 'ConditionValues' or from the attribute of
+		// the record with the same name
+		Condition       string                 `yaml:"Condition"`
+		ConditionValues map[string]interface{} `yaml:"ConditionValues"`
+	}
+)
+
+// compileTransactions validates the transactions of the connector, which are indexed by the
+// method they handle
+func (res *ResourceItem) compileTransactions() error {
+	transactions := make(map[string]*Transaction, len(res.Properties.Transactions))
+	for method, tx := range res.Properties.Transactions {
+		transactions[strings.ToUpper(method)] = tx
+	}
+	res.Properties.Transactions = transactions
+
+	for method, tx := range res.Properties.Transactions {
+		if tx == nil || len(tx.Items) == 0 {
+			return fmt.Errorf("transaction %s has no items", method)
+		}
+		if len(tx.Items) > 100 {
+			return fmt.Errorf("transaction %s has %d items, the limit is 100", method, len(tx.Items))
+		}
+
+		for i, item := range tx.Items {
+			if item.Action == "" {
+				item.Action = TransactPut
+			}
+
+			switch item.Action {
+			case TransactPut, TransactUpdate, TransactDelete:
+			case TransactConditionCheck:
+				if item.Condition == "" {
+					return fmt.Errorf("item %d of transaction %s: ConditionCheck requires a Condition", i, method)
+				}
+			default:
+				return fmt.Errorf("item %d of transaction %s: unsupported action %s", i, method, item.Action)
+			}
+
+			if err := item.Mapping.Compile(); err != nil {
+				return fmt.Errorf("item %d of transaction %s: %v", i, method, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Transaction returns the transaction that handles the method, or nil when the method writes a
+// single item.
+func (res *ResourceItem) Transaction(method string) *Transaction {
+	return res.Properties.Transactions[strings.ToUpper(method)]
+}
+
+// TokenHeaderName returns the header carrying the client token of the transaction
+func (tx *Transaction) TokenHeaderName() string {
+	if tx.TokenHeader == "" {
+		return DefaultTransactionTokenHeader
+	}
+
+	return tx.TokenHeader
+}
+
+// TransactItems builds the items of the transaction for the connector record and the request
+// received.
+func (res *ResourceItem) TransactItems(tx *Transaction, data map[string]interface{}, req mapping.Request) ([]*dynamodb.TransactWriteItem, error) {
+	items := make([]*dynamodb.TransactWriteItem, len(tx.Items))
+
+	for i, item := range tx.Items {
+		var err error
+		if items[i], err = res.transactItem(item, data, req); err != nil {
+			return nil, fmt.Errorf("item %d of the transaction: %w", i, err)
+		}
+	}
+
+	return items, nil
+}
+
+// transactItem builds an item of the transaction
+func (res *ResourceItem) transactItem(item *TransactionItem, data map[string]interface{}, req mapping.Request) (*dynamodb.TransactWriteItem, error) {
+	record := data
+	if item.Mapping != nil {
+		req.Body = data
+
+		var err error
+		if record, err = item.Mapping.Apply(req); err != nil {
+			return nil, err
+		}
+	}
+
+	table := item.TableName
+	if table == "" {
+		table = res.Properties.TableName
+	}
+
+	// the items of the connector table are written like its own items: their keys are composed by
+	// the templates and their attributes are typed by its schema
+	own := table == res.Properties.TableName
+	marshal := dynamodbattribute.MarshalMap
+	if own {
+		composed, err := res.ComposeKeys(record)
+		if err != nil {
+			return nil, err
+		}
+		record = composed.(map[string]interface{})
+		marshal = func(in interface{}) (map[string]*dynamodb.AttributeValue, error) {
+			return res.marshalAttributes(in.(map[string]interface{}), "")
+		}
+	}
+
+	keys := item.Keys
+	if len(keys) == 0 {
+		for key := range res.Properties.Keys {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	keyValues, attributes := map[string]interface{}{}, map[string]interface{}{}
+	for name, value := range record {
+		if slices.Contains(keys, name) {
+			keyValues[name] = value
+		} else {
+			attributes[name] = value
+		}
+	}
+	// the keys composed only up to a missing field don't identify an item
+	for _, key := range keys {
+		value, ok := keyValues[key]
+		if _, prefix := value.(KeyPrefix); !ok || value == nil || prefix {
+			return nil, fmt.Errorf("the key attribute %s is missing", key)
+		}
+	}
+
+	key, err := marshal(keyValues)
+	if err != nil {
+		return nil, err
+	}
+
+	names, values, err := item.conditionAttributes(record, marshal)
+	if err != nil {
+		return nil, err
+	}
+
+	var condition *string
+	if item.Condition != "" {
+		condition = aws.String(item.Condition)
+	}
+
+	// the deleted items of the connector table are only written again, or updated, after being
+	// restored
+	if own && (item.Action == TransactPut || item.Action == TransactUpdate) && res.SoftDeletes() {
+		if names == nil {
+			names = map[string]*string{}
+		}
+		names["#"+res.Properties.SoftDelete.Attribute] = aws.String(res.Properties.SoftDelete.Attribute)
+
+		tombstone := res.TombstoneCondition()
+		if item.Condition != "" {
+			tombstone = "(" + item.Condition + ") AND " + tombstone
+		}
+		condition = aws.String(tombstone)
+	}
+
+	switch item.Action {
+	case TransactPut:
+		var attributes map[string]*dynamodb.AttributeValue
+		if own {
+			// the items are validated by the schema, like the ones created by the connector
+			if attributes, err = res.MarshalItem(record); err == nil {
+				res.VersionItem(attributes)
+			}
+		} else {
+			attributes, err = marshal(record)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &dynamodb.TransactWriteItem{Put: &dynamodb.Put{
+			TableName:                 aws.String(table),
+			Item:                      attributes,
+			ConditionExpression:       condition,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		}}, nil
+	case TransactUpdate:
+		if own {
+			attributes = res.VersionData(attributes)
+		}
+		if len(attributes) == 0 {
+			return nil, fmt.Errorf("the update doesn't change any attribute")
+		}
+
+		commands := []string{}
+		for name, value := range attributes {
+			if names == nil {
+				names = map[string]*string{}
+			}
+			if values == nil {
+				values = map[string]*dynamodb.AttributeValue{}
+			}
+
+			marshalled, err := marshal(map[string]interface{}{name: value})
+			if err != nil {
+				return nil, err
+			}
+
+			names["#"+name] = aws.String(name)
+			values[":new_"+name] = marshalled[name]
+			commands = append(commands, fmt.Sprintf("#%s = :new_%s", name, name))
+		}
+		sort.Strings(commands)
+
+		return &dynamodb.TransactWriteItem{Update: &dynamodb.Update{
+			TableName:                 aws.String(table),
+			Key:                       key,
+			UpdateExpression:          aws.String("SET " + strings.Join(commands, ", ")),
+			ConditionExpression:       condition,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		}}, nil
+	case TransactDelete:
+		if own && res.SoftDeletes() {
+			return res.transactTombstone(key, item.Condition, names, values), nil
+		}
+
+		return &dynamodb.TransactWriteItem{Delete: &dynamodb.Delete{
+			TableName:                 aws.String(table),
+			Key:                       key,
+			ConditionExpression:       condition,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		}}, nil
+	default:
+		return &dynamodb.TransactWriteItem{ConditionCheck: &dynamodb.ConditionCheck{
+			TableName:                 aws.String(table),
+			Key:                       key,
+			ConditionExpression:       condition,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		}}, nil
+	}
+}
+
+// transactTombstone marks the item of the connector table as deleted, like the DELETE method does
+// when 'SoftDelete' is configured. The items missing or already deleted fail the transaction.
+func (res *ResourceItem) transactTombstone(key map[string]*dynamodb.AttributeValue, condition string, names map[string]*string, values map[string]*dynamodb.AttributeValue) *dynamodb.TransactWriteItem {
+	if names == nil {
+		names = map[string]*string{}
+	}
+	if values == nil {
+		values = map[string]*dynamodb.AttributeValue{}
+	}
+
+	commands := []string{}
+	for name, value := range res.Tombstone(time.Now()) {
+		names["#"+name] = aws.String(name)
+		values[":new_"+name] = value
+		commands = append(commands, fmt.Sprintf("#%s = :new_%s", name, name))
+	}
+	sort.Strings(commands)
+
+	conditions := []string{res.TombstoneCondition()}
+	for name := range key {
+		names["#"+name] = aws.String(name)
+		conditions = append(conditions, fmt.Sprintf("attribute_exists(#%s)", name))
+	}
+	sort.Strings(conditions)
+	if condition != "" {
+		conditions = append([]string{"(" + condition + ")"}, conditions...)
+	}
+
+	return &dynamodb.TransactWriteItem{Update: &dynamodb.Update{
+		TableName:                 aws.String(res.Properties.TableName),
+		Key:                       key,
+		UpdateExpression:          aws.String("SET " + strings.Join(commands, ", ")),
+		ConditionExpression:       aws.String(strings.Join(conditions, " AND ")),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}}
+}
+
+// conditionAttributes returns the names and values referenced by the condition of the item
+func (item *TransactionItem) conditionAttributes(record map[string]interface{}, marshal func(interface{}) (map[string]*dynamodb.AttributeValue, error)) (map[string]*string, map[string]*dynamodb.AttributeValue, error) {
+	if item.Condition == "" {
+		return nil, nil, nil
+	}
+
+	var names map[string]*string
+	for _, match := range attributeName.FindAllStringSubmatch(item.Condition, -1) {
+		if names == nil {
+			names = map[string]*string{}
+		}
+		names[match[0]] = aws.String(match[1])
+	}
+
+	raw := map[string]interface{}{}
+	for _, match := range attributeValue.FindAllStringSubmatch(item.Condition, -1) {
+		if value, ok := item.ConditionValues[match[0]]; ok {
+			raw[match[1]] = value
+		} else if value, ok := item.ConditionValues[match[1]]; ok {
+			raw[match[1]] = value
+		} else if value, ok := record[match[1]]; ok {
+			raw[match[1]] = value
+		} else {
+			return nil, nil, fmt.Errorf("the condition value %s is missing", match[0])
+		}
+	}
+	if len(raw) == 0 {
+		return names, nil, nil
+	}
+
+	marshalled, err := marshal(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := make(map[string]*dynamodb.AttributeValue, len(marshalled))
+	for name, value := range marshalled {
+		values[":"+name] = value
+	}
+
+	return names, values, nil
+}
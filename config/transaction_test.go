@@ -0,0 +1,153 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+)
+
+const orderTransactions = `
+Keys:
+  UserID: EQ
+  OrderID: EQ
+Transactions:
+  post:
+    TokenHeader: X-Request-ID
+    Items:
+      - Condition: attribute_not_exists(#OrderID)
+      - Action: Update
+        TableName: users
+        Keys: [UserID]
+        Mapping:
+          Request:
+            - Target: UserID
+              Source: UserID
+            - Target: LastOrderID
+              Source: OrderID
+      - Action: ConditionCheck
+        TableName: users
+        Keys: [UserID]
+        Condition: '#Status = :active'
+        ConditionValues:
+          active: ACTIVE
+  delete:
+    Items:
+      - Action: Delete
+`
+
+func TestCompileTransactions(t *testing.T) {
+	res := loadConnector(t, orderTransactions)
+
+	tx := res.Transaction("POST")
+	if tx == nil || tx.Items[0].Action != TransactPut || tx.TokenHeaderName() != "X-Request-ID" {
+		t.Fatalf("Transaction() = %+v, want the POST transaction", tx)
+	}
+	if res.Transaction("PUT") != nil {
+		t.Errorf("Transaction() returned a transaction for PUT")
+	}
+	if got := res.Transaction("delete").TokenHeaderName(); got != DefaultTransactionTokenHeader {
+		t.Errorf("TokenHeaderName() = %s, want %s", got, DefaultTransactionTokenHeader)
+	}
+
+	tests := []struct {
+		name       string
+		properties string
+		want       string
+	}{
+		{name: "no items", properties: "Keys:\n  UserID: EQ\nTransactions:\n  POST:\n    Items: []\n", want: "has no items"},
+		{name: "check without condition", properties: "Keys:\n  UserID: EQ\nTransactions:\n  POST:\n    Items:\n      - Action: ConditionCheck\n", want: "requires a Condition"},
+		{name: "unknown action", properties: "Keys:\n  UserID: EQ\nTransactions:\n  POST:\n    Items:\n      - Action: Upsert\n", want: "unsupported action"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadConfig(tt.properties); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadFrom() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransactItems(t *testing.T) {
+	res := loadConnector(t, orderTransactions)
+
+	items, err := res.TransactItems(res.Transaction("POST"), map[string]interface{}{"UserID": "42", "OrderID": "7", "Total": 10.0}, mapping.Request{})
+	if err != nil {
+		t.Fatalf("TransactItems() error = %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("TransactItems() = %d items, want 3", len(items))
+	}
+
+	// the items of the connector table are typed by its schema
+	put := items[0].Put
+	if aws.StringValue(put.TableName) != "app" || aws.StringValue(put.Item["OrderID"].N) != "7" ||
+		aws.StringValue(put.ConditionExpression) != "attribute_not_exists(#OrderID)" {
+		t.Errorf("Put = %v, want the order typed and its condition", put)
+	}
+
+	update := items[1].Update
+	if aws.StringValue(update.TableName) != "users" || aws.StringValue(update.Key["UserID"].S) != "42" ||
+		aws.StringValue(update.UpdateExpression) != "SET #LastOrderID = :new_LastOrderID" ||
+		aws.StringValue(update.ExpressionAttributeValues[":new_LastOrderID"].S) != "7" {
+		t.Errorf("Update = %v, want the last order of the user", update)
+	}
+
+	check := items[2].ConditionCheck
+	if aws.StringValue(check.ExpressionAttributeNames["#Status"]) != "Status" ||
+		aws.StringValue(check.ExpressionAttributeValues[":active"].S) != "ACTIVE" {
+		t.Errorf("ConditionCheck = %v, want the condition values", check)
+	}
+}
+
+func TestTransactItemsErrors(t *testing.T) {
+	res := loadConnector(t, orderTransactions)
+
+	if _, err := res.TransactItems(res.Transaction("POST"), map[string]interface{}{"UserID": "42"}, mapping.Request{}); err == nil ||
+		!strings.Contains(err.Error(), "the key attribute OrderID is missing") {
+		t.Errorf("TransactItems() error = %v, want the missing key", err)
+	}
+
+	if _, err := res.TransactItems(res.Transaction("POST"), map[string]interface{}{"UserID": "42", "OrderID": "seven"}, mapping.Request{}); err == nil {
+		t.Errorf("TransactItems() error = nil, want the order refused by the schema")
+	}
+}
+
+func TestTransactDeleteMarksTheItems(t *testing.T) {
+	res := loadConnector(t, orderTransactions+"SoftDelete: {}\n")
+
+	items, err := res.TransactItems(res.Transaction("DELETE"), map[string]interface{}{"UserID": "42", "OrderID": 7.0}, mapping.Request{})
+	if err != nil {
+		t.Fatalf("TransactItems() error = %v", err)
+	}
+
+	update := items[0].Update
+	if update == nil || aws.StringValue(update.UpdateExpression) != "SET #DeletedAt = :new_DeletedAt" ||
+		aws.StringValue(update.ConditionExpression) != "attribute_exists(#OrderID) AND attribute_exists(#UserID) AND attribute_not_exists(#DeletedAt)" {
+		t.Errorf("TransactItems() = %v, want the item marked as deleted", items[0])
+	}
+}
+
+func TestTransactUpdateSkipsTheDeletedItems(t *testing.T) {
+	res := loadConnector(t, "Keys:\n  UserID: EQ\n  OrderID: EQ\nSoftDelete: {}\nTransactions:\n  PUT:\n    Items:\n"+
+		"      - Action: Update\n        Condition: '#Total < :max'\n        ConditionValues:\n          max: 100\n"+
+		"      - Action: Update\n        TableName: users\n        Keys: [UserID]\n")
+
+	items, err := res.TransactItems(res.Transaction("PUT"), map[string]interface{}{"UserID": "42", "OrderID": 7.0, "Total": 10.0}, mapping.Request{})
+	if err != nil {
+		t.Fatalf("TransactItems() error = %v", err)
+	}
+
+	own := items[0].Update
+	if aws.StringValue(own.ConditionExpression) != "(#Total < :max) AND attribute_not_exists(#DeletedAt)" ||
+		aws.StringValue(own.ExpressionAttributeNames["#DeletedAt"]) != "DeletedAt" {
+		t.Errorf("Update = %v, want the deleted orders skipped", own)
+	}
+
+	// the items of other tables don't follow the soft delete of the connector
+	if other := items[1].Update; other.ConditionExpression != nil {
+		t.Errorf("Update = %v, want no condition on the users", other)
+	}
+}
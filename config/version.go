@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+// DefaultSchemaVersionAttribute is the attribute that stores the schema version of the items when
+// 'SchemaVersionAttribute' is not informed.
+const DefaultSchemaVersionAttribute = "_schemaVersion"
+
+// SchemaVersionAttribute returns the name of the attribute that stores the schema version of the
+// items, or an empty string when the resource doesn't version its items.
+func (res *ResourceItem) SchemaVersionAttribute() string {
+	if res.Properties.SchemaVersion == "" {
+		return ""
+	}
+
+	if res.Properties.SchemaVersionAttribute == "" {
+		return DefaultSchemaVersionAttribute
+	}
+
+	return res.Properties.SchemaVersionAttribute
+}
+
+// SchemaFiles returns the schema files of the resource: the one indicated by 'ObjectPathSchema'
+// followed by the files of the previous versions, in the order of their versions.
+func (res *ResourceItem) SchemaFiles() []string {
+	files := []string{}
+	if res.ObjectPathSchema != "" {
+		files = append(files, res.ObjectPathSchema)
+	}
+
+	for _, version := range res.historyVersions() {
+		files = append(files, res.Properties.SchemaHistory[version])
+	}
+
+	return files
+}
+
+// historyVersions returns the previous versions of the schema, sorted
+func (res *ResourceItem) historyVersions() []string {
+	versions := make([]string, 0, len(res.Properties.SchemaHistory))
+	for version := range res.Properties.SchemaHistory {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	return versions
+}
+
+// compileHistory parses the schemas of the previous versions, which must be readable by the current
+// schema of the resource
+func (res *ResourceItem) compileHistory() error {
+	res.history = nil
+	if len(res.Properties.SchemaHistory) == 0 {
+		return nil
+	}
+
+	if res.SchemaVersionAttribute() == "" || res.schema == nil {
+		return fmt.Errorf("SchemaHistory requires a schema and its SchemaVersion")
+	}
+
+	history := make(map[string]*schema.Schema, len(res.Properties.SchemaHistory))
+	for _, version := range res.historyVersions() {
+		path := res.Properties.SchemaHistory[version]
+
+		writer, err := res.parseFile(path)
+		if err != nil {
+			return err
+		}
+
+		if err := schema.CheckCompatibility(res.schema.Element(), writer.Element()); err != nil {
+			return fmt.Errorf("schema version %s cannot be read with the current schema: %v", version, err)
+		}
+
+		history[version] = writer
+	}
+
+	res.history = history
+	return nil
+}
+
+// VersionItem stores the current schema version on the item, when the resource versions its items.
+func (res *ResourceItem) VersionItem(item map[string]*dynamodb.AttributeValue) {
+	if attribute := res.SchemaVersionAttribute(); attribute != "" {
+		item[attribute] = &dynamodb.AttributeValue{S: aws.String(res.Properties.SchemaVersion)}
+	}
+}
+
+// VersionData returns a copy of the data carrying the current schema version, when the resource
+// versions its items, so the version is updated along with the attributes of the item.
+func (res *ResourceItem) VersionData(data map[string]interface{}) map[string]interface{} {
+	attribute := res.SchemaVersionAttribute()
+	if attribute == "" {
+		return data
+	}
+
+	versioned := make(map[string]interface{}, len(data)+1)
+	for key, value := range data {
+		versioned[key] = value
+	}
+	versioned[attribute] = res.Properties.SchemaVersion
+
+	return versioned
+}
+
+// ResolveItem converts an item read from the table into the shape of the current schema, resolving
+// it from the schema of the version it was written with: new fields receive their default values,
+// removed fields are dropped and numbers are promoted. Items without a version are handled as
+// written with the current schema. The items of resources that don't version them are returned
+// as read. The fields composing the key attributes of the item are parsed from them first, and
+// the items projected by 'OutputColumns' only have the fields read resolved.
+func (res *ResourceItem) ResolveItem(item map[string]interface{}) (map[string]interface{}, error) {
+	item = res.DecomposeItem(item)
+
+	attribute := res.SchemaVersionAttribute()
+	if attribute == "" {
+		return item, nil
+	}
+
+	reader, err := res.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	writer := reader
+	if version, _ := item[attribute].(string); version != "" && version != res.Properties.SchemaVersion {
+		if writer = res.history[version]; writer == nil {
+			return nil, fmt.Errorf("item written with unknown schema version %s", version)
+		}
+	}
+
+	// the compatibility of the versions was checked by the compilation of the configuration
+	partial := len(res.Properties.OutputColumns) > 0
+	resolved, err := reader.Element().ResolveCompatible(writer.Element(), item, partial)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolved.(map[string]interface{}), nil
+}
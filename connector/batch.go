@@ -0,0 +1,395 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+)
+
+// Limits of the batch operations of DynamoDB.
+const (
+	MaxBatchWriteItems = 25
+	MaxBatchGetKeys    = 100
+)
+
+// Retries of the items left unprocessed by the batch operations.
+const (
+	DefaultBatchMaxAttempts = 6
+	batchBaseDelay          = 50 * time.Millisecond
+	batchMaxDelay           = 2 * time.Second
+)
+
+// Batcher is implemented by the connectors able to create, read and delete many items with fewer
+// calls. The responses follow the order of the records received.
+type Batcher interface {
+	CreateBatch(ctx context.Context, records []interface{}) []*lowcodeattribute.ExecutionResponse
+	ReadBatch(ctx context.Context, records []interface{}) []*lowcodeattribute.ExecutionResponse
+	DeleteBatch(ctx context.Context, records []interface{}) []*lowcodeattribute.ExecutionResponse
+}
+
+// batchEntry is a record of a batch identified by its key
+type batchEntry struct {
+	index   int
+	key     string
+	request *dynamodb.WriteRequest
+}
+
+// CreateBatch inserts the items of the records with BatchWriteItem, answering 201 (Created) with the
+// key of each item written.
+func (c *DynamoDB) CreateBatch(ctx context.Context, records []interface{}) []*lowcodeattribute.ExecutionResponse {
+	res := &c.Config.Resources.Connector
+
+	// each record writes the items of its own transaction, and the items of the tables deleting them
+	// logically are written with conditions, which BatchWriteItem doesn't support
+	if res.Transaction("POST") != nil || res.SoftDeletes() {
+		return each(ctx, records, c.Create)
+	}
+
+	return c.writeBatchToDynamoDB(ctx, records, func(record interface{}) (*dynamodb.WriteRequest, *lowcodeattribute.ExecutionResponse) {
+		record, err := res.ComposeKeys(record)
+		if err != nil {
+			return nil, lowcodeattribute.NewBadRequestResponse(err)
+		}
+		if !res.HasFullKey(record) {
+			return nil, lowcodeattribute.NewBadRequestResponse(fmt.Errorf("the key attributes are required to create an item"))
+		}
+
+		item, err := res.MarshalItem(record)
+		if err != nil {
+			return nil, lowcodeattribute.NewBadRequestResponse(err)
+		}
+		res.VersionItem(item)
+
+		return &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: item}}, &lowcodeattribute.ExecutionResponse{
+			StatusCode: 201,
+			Message:    res.ItemKey(record),
+		}
+	})
+}
+
+// DeleteBatch removes the items identified by the records with BatchWriteItem.
+func (c *DynamoDB) DeleteBatch(ctx context.Context, records []interface{}) []*lowcodeattribute.ExecutionResponse {
+	res := &c.Config.Resources.Connector
+
+	// the deleted items are marked by updates, which can't be written in batches
+	if res.Transaction("DELETE") != nil || res.SoftDeletes() {
+		return each(ctx, records, c.Delete)
+	}
+
+	return c.writeBatchToDynamoDB(ctx, records, func(record interface{}) (*dynamodb.WriteRequest, *lowcodeattribute.ExecutionResponse) {
+		record, err := res.ComposeKeys(record)
+		if err != nil {
+			return nil, lowcodeattribute.NewBadRequestResponse(err)
+		}
+		if !res.HasFullKey(record) {
+			return nil, lowcodeattribute.NewBadRequestResponse(fmt.Errorf("the key attributes are required to delete an item"))
+		}
+
+		keys, err := res.GetPrimaryKeyAttributeValue(record)
+		if err != nil {
+			return nil, lowcodeattribute.NewBadRequestResponse(err)
+		}
+
+		return &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{Key: keys}}, &lowcodeattribute.ExecutionResponse{
+			StatusCode: 200,
+		}
+	})
+}
+
+// writeBatchToDynamoDB é uma função interna responsável por gravar ou remover um lote de ítens de
+// uma tabela do DynamoDB, previamente indicada na configuração da função, por meio de chamadas
+// BatchWriteItem de até 25 ítens.
+//
+// Os ítens não processados pelo DynamoDB (UnprocessedItems) são reenviados com um intervalo
+// exponencial entre as tentativas. Cada registro recebe o seu próprio resultado: os registros
+// inválidos ou com chaves repetidas no lote falham com o código 400, e os ítens que continuarem sem
+// processamento após a última tentativa falham com o código 429.
+func (c *DynamoDB) writeBatchToDynamoDB(ctx context.Context, records []interface{}, build func(interface{}) (*dynamodb.WriteRequest, *lowcodeattribute.ExecutionResponse)) []*lowcodeattribute.ExecutionResponse {
+	res := &c.Config.Resources.Connector
+	responses := make([]*lowcodeattribute.ExecutionResponse, len(records))
+
+	var entries []batchEntry
+	seen := map[string]bool{}
+
+	for i, record := range records {
+		request, response := build(record)
+		responses[i] = response
+		if request == nil {
+			continue
+		}
+
+		// DynamoDB refuses the batches that write the same item twice
+		key := c.itemKey(writtenItem(request))
+		if seen[key] {
+			responses[i] = lowcodeattribute.NewBadRequestResponse(fmt.Errorf("the item is repeated in the batch"))
+			continue
+		}
+		seen[key] = true
+
+		entries = append(entries, batchEntry{index: i, key: key, request: request})
+	}
+
+	for start := 0; start < len(entries); start += MaxBatchWriteItems {
+		chunk := entries[start:min(start+MaxBatchWriteItems, len(entries))]
+
+		pending := make(map[string]batchEntry, len(chunk))
+		requests := make([]*dynamodb.WriteRequest, len(chunk))
+		for i, entry := range chunk {
+			pending[entry.key] = entry
+			requests[i] = entry.request
+		}
+
+		for attempt := 0; len(requests) > 0; attempt++ {
+			if attempt > 0 {
+				if attempt >= DefaultBatchMaxAttempts || !backoff(ctx, attempt) {
+					break
+				}
+			}
+
+			output, err := c.Client.BatchWriteItemWithContext(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems: map[string][]*dynamodb.WriteRequest{res.Properties.TableName: requests},
+			})
+			if err != nil {
+				for _, entry := range pending {
+					responses[entry.index] = lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to write batch: %w", err))
+				}
+				pending = nil
+				break
+			}
+
+			requests = output.UnprocessedItems[res.Properties.TableName]
+
+			unprocessed := make(map[string]batchEntry, len(requests))
+			for _, request := range requests {
+				key := c.itemKey(writtenItem(request))
+				unprocessed[key] = pending[key]
+			}
+			pending = unprocessed
+		}
+
+		for _, entry := range pending {
+			responses[entry.index] = lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindThrottled, "the item was not processed after %d attempts", DefaultBatchMaxAttempts))
+		}
+	}
+
+	return responses
+}
+
+// ReadBatch reads the items identified by the records with BatchGetItem, answering 404 (Not Found)
+// for the items that don't exist.
+func (c *DynamoDB) ReadBatch(ctx context.Context, records []interface{}) []*lowcodeattribute.ExecutionResponse {
+	return c.readBatchFromDynamoDB(ctx, records)
+}
+
+// readBatchFromDynamoDB é uma função interna responsável por ler um lote de ítens de uma tabela do
+// DynamoDB, identificados pelos atributos da chave primária de cada registro, por meio de chamadas
+// BatchGetItem de até 100 chaves.
+//
+// As chaves não processadas pelo DynamoDB (UnprocessedKeys) são reenviadas com um intervalo
+// exponencial entre as tentativas. Cada registro recebe o ítem encontrado, com o código 200, ou o código 404 caso
+// ele não exista. As colunas de 'OutputColumns' e o atributo 'ConsistentRead' são respeitados.
+func (c *DynamoDB) readBatchFromDynamoDB(ctx context.Context, records []interface{}) []*lowcodeattribute.ExecutionResponse {
+	res := &c.Config.Resources.Connector
+	responses := make([]*lowcodeattribute.ExecutionResponse, len(records))
+
+	// the records asking for the same item share its key
+	indexes := map[string][]int{}
+	keys := []map[string]*dynamodb.AttributeValue{}
+
+	for i, record := range records {
+		record, err := res.ComposeKeys(record)
+		if err != nil {
+			responses[i] = lowcodeattribute.NewBadRequestResponse(err)
+			continue
+		}
+		if !res.HasFullKey(record) {
+			responses[i] = lowcodeattribute.NewBadRequestResponse(fmt.Errorf("the key attributes are required to read an item"))
+			continue
+		}
+
+		key, err := res.GetPrimaryKeyAttributeValue(record)
+		if err != nil {
+			responses[i] = lowcodeattribute.NewBadRequestResponse(err)
+			continue
+		}
+
+		id := c.itemKey(key)
+		if _, found := indexes[id]; !found {
+			keys = append(keys, key)
+		}
+		indexes[id] = append(indexes[id], i)
+	}
+
+	projection, names := res.GetProjectionExpression()
+
+	// the key attributes identify the items read, and the attribute marking the deleted items hides
+	// them, so both are projected even when they are not output columns
+	var hidden []string
+	if projection != "" {
+		attributes := sortedKeys(res.Properties.Keys)
+		if res.SoftDeletes() {
+			attributes = append(attributes, res.Properties.SoftDelete.Attribute)
+		}
+
+		for _, attribute := range attributes {
+			if _, ok := names["#"+attribute]; !ok {
+				names["#"+attribute] = aws.String(attribute)
+				projection += ", #" + attribute
+				hidden = append(hidden, attribute)
+			}
+		}
+	}
+
+	for start := 0; start < len(keys); start += MaxBatchGetKeys {
+		request := &dynamodb.KeysAndAttributes{
+			Keys:           keys[start:min(start+MaxBatchGetKeys, len(keys))],
+			ConsistentRead: aws.Bool(res.Properties.ConsistentRead),
+		}
+		if projection != "" {
+			request.ProjectionExpression = aws.String(projection)
+			request.ExpressionAttributeNames = names
+		}
+
+		for attempt := 0; request != nil && len(request.Keys) > 0; attempt++ {
+			if attempt > 0 {
+				if attempt >= DefaultBatchMaxAttempts || !backoff(ctx, attempt) {
+					break
+				}
+			}
+
+			output, err := c.Client.BatchGetItemWithContext(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: map[string]*dynamodb.KeysAndAttributes{res.Properties.TableName: request},
+			})
+			if err != nil {
+				for _, key := range request.Keys {
+					c.answer(responses, indexes[c.itemKey(key)], lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to read batch: %w", err)))
+				}
+				request = nil
+				break
+			}
+
+			for _, item := range output.Responses[res.Properties.TableName] {
+				if res.IsDeleted(item) {
+					continue
+				}
+
+				id := c.itemKey(item)
+				for _, attribute := range hidden {
+					delete(item, attribute)
+				}
+				c.answer(responses, indexes[id], c.itemResponse(item))
+			}
+
+			request = output.UnprocessedKeys[res.Properties.TableName]
+		}
+
+		if request != nil {
+			for _, key := range request.Keys {
+				c.answer(responses, indexes[c.itemKey(key)], lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindThrottled, "the item was not read after %d attempts", DefaultBatchMaxAttempts)))
+			}
+		}
+	}
+
+	for i, response := range responses {
+		if response == nil {
+			responses[i] = lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindNotFound, "item not found"))
+		}
+	}
+
+	return responses
+}
+
+// each executes the action for each one of the records
+func each(ctx context.Context, records []interface{}, action func(context.Context, interface{}) *lowcodeattribute.ExecutionResponse) []*lowcodeattribute.ExecutionResponse {
+	responses := make([]*lowcodeattribute.ExecutionResponse, len(records))
+	for i, record := range records {
+		responses[i] = action(ctx, record)
+	}
+
+	return responses
+}
+
+// answer sets the response of the records found at the indexes
+func (c *DynamoDB) answer(responses []*lowcodeattribute.ExecutionResponse, indexes []int, response *lowcodeattribute.ExecutionResponse) {
+	for _, index := range indexes {
+		responses[index] = response
+	}
+}
+
+// itemResponse creates the response of an item read from the table
+func (c *DynamoDB) itemResponse(item map[string]*dynamodb.AttributeValue) *lowcodeattribute.ExecutionResponse {
+	var record map[string]interface{}
+	if err := dynamodbattribute.UnmarshalMap(item, &record); err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to deserialize response: %w", err))
+	}
+
+	resolved, err := c.Config.Resources.Connector.ResolveItem(record)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(lowcodeattribute.NewError(lowcodeattribute.KindInternal, "failed to resolve item schema", err))
+	}
+
+	return &lowcodeattribute.ExecutionResponse{StatusCode: 200, Message: resolved}
+}
+
+// writtenItem returns the item put, or the key of the item deleted, by a write request
+func writtenItem(request *dynamodb.WriteRequest) map[string]*dynamodb.AttributeValue {
+	if request.PutRequest != nil {
+		return request.PutRequest.Item
+	}
+
+	return request.DeleteRequest.Key
+}
+
+// itemKey identifies an item by the values of its key attributes
+func (c *DynamoDB) itemKey(item map[string]*dynamodb.AttributeValue) string {
+	names := sortedKeys(c.Config.Resources.Connector.Properties.Keys)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		if value := item[name]; value != nil {
+			parts[i] = aws.StringValue(value.S) + aws.StringValue(value.N) + string(value.B)
+		}
+	}
+
+	return strings.Join(parts, "\x00")
+}
+
+// backoff waits before the attempt received, doubling the delay of each attempt with jitter. It
+// reports false when the invocation must answer instead of waiting.
+func backoff(ctx context.Context, attempt int) bool {
+	delay := batchBaseDelay << (attempt - 1)
+	if delay > batchMaxDelay {
+		delay = batchMaxDelay
+	}
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay+scanDeadlineMargin {
+		return false
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
+
+// sortedKeys returns the names of the key attributes in order
+func sortedKeys(keys map[string]string) []string {
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
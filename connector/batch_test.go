@@ -0,0 +1,105 @@
+package connector
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestReadBatchProjectsTheKeys(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties string
+		want       map[string]interface{}
+	}{
+		{
+			name:       "all attributes",
+			properties: "Keys:\n  UserID: EQ\n",
+			want:       map[string]interface{}{"UserID": "1", "Name": "Ana", "Age": 30.0},
+		},
+		{
+			name:       "output columns without the key",
+			properties: "Keys:\n  UserID: EQ\nOutputColumns: [Name]\n",
+			want:       map[string]interface{}{"Name": "Ana"},
+		},
+		{
+			name:       "output columns with the key",
+			properties: "Keys:\n  UserID: EQ\nOutputColumns: [UserID, Name]\n",
+			want:       map[string]interface{}{"UserID": "1", "Name": "Ana"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &stubDynamoDB{}
+			client.batchGetItem = func(input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+				request := input.RequestItems["users"]
+
+				item := marshalItem(t, map[string]interface{}{"UserID": "1", "Name": "Ana", "Age": 30})
+				if request.ProjectionExpression != nil {
+					// DynamoDB answers only the attributes projected
+					projected := map[string]*dynamodb.AttributeValue{}
+					for _, name := range request.ExpressionAttributeNames {
+						if value, ok := item[aws.StringValue(name)]; ok {
+							projected[aws.StringValue(name)] = value
+						}
+					}
+					item = projected
+				}
+
+				return &dynamodb.BatchGetItemOutput{
+					Responses: map[string][]map[string]*dynamodb.AttributeValue{"users": {item}},
+				}, nil
+			}
+
+			conn := NewDynamoDB(loadConfig(t, tt.properties), client)
+			responses := conn.ReadBatch(context.Background(), []interface{}{
+				map[string]interface{}{"UserID": "1"},
+				map[string]interface{}{"UserID": "2"},
+			})
+
+			if responses[0].StatusCode != 200 || !reflect.DeepEqual(responses[0].Message, tt.want) {
+				t.Errorf("ReadBatch()[0] = %d %#v, want 200 %#v", responses[0].StatusCode, responses[0].Message, tt.want)
+			}
+			if responses[1].StatusCode != 404 {
+				t.Errorf("ReadBatch()[1] = %d, want 404", responses[1].StatusCode)
+			}
+		})
+	}
+}
+
+func TestReadBatchRetriesTheUnprocessedKeys(t *testing.T) {
+	client := &stubDynamoDB{}
+	client.batchGetItem = func(input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+		request := input.RequestItems["users"]
+
+		// the first key of each call is read, and the others are left unprocessed
+		output := &dynamodb.BatchGetItemOutput{
+			Responses: map[string][]map[string]*dynamodb.AttributeValue{"users": {request.Keys[0]}},
+		}
+		if len(request.Keys) > 1 {
+			output.UnprocessedKeys = map[string]*dynamodb.KeysAndAttributes{"users": {Keys: request.Keys[1:]}}
+		}
+
+		return output, nil
+	}
+
+	conn := NewDynamoDB(loadConfig(t, "Keys:\n  UserID: EQ\n"), client)
+	responses := conn.ReadBatch(context.Background(), []interface{}{
+		map[string]interface{}{"UserID": "1"},
+		map[string]interface{}{"UserID": "2"},
+		map[string]interface{}{"UserID": "1"},
+	})
+
+	for i, want := range []string{"1", "2", "1"} {
+		if responses[i].StatusCode != 200 || responses[i].Message.(map[string]interface{})["UserID"] != want {
+			t.Errorf("ReadBatch()[%d] = %d %v, want the user %s", i, responses[i].StatusCode, responses[i].Message, want)
+		}
+	}
+	if got := client.count("BatchGetItem"); got != 2 {
+		t.Errorf("BatchGetItem called %d times, want 2", got)
+	}
+}
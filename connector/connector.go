@@ -0,0 +1,21 @@
+package connector
+
+import (
+	"context"
+
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+)
+
+// Connector is the contract implemented by every resource able to persist and recover the data
+// received by the function, such as the DynamoDB connector.
+type Connector interface {
+	Create(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse
+	Read(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse
+	Update(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse
+	Delete(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse
+}
+
+// Factory creates a connector for the configuration received. The function chooses the factory
+// using the 'ResourceType' of the 'Connector' resource of the configuration.
+type Factory func(conf *config.Config) (Connector, error)
@@ -0,0 +1,480 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+)
+
+// DynamoDB is the connector responsible for persisting and recovering the items of the DynamoDB
+// table indicated in the 'Connector' resource of the configuration it carries.
+type DynamoDB struct {
+	Config *config.Config
+	Client dynamodbiface.DynamoDBAPI
+}
+
+// NewDynamoDB creates a DynamoDB connector using the configuration and client received.
+func NewDynamoDB(conf *config.Config, client dynamodbiface.DynamoDBAPI) *DynamoDB {
+	return &DynamoDB{
+		Config: conf,
+		Client: client,
+	}
+}
+
+// DynamoDBFactory returns the factory used by the function to create DynamoDB connectors sharing
+// the client received.
+func DynamoDBFactory(client dynamodbiface.DynamoDBAPI) Factory {
+	return func(conf *config.Config) (Connector, error) {
+		return NewDynamoDB(conf, client), nil
+	}
+}
+
+// Create inserts a new item on the table, or writes the items of the transaction of the POST method
+func (c *DynamoDB) Create(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	data, err := c.Config.Resources.Connector.ComposeKeys(data)
+	if err != nil {
+		return lowcodeattribute.NewBadRequestResponse(err)
+	}
+
+	if !c.Config.Resources.Connector.HasFullKey(data) {
+		return lowcodeattribute.NewBadRequestResponse(errors.New("the key attributes are required to create an item"))
+	}
+
+	if tx := c.Config.Resources.Connector.Transaction("POST"); tx != nil {
+		return c.transactOnDynamoDB(ctx, tx, 201, data)
+	}
+	return c.saveToDynamoDB(ctx, data)
+}
+
+// Read queries the items of the table that match the keys received, nesting the related items
+// asked by the expand parameter
+func (c *DynamoDB) Read(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	relations, err := c.expansions(ctx)
+	if err != nil {
+		return lowcodeattribute.NewBadRequestResponse(err)
+	}
+
+	data, err = c.Config.Resources.Connector.ComposeKeys(data)
+	if err != nil {
+		return lowcodeattribute.NewBadRequestResponse(err)
+	}
+
+	response := c.readFromDynamoDB(ctx, data)
+	if len(relations) == 0 || response.StatusCode != 200 {
+		return response
+	}
+
+	return c.expandOnDynamoDB(ctx, response, relations)
+}
+
+// Update replaces an item of the table, or writes the items of the transaction of the PUT method
+func (c *DynamoDB) Update(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	data, err := c.Config.Resources.Connector.ComposeKeys(data)
+	if err != nil {
+		return lowcodeattribute.NewBadRequestResponse(err)
+	}
+
+	if !c.Config.Resources.Connector.HasFullKey(data) {
+		return lowcodeattribute.NewBadRequestResponse(errors.New("the key attributes are required to update an item"))
+	}
+
+	if tx := c.Config.Resources.Connector.Transaction("PUT"); tx != nil {
+		return c.transactOnDynamoDB(ctx, tx, 200, data)
+	}
+	return c.updateOnDynamoDB(ctx, data)
+}
+
+// Delete removes an item of the table, or writes the items of the transaction of the DELETE method
+func (c *DynamoDB) Delete(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	data, err := c.Config.Resources.Connector.ComposeKeys(data)
+	if err != nil {
+		return lowcodeattribute.NewBadRequestResponse(err)
+	}
+
+	if !c.Config.Resources.Connector.HasFullKey(data) {
+		return lowcodeattribute.NewBadRequestResponse(errors.New("the key attributes are required to delete an item"))
+	}
+
+	if tx := c.Config.Resources.Connector.Transaction("DELETE"); tx != nil {
+		return c.transactOnDynamoDB(ctx, tx, 200, data)
+	}
+	return c.deleteOnDynamoDB(ctx, data)
+}
+
+// CounterValueAttribute is the attribute of the counter items holding the last value generated.
+const CounterValueAttribute = "Value"
+
+// Next increments atomically the counter stored on the item of the table whose partition key has
+// the name received, creating the item when it doesn't exist, and returns its new value.
+func (c *DynamoDB) Next(ctx context.Context, table, key, name string) (int64, error) {
+	output, err := c.Client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(table),
+		Key: map[string]*dynamodb.AttributeValue{
+			key: {S: aws.String(name)},
+		},
+		UpdateExpression:         aws.String("ADD #value :one"),
+		ExpressionAttributeNames: map[string]*string{"#value": aws.String(CounterValueAttribute)},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":one": {N: aws.String("1")},
+		},
+		ReturnValues: aws.String(dynamodb.ReturnValueUpdatedNew),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed incrementing counter %s: %v", name, err)
+	}
+
+	value, ok := output.Attributes[CounterValueAttribute]
+	if !ok || value.N == nil {
+		return 0, fmt.Errorf("counter %s returned no value", name)
+	}
+
+	return strconv.ParseInt(*value.N, 10, 64)
+}
+
+// saveToDynamoDB é uma função interna responsável por inserir um novo registro do DynamoDB na tabela
+// que foi previamente indicado na configuração da função.
+//
+// Se o novo ítem for inserido com sucesso na tabela, a função retornará um código de status 201, indicando
+// que o registro foi criado com sucesso, no entanto, se algo der errado, ele deverá retornar um erro 500 e
+// indicar uma mensagem com o erro ocorrido.
+//
+// Os dados a serem registrados na tabela devem ser indicados no corpo da requisição e o caminho do
+// arquivo avsc (avro) com a estrutura do objeto deve ter sido especificado na configuração da função
+//
+// Com a configuração 'SoftDelete', a inserção de um ítem removido logicamente retornará um código
+// 409, já que ele precisa ser restaurado antes de ser gravado novamente.
+//
+// A resposta de sucesso traz os valores da chave primária do ítem criado, incluindo os valores gerados
+// pela seção 'Generators' da configuração. Quando as chaves forem compostas por templates, como
+// 'USER#{UserID}', a resposta traz os campos que as compõem.
+//
+// Para usar esta função, você também precisa especificar o Nome da Tabela do DynamoDB e as chaves que
+// compõem a chave primária da tabela.
+func (c *DynamoDB) saveToDynamoDB(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	item, err := c.Config.Resources.Connector.MarshalItem(data)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed marshal data: %w", err))
+	}
+
+	res := &c.Config.Resources.Connector
+	res.VersionItem(item)
+
+	input := &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: aws.String(res.Properties.TableName),
+	}
+
+	// the deleted items are only written again after being restored
+	if condition := res.TombstoneCondition(); condition != "" {
+		input.ConditionExpression = aws.String(condition)
+		input.ExpressionAttributeNames = map[string]*string{"#" + res.Properties.SoftDelete.Attribute: aws.String(res.Properties.SoftDelete.Attribute)}
+	}
+
+	_, err = c.Client.PutItemWithContext(ctx, input)
+	if err != nil {
+		var failed *dynamodb.ConditionalCheckFailedException
+		if errors.As(err, &failed) {
+			return lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindConflict, "the item was deleted and must be restored"))
+		}
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed input new item: %w", err))
+	}
+
+	return &lowcodeattribute.ExecutionResponse{
+		StatusCode: 201,
+		Message:    c.Config.Resources.Connector.ItemKey(data),
+	}
+}
+
+// readFromDynamoDB é uma função interna que possibilita realizar consultas em uma tabela do DynamoDB
+// previamente indicada nas configurações da função.
+//
+// Se você setar o atributo 'ProjectionCols', a consulta irá retornar apenas as colunas que foram
+// préviamente indicadas.
+//
+// Se você setar os atributos 'Filter' e 'FilterValues', este filtro será aplicado a query, realizando
+// uma consulta mais específica mediante as regras indicadas. Os valores também podem ser calculados
+// a cada requisição por expressões CEL indicadas em 'FilterExpressions'.
+//
+// Se o atributo 'SchemaVersion' for informado, os ítens gravados com versões anteriores do schema
+// ('SchemaHistory') serão convertidos para o schema atual antes de serem retornados.
+//
+// Os ítens encontrados são retornados como uma lista de objetos, codificada pelo receiver no formato
+// negociado com o cliente, e o número de ítens examinados pela consulta é indicado em 'Meta'.
+//
+// Quando todos os atributos de 'Keys' forem informados, a consulta identifica um único ítem, que é
+// retornado como um objeto, ou um erro 404 caso ele não exista. Sem filtros, o ítem é lido com um
+// GetItem. Com o atributo 'ConsistentRead', as leituras são fortemente consistentes.
+//
+// Com a configuração 'SoftDelete', os ítens removidos logicamente não são retornados pelas leituras.
+//
+// Quando as chaves forem compostas por templates ('KeyTemplates' ou 'Entities'), como
+// 'ORDER#{OrderDate}#{OrderID}', a chave de ordenação ('RangeKey') informada parcialmente seleciona
+// os ítens que começam com a parte composta (begins_with), e os campos de cada ítem retornado são
+// extraídos das suas chaves. A chave de partição ('HashKey') precisa ser informada por completo, caso
+// contrário a função retornará um código 400.
+//
+// Leituras sem nenhum atributo da chave listam a tabela inteira por meio de um Scan, desde que a
+// configuração 'Scan' esteja habilitada, aplicando os filtros e as colunas de 'OutputColumns'. A
+// listagem é paginada pelos parâmetros 'limit' e 'cursor' da query string.
+//
+// Para usar esta função, você também precisa especificar o Nome da Tabela do DynamoDB e as chaves que
+// compõem a chave primária da tabela.
+func (c *DynamoDB) readFromDynamoDB(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	if !c.Config.Resources.Connector.HasKey(data) {
+		if c.Config.Resources.Connector.CanScan() {
+			return c.scanFromDynamoDB(ctx)
+		}
+
+		return lowcodeattribute.NewBadRequestResponse(fmt.Errorf("the key attributes are required to read the table"))
+	}
+
+	// the queries select a single partition, so its key can't be a prefix
+	if !c.Config.Resources.Connector.HasPartitionKey(data) {
+		return lowcodeattribute.NewBadRequestResponse(fmt.Errorf("the partition key %s is required to read the table", c.Config.Resources.Connector.Properties.HashKey))
+	}
+
+	single := c.Config.Resources.Connector.HasFullKey(data)
+
+	// the filters can only be applied by a query, while the deleted items are hidden by the GetItem
+	if single && len(c.Config.Resources.Connector.Properties.Filter) == 0 {
+		return c.getFromDynamoDB(ctx, data)
+	}
+
+	names, err := c.Config.Resources.Connector.GetKeyAttributeNames(data)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed getting attribute names: %w", err))
+	}
+
+	values, err := c.Config.Resources.Connector.GetKeyAttributeValues(data)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed getting attribute values: %w", err))
+	}
+
+	conditions, err := c.Config.Resources.Connector.GetKeyConditions(data)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to execute a table query: %w", err))
+	}
+
+	queryInput := &dynamodb.QueryInput{
+		TableName:                 aws.String(c.Config.Resources.Connector.Properties.TableName),
+		KeyConditionExpression:    aws.String(conditions),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ConsistentRead:            aws.Bool(c.Config.Resources.Connector.Properties.ConsistentRead),
+	}
+
+	if filter := c.Config.Resources.Connector.GetFilterExpression(); filter != "" {
+		req, _ := mapping.FromContext(ctx)
+
+		// the filter values are derived from the request, so they fail on requests missing them
+		filterValues, err := c.Config.Resources.Connector.GetFilterAttributeValues(req)
+		if err != nil {
+			return lowcodeattribute.NewBadRequestResponse(err)
+		}
+
+		for name, value := range c.Config.Resources.Connector.GetFilterAttributeNames() {
+			names[name] = value
+		}
+		for name, value := range filterValues {
+			values[name] = value
+		}
+		queryInput.FilterExpression = aws.String(filter)
+	}
+	result, err := c.Client.QueryWithContext(ctx, queryInput)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to execute a table query: %w", err))
+	}
+
+	var jsonMap []map[string]interface{}
+	err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &jsonMap)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to deserialize response: %w", err))
+	}
+
+	for i, item := range jsonMap {
+		if jsonMap[i], err = c.Config.Resources.Connector.ResolveItem(item); err != nil {
+			return lowcodeattribute.NewErrorResponse(lowcodeattribute.NewError(lowcodeattribute.KindInternal, "failed to resolve item schema", err))
+		}
+	}
+
+	if single {
+		if len(jsonMap) == 0 {
+			return lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindNotFound, "item not found"))
+		}
+
+		return &lowcodeattribute.ExecutionResponse{
+			StatusCode: 200,
+			Message:    jsonMap[0],
+		}
+	}
+
+	if jsonMap == nil {
+		jsonMap = []map[string]interface{}{}
+	}
+
+	return &lowcodeattribute.ExecutionResponse{
+		StatusCode: 200,
+		Message:    jsonMap,
+		Meta: map[string]interface{}{
+			"scannedCount": aws.Int64Value(result.ScannedCount),
+		},
+	}
+}
+
+// getFromDynamoDB reads the item identified by the primary key received, answering 404 (Not Found)
+// when it doesn't exist
+func (c *DynamoDB) getFromDynamoDB(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	keys, err := c.Config.Resources.Connector.GetPrimaryKeyAttributeValue(data)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to get primary key: %w", err))
+	}
+
+	result, err := c.Client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(c.Config.Resources.Connector.Properties.TableName),
+		Key:            keys,
+		ConsistentRead: aws.Bool(c.Config.Resources.Connector.Properties.ConsistentRead),
+	})
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to get table item: %w", err))
+	}
+
+	if len(result.Item) == 0 || c.Config.Resources.Connector.IsDeleted(result.Item) {
+		return lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindNotFound, "item not found"))
+	}
+
+	var item map[string]interface{}
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &item); err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to deserialize response: %w", err))
+	}
+
+	if item, err = c.Config.Resources.Connector.ResolveItem(item); err != nil {
+		return lowcodeattribute.NewErrorResponse(lowcodeattribute.NewError(lowcodeattribute.KindInternal, "failed to resolve item schema", err))
+	}
+
+	return &lowcodeattribute.ExecutionResponse{
+		StatusCode: 200,
+		Message:    item,
+	}
+}
+
+// updateOnDynamoDB é uma função interna responsável por atualizar, remover ou adicionar os atributos
+// de uma tabela do DynamoDB previamente especificada nas configurações da função. Se o ítem for atualizado
+// com sucesso, a função retornará um código de status 200 em resposta a sua requisição, entretant,
+// se algo der errado, ela retornará o status 500 jutamente com a descrição do erro.
+//
+// Os atributos do ítem que serão modificados, juntamente com os atributos da chave primária precisam ser
+// enviados no corpo da requisição para que a atualização seja efetuada.
+//
+// A atualização substitui o ítem inteiro pelo registro recebido, validado pelo schema do conector, e os
+// atributos ausentes do registro são removidos do ítem. Caso o ítem não exista, a função retornará um
+// código 404, assim como os ítens removidos logicamente ('SoftDelete'), que precisam ser restaurados
+// antes. Atualizações parciais devem usar o método PATCH.
+func (c *DynamoDB) updateOnDynamoDB(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	res := &c.Config.Resources.Connector
+
+	if !res.HasFullKey(data) {
+		return lowcodeattribute.NewBadRequestResponse(errors.New("the key attributes are required to update an item"))
+	}
+
+	item, err := res.MarshalItem(data)
+	if lowcodeattribute.IsValidationError(err) {
+		return lowcodeattribute.NewBadRequestResponse(err)
+	}
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed marshal data: %w", err))
+	}
+
+	res.VersionItem(item)
+
+	// the item is replaced only when it exists, so the updates don't create items
+	names := map[string]*string{}
+	conditions := []string{}
+	for key := range res.Properties.Keys {
+		names["#"+key] = aws.String(key)
+		conditions = append(conditions, fmt.Sprintf("attribute_exists(#%s)", key))
+	}
+	sort.Strings(conditions)
+
+	// the deleted items are only replaced after being restored
+	if condition := res.TombstoneCondition(); condition != "" {
+		names["#"+res.Properties.SoftDelete.Attribute] = aws.String(res.Properties.SoftDelete.Attribute)
+		conditions = append(conditions, condition)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:                aws.String(res.Properties.TableName),
+		Item:                     item,
+		ConditionExpression:      aws.String(strings.Join(conditions, " AND ")),
+		ExpressionAttributeNames: names,
+	}
+
+	_, err = c.Client.PutItemWithContext(ctx, input)
+	if err != nil {
+		var failed *dynamodb.ConditionalCheckFailedException
+		if errors.As(err, &failed) {
+			return lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindNotFound, "item not found"))
+		}
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to replace table item: %w", err))
+	}
+
+	var record map[string]interface{}
+	if err := dynamodbattribute.UnmarshalMap(item, &record); err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to deserialize response: %w", err))
+	}
+
+	resolved, err := res.ResolveItem(record)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(lowcodeattribute.NewError(lowcodeattribute.KindInternal, "failed to resolve item schema", err))
+	}
+
+	return &lowcodeattribute.ExecutionResponse{
+		StatusCode: 200,
+		Message:    resolved,
+	}
+}
+
+// delete is an internal function responsible for remove an item of the DynamoDB table using the settings
+// specified in your configuration file. If the item is removed successfully, you will receive a 200 (Ok)
+// status code in response of your request. However, if something goes wrong, you will receive a 500 status
+// code and an error specifying the problem
+//
+// you need to send the values of the keys in your request to properly remove the item
+//
+// When 'SoftDelete' is configured, the item is marked as deleted instead of being removed.
+//
+// To use this function, you need to specify the 'TableName' and 'Keys' in your configuration file.
+func (c *DynamoDB) deleteOnDynamoDB(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	if c.Config.Resources.Connector.SoftDeletes() {
+		return c.softDeleteOnDynamoDB(ctx, data)
+	}
+
+	keys, err := c.Config.Resources.Connector.GetPrimaryKeyAttributeValue(data.(map[string]interface{}))
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to get primary key: %w", err))
+	}
+
+	deleteInput := dynamodb.DeleteItemInput{
+		TableName: aws.String(c.Config.Resources.Connector.Properties.TableName),
+		Key:       keys,
+	}
+
+	_, err = c.Client.DeleteItemWithContext(ctx, &deleteInput)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to remove table item: %w", err))
+	}
+
+	return &lowcodeattribute.ExecutionResponse{
+		StatusCode: 200,
+	}
+}
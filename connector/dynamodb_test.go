@@ -0,0 +1,140 @@
+package connector
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+)
+
+const userSchema = `{
+  "type": "object",
+  "properties": {
+    "UserID": { "type": "string" },
+    "Name": { "type": "string" },
+    "Age": { "type": "integer" }
+  }
+}`
+
+// stubDynamoDB answers the calls of the connector with the functions informed, counting them.
+// The calls without a function panic through the nil interface embedded.
+type stubDynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+
+	mu    sync.Mutex
+	calls map[string]int
+
+	batchGetItem       func(*dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error)
+	batchWriteItem     func(*dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error)
+	deleteItem         func(*dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error)
+	getItem            func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
+	putItem            func(*dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
+	query              func(*dynamodb.QueryInput) (*dynamodb.QueryOutput, error)
+	scan               func(*dynamodb.ScanInput) (*dynamodb.ScanOutput, error)
+	transactWriteItems func(*dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error)
+	updateItem         func(*dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error)
+}
+
+func (s *stubDynamoDB) record(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.calls == nil {
+		s.calls = map[string]int{}
+	}
+	s.calls[name]++
+}
+
+func (s *stubDynamoDB) count(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[name]
+}
+
+func (s *stubDynamoDB) BatchGetItemWithContext(_ context.Context, input *dynamodb.BatchGetItemInput, _ ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	s.record("BatchGetItem")
+	return s.batchGetItem(input)
+}
+
+func (s *stubDynamoDB) BatchWriteItemWithContext(_ context.Context, input *dynamodb.BatchWriteItemInput, _ ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	s.record("BatchWriteItem")
+	return s.batchWriteItem(input)
+}
+
+func (s *stubDynamoDB) DeleteItemWithContext(_ context.Context, input *dynamodb.DeleteItemInput, _ ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	s.record("DeleteItem")
+	return s.deleteItem(input)
+}
+
+func (s *stubDynamoDB) GetItemWithContext(_ context.Context, input *dynamodb.GetItemInput, _ ...request.Option) (*dynamodb.GetItemOutput, error) {
+	s.record("GetItem")
+	return s.getItem(input)
+}
+
+func (s *stubDynamoDB) PutItemWithContext(_ context.Context, input *dynamodb.PutItemInput, _ ...request.Option) (*dynamodb.PutItemOutput, error) {
+	s.record("PutItem")
+	return s.putItem(input)
+}
+
+func (s *stubDynamoDB) QueryWithContext(_ context.Context, input *dynamodb.QueryInput, _ ...request.Option) (*dynamodb.QueryOutput, error) {
+	s.record("Query")
+	return s.query(input)
+}
+
+func (s *stubDynamoDB) ScanWithContext(_ context.Context, input *dynamodb.ScanInput, _ ...request.Option) (*dynamodb.ScanOutput, error) {
+	s.record("Scan")
+	return s.scan(input)
+}
+
+func (s *stubDynamoDB) TransactWriteItemsWithContext(_ context.Context, input *dynamodb.TransactWriteItemsInput, _ ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+	s.record("TransactWriteItems")
+	return s.transactWriteItems(input)
+}
+
+func (s *stubDynamoDB) UpdateItemWithContext(_ context.Context, input *dynamodb.UpdateItemInput, _ ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	s.record("UpdateItem")
+	return s.updateItem(input)
+}
+
+// loadConfig compiles the configuration of a DynamoDB connector whose Properties are received, on
+// the table users with the schema of the users
+func loadConfig(t testing.TB, properties string) *config.Config {
+	t.Helper()
+
+	lines := strings.Split(strings.Trim(properties, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = "      " + line
+	}
+
+	document := "Resources:\n  Connector:\n    ResourceType: DynamoDB\n    ObjectPathSchema: user.json\n" +
+		"    Properties:\n      TableName: users\n" + strings.Join(lines, "\n") + "\n"
+
+	conf, err := config.LoadFrom(config.NewMemorySource(map[string][]byte{
+		"config.yaml": []byte(document),
+		"user.json":   []byte(userSchema),
+	}), "config.yaml")
+	if err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+
+	return conf
+}
+
+// marshalItem converts a record into the attributes of an item of the table
+func marshalItem(t testing.TB, record map[string]interface{}) map[string]*dynamodb.AttributeValue {
+	t.Helper()
+
+	item, err := dynamodbattribute.MarshalMap(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return item
+}
@@ -0,0 +1,127 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/idempotency"
+)
+
+// Acquire stores the idempotency record in progress, unless the table holds a completed record
+// with the same key that didn't expire, or a record in progress whose lock didn't expire, which is
+// returned instead.
+func (c *DynamoDB) Acquire(ctx context.Context, settings *idempotency.Settings, record *idempotency.Record) (*idempotency.Record, error) {
+	item, err := idempotencyItem(settings, record)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.Client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(settings.TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(#key) OR #expires < :now OR (#status = :progress AND #lock < :now)"),
+		ExpressionAttributeNames: map[string]*string{
+			"#key":     aws.String(settings.KeyAttribute),
+			"#expires": aws.String("ExpiresAt"),
+			"#status":  aws.String("Status"),
+			"#lock":    aws.String("LockExpiresAt"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now":      {N: aws.String(strconv.FormatInt(time.Now().Unix(), 10))},
+			":progress": {S: aws.String(idempotency.StatusInProgress)},
+		},
+		ReturnValuesOnConditionCheckFailure: aws.String(dynamodb.ReturnValuesOnConditionCheckFailureAllOld),
+	})
+
+	var failed *dynamodb.ConditionalCheckFailedException
+	if errors.As(err, &failed) && failed.Item != nil {
+		existing := &idempotency.Record{Key: record.Key}
+		if err := dynamodbattribute.UnmarshalMap(failed.Item, existing); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+
+	return nil, err
+}
+
+// Complete stores the idempotency record completed with the response of its request, when the
+// table still holds the record in progress acquired by the request. The record acquired by another
+// request, after the lock expired, is kept and idempotency.ErrLockLost is returned.
+func (c *DynamoDB) Complete(ctx context.Context, settings *idempotency.Settings, record *idempotency.Record) error {
+	item, err := idempotencyItem(settings, record)
+	if err != nil {
+		return err
+	}
+
+	names, values, condition := lockCondition(record)
+
+	_, err = c.Client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(settings.TableName),
+		Item:                      item,
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+
+	var failed *dynamodb.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return idempotency.ErrLockLost
+	}
+
+	return err
+}
+
+// Release removes the idempotency record in progress acquired by the request, so its request can
+// be retried.
+func (c *DynamoDB) Release(ctx context.Context, settings *idempotency.Settings, record *idempotency.Record) error {
+	names, values, condition := lockCondition(record)
+
+	_, err := c.Client.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 aws.String(settings.TableName),
+		Key:                       map[string]*dynamodb.AttributeValue{settings.KeyAttribute: {S: aws.String(record.Key)}},
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+
+	// the record acquired or completed by another request is kept
+	var failed *dynamodb.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return nil
+	}
+
+	return err
+}
+
+// lockCondition returns the condition matching the record in progress acquired by the request
+func lockCondition(record *idempotency.Record) (map[string]*string, map[string]*dynamodb.AttributeValue, string) {
+	names := map[string]*string{
+		"#status": aws.String("Status"),
+		"#hash":   aws.String("PayloadHash"),
+		"#token":  aws.String("LockToken"),
+	}
+	values := map[string]*dynamodb.AttributeValue{
+		":progress": {S: aws.String(idempotency.StatusInProgress)},
+		":hash":     {S: aws.String(record.PayloadHash)},
+		":token":    {S: aws.String(record.LockToken)},
+	}
+
+	return names, values, "#status = :progress AND #hash = :hash AND #token = :token"
+}
+
+// idempotencyItem converts an idempotency record into an item of the idempotency table
+func idempotencyItem(settings *idempotency.Settings, record *idempotency.Record) (map[string]*dynamodb.AttributeValue, error) {
+	item, err := dynamodbattribute.MarshalMap(record)
+	if err != nil {
+		return nil, err
+	}
+	item[settings.KeyAttribute] = &dynamodb.AttributeValue{S: aws.String(record.Key)}
+
+	return item, nil
+}
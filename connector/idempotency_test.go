@@ -0,0 +1,139 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/raywall/aws-lowcode-lambda-go/idempotency"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+)
+
+// idempotencyTable keeps the items of the idempotency table, checking the conditions written by the
+// connector
+func idempotencyTable(items map[string]map[string]*dynamodb.AttributeValue) *stubDynamoDB {
+	key := func(item map[string]*dynamodb.AttributeValue) string {
+		return aws.StringValue(item[idempotency.DefaultKeyAttribute].S)
+	}
+
+	// owned reports whether the item is the record in progress of the values of the condition
+	owned := func(item map[string]*dynamodb.AttributeValue, values map[string]*dynamodb.AttributeValue) bool {
+		return item != nil && aws.StringValue(item["Status"].S) == idempotency.StatusInProgress &&
+			aws.StringValue(item["PayloadHash"].S) == aws.StringValue(values[":hash"].S) &&
+			aws.StringValue(item["LockToken"].S) == aws.StringValue(values[":token"].S)
+	}
+
+	client := &stubDynamoDB{}
+	client.putItem = func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+		current := items[key(input.Item)]
+
+		var allowed bool
+		if !strings.HasPrefix(aws.StringValue(input.ConditionExpression), "attribute_not_exists") {
+			allowed = owned(current, input.ExpressionAttributeValues)
+		} else {
+			now, _ := strconv.ParseInt(aws.StringValue(input.ExpressionAttributeValues[":now"].N), 10, 64)
+			number := func(name string) int64 {
+				n, _ := strconv.ParseInt(aws.StringValue(current[name].N), 10, 64)
+				return n
+			}
+			allowed = current == nil || number("ExpiresAt") < now ||
+				(aws.StringValue(current["Status"].S) == idempotency.StatusInProgress && number("LockExpiresAt") < now)
+		}
+
+		if !allowed {
+			return nil, &dynamodb.ConditionalCheckFailedException{Message_: aws.String("failed"), Item: current}
+		}
+		items[key(input.Item)] = input.Item
+		return &dynamodb.PutItemOutput{}, nil
+	}
+	client.deleteItem = func(input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+		if !owned(items[key(input.Key)], input.ExpressionAttributeValues) {
+			return nil, &dynamodb.ConditionalCheckFailedException{Message_: aws.String("failed")}
+		}
+		delete(items, key(input.Key))
+		return &dynamodb.DeleteItemOutput{}, nil
+	}
+
+	return client
+}
+
+func TestIdempotencyReplaysTheCompletedResponse(t *testing.T) {
+	ctx := context.Background()
+	items := map[string]map[string]*dynamodb.AttributeValue{}
+	conn := NewDynamoDB(loadConfig(t, "Keys:\n  UserID: EQ\n"), idempotencyTable(items))
+
+	s := &idempotency.Settings{TableName: "idempotency"}
+	if err := s.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	guard, replayed, err := idempotency.Begin(ctx, conn, s, "key-1", "hash")
+	if err != nil || guard == nil || replayed != nil {
+		t.Fatalf("Begin() = %v, %v, %v, want the guard", guard, replayed, err)
+	}
+
+	if _, _, err := idempotency.Begin(ctx, conn, s, "key-1", "hash"); !errors.Is(err, idempotency.ErrInProgress) {
+		t.Errorf("Begin() error = %v, want ErrInProgress", err)
+	}
+	if _, _, err := idempotency.Begin(ctx, conn, s, "key-1", "other"); !errors.Is(err, idempotency.ErrPayloadMismatch) {
+		t.Errorf("Begin() error = %v, want ErrPayloadMismatch", err)
+	}
+
+	response := &lowcodeattribute.ExecutionResponse{StatusCode: http.StatusCreated, Message: map[string]interface{}{"UserID": "42"}}
+	if err := guard.Finish(ctx, response); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	_, replayed, err = idempotency.Begin(ctx, conn, s, "key-1", "hash")
+	if err != nil || replayed == nil || replayed.StatusCode != http.StatusCreated || replayed.Headers[idempotency.ReplayedHeader] != "true" {
+		t.Errorf("Begin() = %+v, %v, want the response replayed", replayed, err)
+	}
+}
+
+func TestIdempotencyKeepsTheRecordOfAnotherRequest(t *testing.T) {
+	ctx := context.Background()
+	items := map[string]map[string]*dynamodb.AttributeValue{}
+	conn := NewDynamoDB(loadConfig(t, "Keys:\n  UserID: EQ\n"), idempotencyTable(items))
+
+	s := &idempotency.Settings{TableName: "idempotency"}
+	if err := s.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	first, _, err := idempotency.Begin(ctx, conn, s, "key-1", "hash")
+	if err != nil || first == nil {
+		t.Fatalf("Begin() = %v, %v, want the guard", first, err)
+	}
+
+	// the lock of the first request expires and the retry acquires the key
+	expired := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	items["key-1"]["LockExpiresAt"] = &dynamodb.AttributeValue{N: aws.String(expired)}
+
+	second, _, err := idempotency.Begin(ctx, conn, s, "key-1", "hash")
+	if err != nil || second == nil {
+		t.Fatalf("Begin() = %v, %v, want the key acquired again", second, err)
+	}
+	token := aws.StringValue(items["key-1"]["LockToken"].S)
+
+	if err := first.Finish(ctx, &lowcodeattribute.ExecutionResponse{StatusCode: http.StatusCreated}); !errors.Is(err, idempotency.ErrLockLost) {
+		t.Errorf("Finish() error = %v, want ErrLockLost", err)
+	}
+	throttled := lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindThrottled, "slow down"))
+	if err := first.Finish(ctx, throttled); err != nil {
+		t.Errorf("Finish() error = %v, want the record of the retry kept", err)
+	}
+	if item := items["key-1"]; item == nil || aws.StringValue(item["LockToken"].S) != token ||
+		aws.StringValue(item["Status"].S) != idempotency.StatusInProgress {
+		t.Fatalf("the table holds %v, want the record of the retry", item)
+	}
+
+	if err := second.Finish(ctx, throttled); err != nil || items["key-1"] != nil {
+		t.Errorf("Finish() error = %v, table holds %v, want the record released", err, items["key-1"])
+	}
+}
@@ -0,0 +1,380 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/patch"
+)
+
+// Patcher is implemented by the connectors able to apply partial updates to an item.
+type Patcher interface {
+	Patch(ctx context.Context, data interface{}, p *patch.Patch) *lowcodeattribute.ExecutionResponse
+}
+
+// updateBuilder builds the update and condition expressions of a patch
+type updateBuilder struct {
+	names        map[string]*string
+	placeholders map[string]string
+	values       map[string]*dynamodb.AttributeValue
+	set          []string
+	remove       []string
+	add          []string
+	delete       []string
+	conditions   []string
+	parents      map[string]bool
+	// marshal converts the value of the attribute found on the path into the type of the schema
+	marshal func(path []string, value interface{}) (*dynamodb.AttributeValue, error)
+}
+
+// Patch applies the operations of the patch to the item identified by the key received, answering
+// 200 (OK) with the updated item.
+func (c *DynamoDB) Patch(ctx context.Context, data interface{}, p *patch.Patch) *lowcodeattribute.ExecutionResponse {
+	data, err := c.Config.Resources.Connector.ComposeKeys(data)
+	if err != nil {
+		return lowcodeattribute.NewBadRequestResponse(err)
+	}
+
+	return c.patchOnDynamoDB(ctx, data, p)
+}
+
+// patchOnDynamoDB é uma função interna responsável por aplicar uma atualização parcial a um ítem de uma
+// tabela do DynamoDB, identificado pelos atributos da chave primária recebidos.
+//
+// Cada operação do patch é convertida em uma ação da expressão de atualização: atribuições (SET),
+// remoções (REMOVE), incrementos de números e adições a conjuntos (ADD), remoções de conjuntos
+// (DELETE), concatenação de listas (list_append) e atribuições condicionais (if_not_exists). As
+// operações 'test' se tornam condições da atualização.
+//
+// Os atributos aninhados exigem que o objeto que os contém exista. Quando ele não existir, um JSON
+// Merge Patch é repetido criando o objeto com os atributos atribuídos a ele, enquanto um JSON Patch
+// é recusado com o código 409. A inserção de valores no meio de listas não é suportada.
+//
+// Se o ítem não existir, a função retornará um código 404; se uma das condições do patch não for
+// atendida, um código 409. Quando o ítem for atualizado, ele será retornado com o código 200.
+func (c *DynamoDB) patchOnDynamoDB(ctx context.Context, data interface{}, p *patch.Patch) *lowcodeattribute.ExecutionResponse {
+	res := &c.Config.Resources.Connector
+
+	if !res.HasFullKey(data) {
+		return lowcodeattribute.NewBadRequestResponse(errors.New("the key attributes are required to patch an item"))
+	}
+
+	keys, err := res.GetPrimaryKeyAttributeValue(data)
+	if err != nil {
+		return lowcodeattribute.NewBadRequestResponse(err)
+	}
+
+	keyFields := map[string]bool{}
+	for _, field := range res.KeyFields() {
+		keyFields[field] = true
+	}
+	for _, name := range p.Attributes() {
+		if keyFields[name] {
+			return lowcodeattribute.NewBadRequestResponse(fmt.Errorf("the key attribute %s cannot be patched", name))
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		input, tested, err := c.patchInput(keys, p)
+		if err != nil {
+			return lowcodeattribute.NewBadRequestResponse(err)
+		}
+
+		output, err := c.Client.UpdateItemWithContext(ctx, input)
+		if err == nil {
+			return c.patchedItem(output.Attributes)
+		}
+
+		var failed *dynamodb.ConditionalCheckFailedException
+		if !errors.As(err, &failed) {
+			return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to patch table item: %w", err))
+		}
+
+		// the current item is only returned when it exists
+		if len(failed.Item) == 0 || res.IsDeleted(failed.Item) {
+			return lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindNotFound, "item not found"))
+		}
+
+		created, missing := p.CreateParents(func(path []string) bool {
+			return documentExists(failed.Item, path)
+		})
+		switch {
+		case missing && p.Merge && attempt == 0:
+			p = created
+		case missing:
+			return lowcodeattribute.NewErrorResponse(lowcodeattribute.NewError(lowcodeattribute.KindConflict, "the parent of a nested attribute of the patch doesn't exist", nil))
+		case tested:
+			return lowcodeattribute.NewErrorResponse(lowcodeattribute.NewError(lowcodeattribute.KindConflict, "the item doesn't match the conditions of the patch", nil))
+		default:
+			return lowcodeattribute.NewErrorResponse(lowcodeattribute.NewError(lowcodeattribute.KindConflict, "the item changed during the patch", nil))
+		}
+	}
+}
+
+// patchInput builds the update of the patch, reporting whether it tests the current item
+func (c *DynamoDB) patchInput(keys map[string]*dynamodb.AttributeValue, p *patch.Patch) (*dynamodb.UpdateItemInput, bool, error) {
+	res := &c.Config.Resources.Connector
+
+	b := &updateBuilder{
+		names:        map[string]*string{},
+		placeholders: map[string]string{},
+		values:       map[string]*dynamodb.AttributeValue{},
+		parents:      map[string]bool{},
+		marshal:      res.AttributeValueAt,
+	}
+
+	for key := range keys {
+		b.conditions = append(b.conditions, fmt.Sprintf("attribute_exists(%s)", b.path([]string{key})))
+	}
+	if res.SoftDeletes() {
+		b.conditions = append(b.conditions, fmt.Sprintf("attribute_not_exists(%s)", b.path([]string{res.Properties.SoftDelete.Attribute})))
+	}
+
+	tested := false
+	for _, operation := range p.Operations {
+		if err := b.operation(operation); err != nil {
+			return nil, false, err
+		}
+		tested = tested || operation.Op == patch.OpTest || operation.MustExist
+	}
+
+	// the tests alone don't change the item
+	if b.expression() == "" {
+		return nil, false, errors.New("the patch doesn't change any attribute")
+	}
+
+	if attribute := res.SchemaVersionAttribute(); attribute != "" {
+		b.set = append(b.set, fmt.Sprintf("%s = %s", b.path([]string{attribute}), b.value(&dynamodb.AttributeValue{S: aws.String(res.Properties.SchemaVersion)})))
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:                           aws.String(res.Properties.TableName),
+		Key:                                 keys,
+		UpdateExpression:                    aws.String(b.expression()),
+		ConditionExpression:                 aws.String(strings.Join(b.conditions, " AND ")),
+		ExpressionAttributeNames:            b.names,
+		ReturnValues:                        aws.String(dynamodb.ReturnValueAllNew),
+		ReturnValuesOnConditionCheckFailure: aws.String(dynamodb.ReturnValuesOnConditionCheckFailureAllOld),
+	}
+	if len(b.values) > 0 {
+		input.ExpressionAttributeValues = b.values
+	}
+
+	return input, tested, nil
+}
+
+// patchedItem creates the response of the item updated by a patch
+func (c *DynamoDB) patchedItem(attributes map[string]*dynamodb.AttributeValue) *lowcodeattribute.ExecutionResponse {
+	var item map[string]interface{}
+	if err := dynamodbattribute.UnmarshalMap(attributes, &item); err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to deserialize response: %w", err))
+	}
+
+	resolved, err := c.Config.Resources.Connector.ResolveItem(item)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(lowcodeattribute.NewError(lowcodeattribute.KindInternal, "failed to resolve item schema", err))
+	}
+
+	return &lowcodeattribute.ExecutionResponse{
+		StatusCode: 200,
+		Message:    resolved,
+	}
+}
+
+// documentExists reports whether the item holds the attribute found on the path, which may go
+// through maps and lists
+func documentExists(item map[string]*dynamodb.AttributeValue, path []string) bool {
+	current := &dynamodb.AttributeValue{M: item}
+
+	for _, segment := range path {
+		switch {
+		case current.M != nil:
+			current = current.M[segment]
+		case current.L != nil && patch.IsIndex(segment):
+			index, _ := strconv.Atoi(segment)
+			if index >= len(current.L) {
+				return false
+			}
+			current = current.L[index]
+		default:
+			return false
+		}
+
+		if current == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// operation adds the action, or the condition, of an operation to the expressions
+func (b *updateBuilder) operation(operation patch.Operation) error {
+	path := b.path(operation.Path)
+	topLevel := len(operation.Path) == 1
+
+	if operation.Insert {
+		return errors.New("values can't be inserted into lists, use '-' to append or replace the element")
+	}
+
+	if operation.MustExist {
+		b.conditions = append(b.conditions, fmt.Sprintf("attribute_exists(%s)", path))
+	}
+
+	// the nested attributes can only be changed when the object or list holding them exists
+	if parent := operation.Path[:len(operation.Path)-1]; len(parent) > 0 && !operation.MustExist {
+		if expression := b.path(parent); !b.parents[expression] {
+			b.parents[expression] = true
+			b.conditions = append(b.conditions, fmt.Sprintf("attribute_exists(%s)", expression))
+		}
+	}
+
+	switch operation.Op {
+	case patch.OpRemove:
+		b.remove = append(b.remove, path)
+		return nil
+	case patch.OpAddToSet, patch.OpDeleteFromSet:
+		if !topLevel {
+			return fmt.Errorf("the %s operation only applies to top-level attributes", operation.Op)
+		}
+
+		set, err := setAttribute(operation.Value)
+		if err != nil {
+			return err
+		}
+
+		clause := fmt.Sprintf("%s %s", path, b.value(set))
+		if operation.Op == patch.OpAddToSet {
+			b.add = append(b.add, clause)
+		} else {
+			b.delete = append(b.delete, clause)
+		}
+		return nil
+	}
+
+	value, err := b.marshal(operation.Path, operation.Value)
+	if err != nil {
+		return err
+	}
+
+	switch operation.Op {
+	case patch.OpSet:
+		b.set = append(b.set, fmt.Sprintf("%s = %s", path, b.value(value)))
+	case patch.OpSetIfMissing:
+		b.set = append(b.set, fmt.Sprintf("%s = if_not_exists(%s, %s)", path, path, b.value(value)))
+	case patch.OpTest:
+		b.conditions = append(b.conditions, fmt.Sprintf("%s = %s", path, b.value(value)))
+	case patch.OpIncrement:
+		if value.N == nil {
+			return fmt.Errorf("the %s operation requires a number", operation.Op)
+		}
+		if topLevel {
+			b.add = append(b.add, fmt.Sprintf("%s %s", path, b.value(value)))
+		} else {
+			// ADD only changes top-level attributes, so the nested numbers are summed
+			zero := b.value(&dynamodb.AttributeValue{N: aws.String("0")})
+			b.set = append(b.set, fmt.Sprintf("%s = if_not_exists(%s, %s) + %s", path, path, zero, b.value(value)))
+		}
+	case patch.OpAppend:
+		if value.L == nil {
+			return fmt.Errorf("the %s operation requires a list", operation.Op)
+		}
+		empty := b.value(&dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{}})
+		b.set = append(b.set, fmt.Sprintf("%s = list_append(if_not_exists(%s, %s), %s)", path, path, empty, b.value(value)))
+	default:
+		return fmt.Errorf("unsupported patch operation %s", operation.Op)
+	}
+
+	return nil
+}
+
+// path returns the document path of the segments, with a placeholder for each attribute name
+func (b *updateBuilder) path(segments []string) string {
+	var builder strings.Builder
+
+	for i, segment := range segments {
+		if i > 0 && patch.IsIndex(segment) {
+			fmt.Fprintf(&builder, "[%s]", segment)
+			continue
+		}
+
+		placeholder, ok := b.placeholders[segment]
+		if !ok {
+			placeholder = fmt.Sprintf("#p%d", len(b.placeholders))
+			b.placeholders[segment] = placeholder
+			b.names[placeholder] = aws.String(segment)
+		}
+
+		if i > 0 {
+			builder.WriteString(".")
+		}
+		builder.WriteString(placeholder)
+	}
+
+	return builder.String()
+}
+
+// value returns the placeholder of a new value of the expressions
+func (b *updateBuilder) value(value *dynamodb.AttributeValue) string {
+	placeholder := fmt.Sprintf(":p%d", len(b.values))
+	b.values[placeholder] = value
+
+	return placeholder
+}
+
+// expression returns the update expression with the clauses of every action
+func (b *updateBuilder) expression() string {
+	var clauses []string
+	for _, clause := range []struct {
+		action  string
+		actions []string
+	}{
+		{"SET", b.set},
+		{"REMOVE", b.remove},
+		{"ADD", b.add},
+		{"DELETE", b.delete},
+	} {
+		if len(clause.actions) > 0 {
+			clauses = append(clauses, clause.action+" "+strings.Join(clause.actions, ", "))
+		}
+	}
+
+	return strings.Join(clauses, " ")
+}
+
+// setAttribute converts a list of strings or numbers into a string or number set
+func setAttribute(value interface{}) (*dynamodb.AttributeValue, error) {
+	list, ok := value.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, errors.New("sets require a non-empty list of strings or numbers")
+	}
+
+	attribute := &dynamodb.AttributeValue{}
+	for _, item := range list {
+		switch v := item.(type) {
+		case string:
+			attribute.SS = append(attribute.SS, aws.String(v))
+		case float64, float32, int, int32, int64:
+			number, err := dynamodbattribute.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			attribute.NS = append(attribute.NS, number.N)
+		default:
+			return nil, fmt.Errorf("sets only hold strings or numbers, received %T", item)
+		}
+	}
+
+	if attribute.SS != nil && attribute.NS != nil {
+		return nil, errors.New("sets cannot mix strings and numbers")
+	}
+
+	return attribute, nil
+}
@@ -0,0 +1,51 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/raywall/aws-lowcode-lambda-go/patch"
+)
+
+func TestPatchMarshalsTheValuesWithTheSchema(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{name: "json number", value: json.Number("9007199254740993"), want: "9007199254740993"},
+		{name: "string", value: "30", want: "30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var values map[string]*dynamodb.AttributeValue
+
+			client := &stubDynamoDB{}
+			client.updateItem = func(input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+				values = input.ExpressionAttributeValues
+				return &dynamodb.UpdateItemOutput{Attributes: marshalItem(t, map[string]interface{}{"UserID": "1", "Age": 30})}, nil
+			}
+
+			conn := NewDynamoDB(loadConfig(t, "Keys:\n  UserID: EQ\n"), client)
+			p := &patch.Patch{Operations: []patch.Operation{{Op: patch.OpSet, Path: []string{"Age"}, Value: tt.value}}}
+
+			if response := conn.Patch(context.Background(), map[string]interface{}{"UserID": "1"}, p); response.StatusCode != 200 {
+				t.Fatalf("Patch() = %d %v, want 200", response.StatusCode, response.Error)
+			}
+
+			var found bool
+			for _, value := range values {
+				if value.N != nil {
+					found = aws.StringValue(value.N) == tt.want
+				}
+			}
+			if !found {
+				t.Errorf("UpdateItem values = %v, want the number %s", values, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+)
+
+// ParamExpand is the query parameter listing the relations nested in the items read, like
+// '?expand=orders,customer'.
+const ParamExpand = "expand"
+
+// MaxRelationCalls is the number of calls reading the related items executed concurrently by a
+// request.
+const MaxRelationCalls = 10
+
+// expansions returns the relations asked to be expanded by the request carried by the context
+func (c *DynamoDB) expansions(ctx context.Context) ([]string, error) {
+	req, _ := mapping.FromContext(ctx)
+
+	value := req.Query[ParamExpand]
+	if value == "" {
+		return nil, nil
+	}
+
+	return c.Config.Resources.Connector.Expansions(value)
+}
+
+// expandOnDynamoDB é uma função interna responsável por incluir nos ítens de uma leitura os ítens
+// relacionados a eles pelas relações indicadas no parâmetro 'expand' da query string, declaradas
+// em 'Relations'. Cada relação é incluída no ítem com o seu nome, como uma lista de ítens nas
+// relações OneToMany, ou como um objeto (ou null) nas relações OneToOne.
+//
+// As relações OneToMany consultam, com um Query por ítem, os ítens que compartilham a partição
+// composta pelos templates de 'Keys', na tabela ou no índice 'IndexName', até o limite 'Limit'. As
+// relações OneToOne que identificam o ítem relacionado pela sua chave primária são lidas em lotes
+// de BatchGetItem. As chamadas de todas as relações são executadas concorrentemente, até o limite
+// de MaxRelationCalls, e qualquer falha faz a leitura inteira falhar.
+func (c *DynamoDB) expandOnDynamoDB(ctx context.Context, response *lowcodeattribute.ExecutionResponse, names []string) *lowcodeattribute.ExecutionResponse {
+	var items []map[string]interface{}
+	switch message := response.Message.(type) {
+	case map[string]interface{}:
+		items = []map[string]interface{}{message}
+	case []map[string]interface{}:
+		items = message
+	default:
+		return response
+	}
+
+	res := &c.Config.Resources.Connector
+	slots := make(chan struct{}, MaxRelationCalls)
+	related := make([][][]map[string]interface{}, len(names))
+	errs := make([]error, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, rel *config.Relation) {
+			defer wg.Done()
+			related[i], errs[i] = c.relatedItems(ctx, rel, items, slots)
+		}(i, res.Relation(name))
+	}
+	wg.Wait()
+
+	for i, name := range names {
+		if errs[i] != nil {
+			return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to expand relation %s: %w", name, errs[i]))
+		}
+
+		one := res.Relation(name).Type == config.RelationOneToOne
+		for j, item := range items {
+			switch {
+			case !one:
+				item[name] = related[i][j]
+			case len(related[i][j]) > 0:
+				item[name] = related[i][j][0]
+			default:
+				item[name] = nil
+			}
+		}
+	}
+
+	return response
+}
+
+// relatedItems reads the items related to each item by the relation, in the order of the items.
+// The items without related items receive an empty list. The first failure is returned once every
+// call started finished.
+func (c *DynamoDB) relatedItems(ctx context.Context, rel *config.Relation, items []map[string]interface{}, slots chan struct{}) ([][]map[string]interface{}, error) {
+	related := make([][]map[string]interface{}, len(items))
+	errs := make([]error, len(items))
+
+	// the items identifying their related item by its primary key share a batch
+	gets := map[int]map[string]*dynamodb.AttributeValue{}
+
+	var (
+		wg  sync.WaitGroup
+		err error
+	)
+	for i, item := range items {
+		related[i] = []map[string]interface{}{}
+
+		key, ok := rel.Key(item)
+		if !ok {
+			continue
+		}
+
+		if rel.Complete(key) {
+			av, marshalErr := dynamodbattribute.MarshalMap(key)
+			if marshalErr != nil {
+				err = marshalErr
+				break
+			}
+			gets[i] = av
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, key map[string]interface{}) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			related[i], errs[i] = c.queryRelated(ctx, rel, key)
+		}(i, key)
+	}
+
+	if err == nil && len(gets) > 0 {
+		var found map[string]map[string]interface{}
+		if found, err = c.getRelated(ctx, rel, gets, slots); err == nil {
+			for i, key := range gets {
+				if item := found[relationKey(rel, key)]; item != nil {
+					related[i] = append(related[i], item)
+				}
+			}
+		}
+	}
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return related, nil
+}
+
+// queryRelated queries the items matching the key composed by the relation, up to its limit. The
+// prefix of the sort key is matched with begins_with.
+func (c *DynamoDB) queryRelated(ctx context.Context, rel *config.Relation, key map[string]interface{}) ([]map[string]interface{}, error) {
+	res := &c.Config.Resources.Connector
+
+	names := map[string]*string{}
+	values := map[string]*dynamodb.AttributeValue{}
+	conditions := []string{}
+
+	for attribute, value := range key {
+		condition := "#%s = :%s"
+		if prefix, ok := value.(config.KeyPrefix); ok {
+			condition = "begins_with(#%s, :%s)"
+			value = string(prefix)
+		}
+
+		av, err := dynamodbattribute.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		names["#"+attribute] = aws.String(attribute)
+		values[":"+attribute] = av
+		conditions = append(conditions, fmt.Sprintf(condition, attribute, attribute))
+	}
+	sort.Strings(conditions)
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(rel.TableName),
+		KeyConditionExpression: aws.String(strings.Join(conditions, " AND ")),
+	}
+	if rel.IndexName != "" {
+		input.IndexName = aws.String(rel.IndexName)
+	} else {
+		input.ConsistentRead = aws.Bool(res.Properties.ConsistentRead)
+	}
+
+	// the items of the other entities and the deleted items of the table are not related
+	filters := []string{}
+	if rel.Shared() {
+		if condition := res.TombstoneCondition(); condition != "" {
+			attribute := res.Properties.SoftDelete.Attribute
+			names["#"+attribute] = aws.String(attribute)
+			filters = append(filters, condition)
+		}
+		if attribute := res.Properties.EntityAttribute; attribute != "" && rel.Entity != "" {
+			names["#"+attribute] = aws.String(attribute)
+			values[":"+attribute] = &dynamodb.AttributeValue{S: aws.String(rel.Entity)}
+			filters = append(filters, fmt.Sprintf("#%s = :%s", attribute, attribute))
+		}
+	}
+	if len(filters) > 0 {
+		input.FilterExpression = aws.String(strings.Join(filters, " AND "))
+	}
+	input.ExpressionAttributeNames = names
+	input.ExpressionAttributeValues = values
+
+	limit := rel.Limit
+	if rel.Type == config.RelationOneToOne {
+		limit = 1
+	}
+
+	related := []map[string]interface{}{}
+	for {
+		input.Limit = aws.Int64(limit - int64(len(related)))
+
+		output, err := c.Client.QueryWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range output.Items {
+			record, err := c.relatedItem(rel, item)
+			if err != nil {
+				return nil, err
+			}
+			related = append(related, record)
+		}
+
+		if len(output.LastEvaluatedKey) == 0 || int64(len(related)) >= limit {
+			return related, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// getRelated reads the items identified by the primary keys received with BatchGetItem, returning
+// them by their keys. The items missing, deleted or of other entities are not returned.
+func (c *DynamoDB) getRelated(ctx context.Context, rel *config.Relation, keys map[int]map[string]*dynamodb.AttributeValue, slots chan struct{}) (map[string]map[string]interface{}, error) {
+	res := &c.Config.Resources.Connector
+
+	// the items related to the same item are read once
+	unique := []map[string]*dynamodb.AttributeValue{}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if id := relationKey(rel, key); !seen[id] {
+			seen[id] = true
+			unique = append(unique, key)
+		}
+	}
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		found = map[string]map[string]interface{}{}
+		errs  = make([]error, (len(unique)+MaxBatchGetKeys-1)/MaxBatchGetKeys)
+	)
+
+	for start := 0; start < len(unique); start += MaxBatchGetKeys {
+		wg.Add(1)
+		go func(chunk int, request *dynamodb.KeysAndAttributes) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			for attempt := 0; len(request.Keys) > 0; attempt++ {
+				if attempt > 0 && (attempt >= DefaultBatchMaxAttempts || !backoff(ctx, attempt)) {
+					errs[chunk] = lowcodeattribute.Errorf(lowcodeattribute.KindThrottled, "the related items were not read after %d attempts", DefaultBatchMaxAttempts)
+					return
+				}
+
+				output, err := c.Client.BatchGetItemWithContext(ctx, &dynamodb.BatchGetItemInput{
+					RequestItems: map[string]*dynamodb.KeysAndAttributes{rel.TableName: request},
+				})
+				if err != nil {
+					errs[chunk] = err
+					return
+				}
+
+				for _, item := range output.Responses[rel.TableName] {
+					if rel.Shared() && (res.IsDeleted(item) || !isEntity(res, rel, item)) {
+						continue
+					}
+
+					record, err := c.relatedItem(rel, item)
+					if err != nil {
+						errs[chunk] = err
+						return
+					}
+
+					mu.Lock()
+					found[relationKey(rel, item)] = record
+					mu.Unlock()
+				}
+
+				request = output.UnprocessedKeys[rel.TableName]
+				if request == nil {
+					return
+				}
+			}
+		}(start/MaxBatchGetKeys, &dynamodb.KeysAndAttributes{
+			Keys:           unique[start:min(start+MaxBatchGetKeys, len(unique))],
+			ConsistentRead: aws.Bool(res.Properties.ConsistentRead),
+		})
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return found, nil
+}
+
+// relatedItem converts a related item read from the table. The items of the table of the connector
+// have the fields composing their keys parsed.
+func (c *DynamoDB) relatedItem(rel *config.Relation, item map[string]*dynamodb.AttributeValue) (map[string]interface{}, error) {
+	var record map[string]interface{}
+	if err := dynamodbattribute.UnmarshalMap(item, &record); err != nil {
+		return nil, fmt.Errorf("failed to deserialize related item: %w", err)
+	}
+
+	if rel.Shared() {
+		record = c.Config.Resources.Connector.DecomposeItem(record)
+	}
+
+	return record, nil
+}
+
+// isEntity reports whether the item read from the table is of the entity of the relation
+func isEntity(res *config.ResourceItem, rel *config.Relation, item map[string]*dynamodb.AttributeValue) bool {
+	attribute := res.Properties.EntityAttribute
+	if attribute == "" || rel.Entity == "" {
+		return true
+	}
+
+	return item[attribute] != nil && aws.StringValue(item[attribute].S) == rel.Entity
+}
+
+// relationKey identifies a related item by the values of the key attributes of the relation, which
+// are the primary key of the items read by their keys
+func relationKey(rel *config.Relation, item map[string]*dynamodb.AttributeValue) string {
+	attributes := rel.KeyAttributes()
+
+	parts := make([]string, len(attributes))
+	for i, name := range attributes {
+		if value := item[name]; value != nil {
+			parts[i] = aws.StringValue(value.S) + aws.StringValue(value.N) + string(value.B)
+		}
+	}
+
+	return strings.Join(parts, "\x00")
+}
@@ -0,0 +1,232 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+)
+
+const userRelations = `
+HashKey: PK
+EntityAttribute: Type
+Entities:
+  User:
+    Keys:
+      PK: USER#{UserID}
+      SK: PROFILE
+  Order:
+    Keys:
+      PK: USER#{UserID}
+      SK: ORDER#{OrderID}
+SoftDelete: {}
+Relations:
+  orders:
+    Entity: Order
+    Limit: 3
+  profile:
+    Type: OneToOne
+    Entity: User
+  customer:
+    Type: OneToOne
+    TableName: customers
+    Keys:
+      CustomerID: '{UserID}'
+`
+
+// expand includes the relations on the users received, failing the test when the expansion fails
+func expand(t *testing.T, ctx context.Context, conn *DynamoDB, users []map[string]interface{}, names ...string) {
+	t.Helper()
+
+	response := conn.expandOnDynamoDB(ctx, &lowcodeattribute.ExecutionResponse{StatusCode: http.StatusOK, Message: users}, names)
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("expandOnDynamoDB() = %d %v, want 200", response.StatusCode, response.Message)
+	}
+}
+
+// users returns the records of the users 1 to n
+func users(n int) []map[string]interface{} {
+	records := make([]map[string]interface{}, n)
+	for i := range records {
+		records[i] = map[string]interface{}{"UserID": fmt.Sprint(i + 1)}
+	}
+
+	return records
+}
+
+func TestExpandQueriesUpToTheLimit(t *testing.T) {
+	limits := []int64{}
+
+	client := &stubDynamoDB{}
+	client.query = func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+		limits = append(limits, aws.Int64Value(input.Limit))
+		if got := aws.StringValue(input.KeyConditionExpression); got != "#PK = :PK AND begins_with(#SK, :SK)" {
+			t.Errorf("KeyConditionExpression = %s, want the partition and the prefix of the orders", got)
+		}
+		if got := aws.StringValue(input.ExpressionAttributeValues[":SK"].S); got != "ORDER#" {
+			t.Errorf(":SK = %s, want ORDER#", got)
+		}
+
+		// the deleted items and the other entities of the table are filtered out
+		if got := aws.StringValue(input.FilterExpression); got != "attribute_not_exists(#DeletedAt) AND #Type = :Type" {
+			t.Errorf("FilterExpression = %s, want the tombstone and the entity", got)
+		}
+		if got := aws.StringValue(input.ExpressionAttributeValues[":Type"].S); got != "Order" {
+			t.Errorf(":Type = %s, want Order", got)
+		}
+
+		// every page has up to two orders and more to read
+		output := &dynamodb.QueryOutput{LastEvaluatedKey: map[string]*dynamodb.AttributeValue{"PK": {S: aws.String("next")}}}
+		for i := int64(0); i < 2 && i < aws.Int64Value(input.Limit); i++ {
+			output.Items = append(output.Items, marshalItem(t, map[string]interface{}{"PK": "USER#1", "SK": fmt.Sprintf("ORDER#%d", i), "Type": "Order"}))
+		}
+		return output, nil
+	}
+
+	conn := NewDynamoDB(loadConfig(t, userRelations), client)
+	records := users(1)
+	expand(t, context.Background(), conn, records, "orders")
+
+	orders, _ := records[0]["orders"].([]map[string]interface{})
+	if len(orders) != 3 || !reflect.DeepEqual(limits, []int64{3, 1}) {
+		t.Fatalf("orders = %v after the queries limited to %v, want 3 orders after [3 1]", orders, limits)
+	}
+	if orders[0]["UserID"] != "1" || orders[0]["OrderID"] != "0" {
+		t.Errorf("orders[0] = %v, want the fields parsed from its keys", orders[0])
+	}
+}
+
+func TestExpandLimitsTheConcurrentCalls(t *testing.T) {
+	var (
+		mu              sync.Mutex
+		running, peak   int
+		client          = &stubDynamoDB{}
+		enter, leave    = make(chan struct{}), make(chan struct{})
+		released        sync.Once
+		releaseTheCalls = func() { released.Do(func() { close(leave) }) }
+	)
+	defer releaseTheCalls()
+
+	client.query = func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+
+		select {
+		case enter <- struct{}{}:
+		default:
+		}
+		<-leave
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return &dynamodb.QueryOutput{}, nil
+	}
+
+	conn := NewDynamoDB(loadConfig(t, userRelations), client)
+	records := users(3 * MaxRelationCalls)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		expand(t, context.Background(), conn, records, "orders")
+	}()
+
+	// the calls blocked don't let any other call start beyond the limit
+	<-enter
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	blocked := running
+	mu.Unlock()
+	releaseTheCalls()
+	<-done
+
+	if blocked != MaxRelationCalls || peak != MaxRelationCalls {
+		t.Errorf("calls running = %d, peak = %d, want %d", blocked, peak, MaxRelationCalls)
+	}
+	if got := client.count("Query"); got != len(records) {
+		t.Errorf("Query calls = %d, want %d", got, len(records))
+	}
+}
+
+func TestExpandSkipsTheDeletedItemsAndOtherEntities(t *testing.T) {
+	client := &stubDynamoDB{}
+	client.batchGetItem = func(input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+		items := []map[string]*dynamodb.AttributeValue{}
+		for _, key := range input.RequestItems["users"].Keys {
+			item := map[string]interface{}{"PK": aws.StringValue(key["PK"].S), "SK": "PROFILE", "Type": "User"}
+			switch aws.StringValue(key["PK"].S) {
+			case "USER#2":
+				item["DeletedAt"] = "2024-01-02T03:04:05Z"
+			case "USER#3":
+				item["Type"] = "Order"
+			}
+			items = append(items, marshalItem(t, item))
+		}
+
+		return &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]*dynamodb.AttributeValue{"users": items}}, nil
+	}
+
+	conn := NewDynamoDB(loadConfig(t, userRelations), client)
+	records := users(3)
+	expand(t, context.Background(), conn, records, "profile")
+
+	if profile, _ := records[0]["profile"].(map[string]interface{}); profile["UserID"] != "1" {
+		t.Errorf("profile of the user 1 = %v, want the profile read", records[0]["profile"])
+	}
+	for _, record := range records[1:] {
+		if profile, ok := record["profile"]; !ok || profile != nil {
+			t.Errorf("profile of the user %s = %v, want null", record["UserID"], profile)
+		}
+	}
+}
+
+func TestExpandRetriesTheUnprocessedKeys(t *testing.T) {
+	// the first key of each call is read and the others are left unprocessed
+	client := &stubDynamoDB{}
+	client.batchGetItem = func(input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+		request := input.RequestItems["customers"]
+		output := &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]*dynamodb.AttributeValue{
+			"customers": {{"CustomerID": request.Keys[0]["CustomerID"], "Name": {S: aws.String("Ana")}}},
+		}}
+		if len(request.Keys) > 1 {
+			output.UnprocessedKeys = map[string]*dynamodb.KeysAndAttributes{
+				"customers": {Keys: request.Keys[1:], ConsistentRead: request.ConsistentRead},
+			}
+		}
+		return output, nil
+	}
+
+	conn := NewDynamoDB(loadConfig(t, userRelations), client)
+	records := users(3)
+	expand(t, context.Background(), conn, records, "customer")
+
+	if got := client.count("BatchGetItem"); got != 3 {
+		t.Errorf("BatchGetItem calls = %d, want 3", got)
+	}
+	for _, record := range records {
+		if customer, _ := record["customer"].(map[string]interface{}); customer["CustomerID"] != record["UserID"] {
+			t.Errorf("customer of the user %s = %v, want the customer read", record["UserID"], record["customer"])
+		}
+	}
+
+	// the keys still unprocessed when the retries can't wait fail the expansion
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	response := conn.expandOnDynamoDB(ctx, &lowcodeattribute.ExecutionResponse{StatusCode: http.StatusOK, Message: users(3)}, []string{"customer"})
+	if response.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expandOnDynamoDB() = %d %v, want 429", response.StatusCode, response.Message)
+	}
+}
@@ -1,9 +1,178 @@
 package connector
 
-import "github.com/raywall/aws-lowcode-lambda-go/config"
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
 
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+// RESTfulApi is the connector that forwards the records to the 'Endpoint' of a downstream RESTful
+// api, encoded according to the 'ContentType' indicated in the 'Connector' resource: JSON (default),
+// Avro binary (avro/binary), Avro single-object (application/vnd.apache.avro+single) or an Avro
+// Object Container File (application/vnd.apache.avro+ocf). The Avro encodings use the connector schema.
 type RESTfulApi struct {
-	Config config.Config
+	Config *config.Config
+	Client *http.Client
+}
+
+// NewRESTfulApi creates a RESTfulApi connector using the configuration and client received.
+func NewRESTfulApi(conf *config.Config, client *http.Client) *RESTfulApi {
+	return &RESTfulApi{
+		Config: conf,
+		Client: client,
+	}
+}
+
+// RESTfulApiFactory returns the factory used by the function to create RESTfulApi connectors sharing
+// the client received.
+func RESTfulApiFactory(client *http.Client) Factory {
+	return func(conf *config.Config) (Connector, error) {
+		if conf.Resources.Connector.Properties.Endpoint == "" {
+			return nil, fmt.Errorf("RESTfulApi connector requires an Endpoint")
+		}
+
+		return NewRESTfulApi(conf, client), nil
+	}
+}
+
+// Create sends the record to the api using the POST method
+func (c *RESTfulApi) Create(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	return c.send(ctx, http.MethodPost, data, true)
+}
+
+// Read queries the api using the GET method, sending the data as query parameters
+func (c *RESTfulApi) Read(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	return c.send(ctx, http.MethodGet, data, false)
+}
+
+// Update sends the record to the api using the PUT method
+func (c *RESTfulApi) Update(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	return c.send(ctx, http.MethodPut, data, true)
+}
+
+// Delete removes the record from the api using the DELETE method, sending the data as query parameters
+func (c *RESTfulApi) Delete(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	return c.send(ctx, http.MethodDelete, data, false)
+}
+
+func (c *RESTfulApi) send(ctx context.Context, method string, data interface{}, withBody bool) *lowcodeattribute.ExecutionResponse {
+	props := c.Config.Resources.Connector.Properties
+
+	endpoint, err := url.Parse(props.Endpoint)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("invalid endpoint: %v", err))
+	}
+
+	var body io.Reader
+	contentType := props.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	if withBody {
+		payload, err := c.encode(contentType, data)
+		if lowcodeattribute.IsValidationError(err) {
+			return lowcodeattribute.NewBadRequestResponse(err)
+		}
+		if err != nil {
+			return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed encoding record: %v", err))
+		}
+		body = bytes.NewReader(payload)
+	} else if values, ok := data.(map[string]interface{}); ok {
+		query := endpoint.Query()
+		for key, value := range values {
+			query.Set(key, fmt.Sprint(value))
+		}
+		endpoint.RawQuery = query.Encode()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, endpoint.String(), body)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed creating request: %v", err))
+	}
+
+	for name, value := range props.Headers {
+		request.Header.Set(name, value)
+	}
+	if withBody {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	response, err := c.Client.Do(request)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindUpstream, "failed calling %s: %v", props.Endpoint, err))
+	}
+	defer response.Body.Close()
+
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindUpstream, "failed reading response: %v", err))
+	}
+
+	message := decodeMessage(content, response.Header.Get("Content-Type"))
+
+	// the failures of the api are failures of an upstream service for the clients of the function
+	if response.StatusCode >= 500 {
+		return &lowcodeattribute.ExecutionResponse{
+			StatusCode: 502,
+			Message:    message,
+			Error:      lowcodeattribute.Errorf(lowcodeattribute.KindUpstream, "%s answered %d", props.Endpoint, response.StatusCode),
+		}
+	}
+
+	return &lowcodeattribute.ExecutionResponse{
+		StatusCode: response.StatusCode,
+		Message:    message,
+	}
 }
 
-// logica cliente restful api aqui
+// encode serializes the record using the content type received
+func (c *RESTfulApi) encode(contentType string, data interface{}) ([]byte, error) {
+	media, _, _ := mime.ParseMediaType(contentType)
+
+	switch media {
+	case schema.ContentTypeAvroBinary, schema.ContentTypeAvro, schema.ContentTypeAvroSingleObject, schema.ContentTypeAvroOCF:
+	default:
+		return json.Marshal(data)
+	}
+
+	s, err := c.Config.Resources.Connector.Schema()
+	if err != nil {
+		return nil, err
+	}
+	s = s.Element()
+
+	switch media {
+	case schema.ContentTypeAvroSingleObject:
+		return s.EncodeSingle(data)
+	case schema.ContentTypeAvroOCF:
+		buffer := &bytes.Buffer{}
+		err := s.EncodeOCF(buffer, []interface{}{data})
+		return buffer.Bytes(), err
+	default:
+		return s.EncodeBinary(data)
+	}
+}
+
+// decodeMessage returns the json responses decoded, and the other ones as text
+func decodeMessage(content []byte, contentType string) interface{} {
+	if len(content) == 0 {
+		return nil
+	}
+
+	var message interface{}
+	if media, _, _ := mime.ParseMediaType(contentType); media == "application/json" && json.Unmarshal(content, &message) == nil {
+		return message
+	}
+
+	return string(content)
+}
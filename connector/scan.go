@@ -0,0 +1,358 @@
+package connector
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+)
+
+// Defaults of the listing of the tables.
+const (
+	DefaultScanPageSize = 100
+	DefaultScanMaxItems = 1000
+)
+
+// Query parameters of the listing of the tables.
+const (
+	ParamLimit  = "limit"
+	ParamCursor = "cursor"
+)
+
+// HeaderNextCursor carries the cursor of the next page of a listing.
+const HeaderNextCursor = "X-Next-Cursor"
+
+// scanDeadlineMargin is the time kept to answer before the deadline of the invocation
+const scanDeadlineMargin = time.Second
+
+type (
+	// scanCursor is the position of each segment of a listing, returned to the client as an
+	// opaque cursor
+	scanCursor struct {
+		Segments []scanPosition `json:"segments"`
+	}
+
+	scanPosition struct {
+		Key  map[string]*dynamodb.AttributeValue `json:"key,omitempty"`
+		Done bool                                `json:"done,omitempty"`
+	}
+
+	// scanSegment is the result of the scan of a segment
+	scanSegment struct {
+		items    []map[string]*dynamodb.AttributeValue
+		position scanPosition
+		scanned  int64
+		err      error
+	}
+
+	// scanBudget is the read capacity shared by the segments of a listing
+	scanBudget struct {
+		mu       sync.Mutex
+		max      float64
+		consumed float64
+	}
+)
+
+// add accounts the capacity consumed by a scan
+func (b *scanBudget) add(capacity *dynamodb.ConsumedCapacity) {
+	if capacity == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.consumed += aws.Float64Value(capacity.CapacityUnits)
+}
+
+// exhausted reports whether the segments must stop scanning
+func (b *scanBudget) exhausted() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.max > 0 && b.consumed >= b.max
+}
+
+// scanFromDynamoDB lists the items of the table, applying the filters and the projection of the
+// configuration. The segments are scanned in parallel until the page is filled, the capacity budget
+// is consumed or the deadline of the invocation approaches; the cursor of the next page is
+// returned in 'Meta' and in the X-Next-Cursor header.
+func (c *DynamoDB) scanFromDynamoDB(ctx context.Context) *lowcodeattribute.ExecutionResponse {
+	res := &c.Config.Resources.Connector
+	settings := res.Properties.Scan
+	req, _ := mapping.FromContext(ctx)
+
+	segments := settings.TotalSegments
+	if segments < 1 {
+		segments = 1
+	}
+
+	limit, err := scanLimit(settings, req.Query[ParamLimit])
+	if err != nil {
+		return lowcodeattribute.NewBadRequestResponse(err)
+	}
+
+	cursor, err := decodeCursor(req.Query[ParamCursor], segments, res.Properties.Keys)
+	if err != nil {
+		return lowcodeattribute.NewBadRequestResponse(err)
+	}
+
+	input, err := c.scanInput(req)
+	if err != nil {
+		return lowcodeattribute.NewBadRequestResponse(err)
+	}
+
+	// each segment fills its share of the page
+	share := (limit + segments - 1) / segments
+	budget := &scanBudget{max: settings.MaxCapacity}
+	results := make([]scanSegment, segments)
+
+	var wg sync.WaitGroup
+	for i := range results {
+		if cursor.Segments[i].Done {
+			results[i].position = cursor.Segments[i]
+			continue
+		}
+
+		segmentInput := *input
+		if segments > 1 {
+			segmentInput.Segment = aws.Int64(int64(i))
+			segmentInput.TotalSegments = aws.Int64(segments)
+		}
+
+		wg.Add(1)
+		go func(i int, input *dynamodb.ScanInput) {
+			defer wg.Done()
+			results[i] = c.scanSegment(ctx, input, cursor.Segments[i].Key, share, budget)
+		}(i, &segmentInput)
+	}
+	wg.Wait()
+
+	items := []map[string]interface{}{}
+	next := scanCursor{Segments: make([]scanPosition, segments)}
+	scanned, done := int64(0), true
+
+	for i, result := range results {
+		if result.err != nil {
+			return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to scan the table: %w", result.err))
+		}
+
+		var segmentItems []map[string]interface{}
+		if err := dynamodbattribute.UnmarshalListOfMaps(result.items, &segmentItems); err != nil {
+			return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to deserialize response: %w", err))
+		}
+
+		for _, item := range segmentItems {
+			resolved, err := res.ResolveItem(item)
+			if err != nil {
+				return lowcodeattribute.NewErrorResponse(lowcodeattribute.NewError(lowcodeattribute.KindInternal, "failed to resolve item schema", err))
+			}
+			items = append(items, resolved)
+		}
+
+		next.Segments[i] = result.position
+		scanned += result.scanned
+		done = done && result.position.Done
+	}
+
+	response := &lowcodeattribute.ExecutionResponse{
+		StatusCode: 200,
+		Message:    items,
+		Meta: map[string]interface{}{
+			"scannedCount":     scanned,
+			"consumedCapacity": budget.consumed,
+		},
+	}
+
+	if !done {
+		encoded, err := encodeCursor(next)
+		if err != nil {
+			return lowcodeattribute.NewErrorResponse(err)
+		}
+
+		response.Meta["nextCursor"] = encoded
+		response.SetHeader(HeaderNextCursor, encoded)
+	}
+
+	return response
+}
+
+// scanInput creates the input shared by the segments, with the filters and the projection
+func (c *DynamoDB) scanInput(req mapping.Request) (*dynamodb.ScanInput, error) {
+	res := &c.Config.Resources.Connector
+
+	input := &dynamodb.ScanInput{
+		TableName:              aws.String(res.Properties.TableName),
+		ConsistentRead:         aws.Bool(res.Properties.ConsistentRead),
+		ReturnConsumedCapacity: aws.String(dynamodb.ReturnConsumedCapacityTotal),
+	}
+
+	names := map[string]*string{}
+
+	if projection, projectionNames := res.GetProjectionExpression(); projection != "" {
+		input.ProjectionExpression = aws.String(projection)
+		for name, value := range projectionNames {
+			names[name] = value
+		}
+	}
+
+	if filter := res.GetFilterExpression(); filter != "" {
+		// the filter values are derived from the request, so they fail on requests missing them
+		values, err := res.GetFilterAttributeValues(req)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, value := range res.GetFilterAttributeNames() {
+			names[name] = value
+		}
+		input.FilterExpression = aws.String(filter)
+		if len(values) > 0 {
+			input.ExpressionAttributeValues = values
+		}
+	}
+
+	if len(names) > 0 {
+		input.ExpressionAttributeNames = names
+	}
+
+	return input, nil
+}
+
+// scanSegment scans a segment from the key received until it returns the number of items
+// requested, the segment ends, the budget is consumed or the deadline approaches
+func (c *DynamoDB) scanSegment(ctx context.Context, input *dynamodb.ScanInput, key map[string]*dynamodb.AttributeValue, limit int64, budget *scanBudget) scanSegment {
+	result := scanSegment{position: scanPosition{Key: key}}
+
+	for int64(len(result.items)) < limit && !budget.exhausted() && !nearDeadline(ctx) {
+		input.Limit = aws.Int64(limit - int64(len(result.items)))
+		input.ExclusiveStartKey = result.position.Key
+
+		output, err := c.Client.ScanWithContext(ctx, input)
+		if err != nil {
+			result.err = err
+			return result
+		}
+
+		budget.add(output.ConsumedCapacity)
+		result.items = append(result.items, output.Items...)
+		result.scanned += aws.Int64Value(output.ScannedCount)
+		result.position.Key = output.LastEvaluatedKey
+
+		if len(output.LastEvaluatedKey) == 0 {
+			result.position.Done = true
+			break
+		}
+	}
+
+	return result
+}
+
+// nearDeadline reports whether the invocation must answer instead of scanning further
+func nearDeadline(ctx context.Context) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && time.Until(deadline) < scanDeadlineMargin
+}
+
+// scanLimit returns the number of items of the page, requested by the 'limit' parameter or
+// 'PageSize', and never above 'MaxItems'
+func scanLimit(settings *config.ScanSettings, requested string) (int64, error) {
+	max := settings.MaxItems
+	if max <= 0 {
+		max = DefaultScanMaxItems
+	}
+
+	limit := settings.PageSize
+	if limit <= 0 {
+		limit = DefaultScanPageSize
+	}
+
+	if requested != "" {
+		value, err := strconv.ParseInt(requested, 10, 64)
+		if err != nil || value <= 0 {
+			return 0, fmt.Errorf("invalid %s %q: must be a positive number", ParamLimit, requested)
+		}
+		limit = value
+	}
+
+	if limit > max {
+		limit = max
+	}
+
+	return limit, nil
+}
+
+// decodeCursor decodes the cursor received, which must have a position for each segment. An empty
+// cursor starts the listing. The positions are the keys where the segments stopped, so they can
+// only hold the key attributes of the table, each one a string, number or binary.
+func decodeCursor(value string, segments int64, keys map[string]string) (scanCursor, error) {
+	if value == "" {
+		return scanCursor{Segments: make([]scanPosition, segments)}, nil
+	}
+
+	var cursor scanCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(data, &cursor)
+	}
+	if err != nil || int64(len(cursor.Segments)) != segments {
+		return scanCursor{}, fmt.Errorf("invalid %s", ParamCursor)
+	}
+
+	for _, position := range cursor.Segments {
+		if !validPosition(position, keys) {
+			return scanCursor{}, fmt.Errorf("invalid %s", ParamCursor)
+		}
+	}
+
+	return cursor, nil
+}
+
+// validPosition reports whether the position of a segment holds only the key attributes of the
+// table, or no key when the segment ended
+func validPosition(position scanPosition, keys map[string]string) bool {
+	if position.Done || len(position.Key) == 0 {
+		return len(position.Key) == 0
+	}
+	if len(position.Key) != len(keys) {
+		return false
+	}
+
+	for name, value := range position.Key {
+		if _, ok := keys[name]; !ok || value == nil {
+			return false
+		}
+
+		scalars := 0
+		for _, set := range []bool{value.S != nil, value.N != nil, value.B != nil} {
+			if set {
+				scalars++
+			}
+		}
+		if scalars != 1 || value.BOOL != nil || value.NULL != nil || value.M != nil || value.L != nil ||
+			value.SS != nil || value.NS != nil || value.BS != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// encodeCursor encodes the cursor as an opaque text
+func encodeCursor(cursor scanCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,211 @@
+package connector
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+)
+
+// segmentedTable answers the scans with the users of each segment, up to perCall items per call,
+// consuming one capacity unit per call
+func segmentedTable(t *testing.T, segments, size, perCall int) *stubDynamoDB {
+	client := &stubDynamoDB{}
+	client.scan = func(input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+		segment := int(aws.Int64Value(input.Segment))
+		if segments > 1 && aws.Int64Value(input.TotalSegments) != int64(segments) {
+			return nil, fmt.Errorf("scan of %d segments, want %d", aws.Int64Value(input.TotalSegments), segments)
+		}
+
+		start := 0
+		if key := input.ExclusiveStartKey; key != nil {
+			fmt.Sscanf(aws.StringValue(key["UserID"].S), "%d-%d", &segment, &start)
+			start++
+		}
+
+		limit := int(aws.Int64Value(input.Limit))
+		if perCall > 0 && perCall < limit {
+			limit = perCall
+		}
+
+		output := &dynamodb.ScanOutput{ConsumedCapacity: &dynamodb.ConsumedCapacity{CapacityUnits: aws.Float64(1)}}
+		for i := start; i < size && len(output.Items) < limit; i++ {
+			output.Items = append(output.Items, marshalItem(t, map[string]interface{}{"UserID": fmt.Sprintf("%d-%d", segment, i)}))
+		}
+		output.ScannedCount = aws.Int64(int64(len(output.Items)))
+		if last := start + len(output.Items) - 1; last < size-1 {
+			output.LastEvaluatedKey = map[string]*dynamodb.AttributeValue{"UserID": {S: aws.String(fmt.Sprintf("%d-%d", segment, last))}}
+		}
+
+		return output, nil
+	}
+
+	return client
+}
+
+// list reads a page of the table with the cursor received
+func list(ctx context.Context, conn *DynamoDB, cursor string) *lowcodeattribute.ExecutionResponse {
+	query := map[string]string{}
+	if cursor != "" {
+		query[ParamCursor] = cursor
+	}
+
+	return conn.Read(mapping.NewContext(ctx, mapping.Request{Query: query}), map[string]interface{}{})
+}
+
+func userIDs(t *testing.T, response *lowcodeattribute.ExecutionResponse) []string {
+	t.Helper()
+
+	if response.StatusCode != 200 {
+		t.Fatalf("Read() = %d %v, want 200", response.StatusCode, response.Error)
+	}
+
+	var ids []string
+	for _, item := range response.Message.([]map[string]interface{}) {
+		ids = append(ids, item["UserID"].(string))
+	}
+
+	return ids
+}
+
+func TestScanFansOutTheSegments(t *testing.T) {
+	client := segmentedTable(t, 2, 5, 0)
+	conn := NewDynamoDB(loadConfig(t, "Keys:\n  UserID: EQ\nScan:\n  Enabled: true\n  TotalSegments: 2\n  PageSize: 4\n"), client)
+
+	response := list(context.Background(), conn, "")
+	first := userIDs(t, response)
+	if want := []string{"0-0", "0-1", "1-0", "1-1"}; fmt.Sprint(first) != fmt.Sprint(want) {
+		t.Errorf("Read() = %v, want each segment filling its share %v", first, want)
+	}
+	if got := client.count("Scan"); got != 2 {
+		t.Errorf("Scan called %d times, want once per segment", got)
+	}
+
+	// the cursors go through the pages until every segment ends
+	seen := map[string]bool{}
+	for _, id := range first {
+		seen[id] = true
+	}
+	for pages := 1; response.Meta["nextCursor"] != nil; pages++ {
+		if pages > 5 {
+			t.Fatalf("the listing didn't end after %d pages", pages)
+		}
+
+		cursor := response.Meta["nextCursor"].(string)
+		if response.Headers[HeaderNextCursor] != cursor {
+			t.Errorf("%s = %q, want the cursor %q", HeaderNextCursor, response.Headers[HeaderNextCursor], cursor)
+		}
+
+		response = list(context.Background(), conn, cursor)
+		for _, id := range userIDs(t, response) {
+			if seen[id] {
+				t.Errorf("the user %s was listed twice", id)
+			}
+			seen[id] = true
+		}
+	}
+
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	if len(ids) != 10 {
+		t.Errorf("the listing returned %v, want the 10 users", ids)
+	}
+}
+
+func TestScanStopsOnTheBudgetAndTheDeadline(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties string
+		deadline   time.Duration
+		want       int
+		calls      int
+	}{
+		{
+			name:       "capacity budget",
+			properties: "Keys:\n  UserID: EQ\nScan:\n  Enabled: true\n  PageSize: 4\n  MaxCapacity: 2\n",
+			want:       2,
+			calls:      2,
+		},
+		{
+			name:       "deadline",
+			properties: "Keys:\n  UserID: EQ\nScan:\n  Enabled: true\n  PageSize: 4\n",
+			deadline:   scanDeadlineMargin / 2,
+			want:       0,
+			calls:      0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := segmentedTable(t, 1, 10, 1)
+			conn := NewDynamoDB(loadConfig(t, tt.properties), client)
+
+			ctx := context.Background()
+			if tt.deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.deadline)
+				defer cancel()
+			}
+
+			response := list(ctx, conn, "")
+			if ids := userIDs(t, response); len(ids) != tt.want {
+				t.Errorf("Read() = %v, want %d users", ids, tt.want)
+			}
+			if got := client.count("Scan"); got != tt.calls {
+				t.Errorf("Scan called %d times, want %d", got, tt.calls)
+			}
+			if response.Meta["nextCursor"] == nil {
+				t.Errorf("Read() meta = %v, want the cursor of the next page", response.Meta)
+			}
+		})
+	}
+}
+
+func TestScanRefusesInvalidCursors(t *testing.T) {
+	encode := func(document string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(document))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "%%%"},
+		{name: "not json", cursor: encode(`[`)},
+		{name: "segments missing", cursor: encode(`{"segments": [{}]}`)},
+		{name: "attribute not of the key", cursor: encode(`{"segments": [{"key": {"Name": {"S": "Ana"}}}, {}]}`)},
+		{name: "attribute besides the key", cursor: encode(`{"segments": [{"key": {"UserID": {"S": "1"}, "Name": {"S": "Ana"}}}, {}]}`)},
+		{name: "key not scalar", cursor: encode(`{"segments": [{"key": {"UserID": {"M": {}}}}, {}]}`)},
+		{name: "key of two types", cursor: encode(`{"segments": [{"key": {"UserID": {"S": "1", "N": "1"}}}, {}]}`)},
+		{name: "ended with a key", cursor: encode(`{"segments": [{"key": {"UserID": {"S": "1"}}, "done": true}, {}]}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := segmentedTable(t, 2, 5, 0)
+			conn := NewDynamoDB(loadConfig(t, "Keys:\n  UserID: EQ\nScan:\n  Enabled: true\n  TotalSegments: 2\n"), client)
+
+			if response := list(context.Background(), conn, tt.cursor); response.StatusCode != 400 || client.count("Scan") != 0 {
+				t.Errorf("Read() = %d, Scan called %d times, want 400", response.StatusCode, client.count("Scan"))
+			}
+		})
+	}
+
+	// the cursors of the key are accepted
+	client := segmentedTable(t, 2, 5, 0)
+	conn := NewDynamoDB(loadConfig(t, "Keys:\n  UserID: EQ\nScan:\n  Enabled: true\n  TotalSegments: 2\n"), client)
+	cursor := encode(`{"segments": [{"key": {"UserID": {"S": "0-3"}}}, {"done": true}]}`)
+	if ids := userIDs(t, list(context.Background(), conn, cursor)); fmt.Sprint(ids) != "[0-4]" {
+		t.Errorf("Read() = %v, want the users after the cursor", ids)
+	}
+}
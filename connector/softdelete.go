@@ -0,0 +1,146 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+)
+
+// Restorer is implemented by the connectors able to restore the items deleted logically.
+type Restorer interface {
+	Restore(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse
+}
+
+// Restore clears the mark of a deleted item, answering with the item restored
+func (c *DynamoDB) Restore(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	data, err := c.Config.Resources.Connector.ComposeKeys(data)
+	if err != nil {
+		return lowcodeattribute.NewBadRequestResponse(err)
+	}
+
+	return c.restoreOnDynamoDB(ctx, data)
+}
+
+// softDeleteOnDynamoDB é uma função interna responsável por remover logicamente um ítem da tabela,
+// quando a configuração 'SoftDelete' for informada. Em vez de ser removido, o ítem recebe o horário
+// da remoção no atributo 'Attribute' (DeletedAt por padrão) e, se 'TTLAttribute' for informado, o
+// horário em que ele será expurgado pelo TTL da tabela, depois do período de 'Retention'.
+//
+// Os ítens removidos deixam de ser retornados pelas leituras e de ser atualizados até que sejam
+// restaurados. Caso o ítem não exista, ou já tenha sido removido, a função retornará um código 404.
+func (c *DynamoDB) softDeleteOnDynamoDB(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	res := &c.Config.Resources.Connector
+
+	if !res.HasFullKey(data) {
+		return lowcodeattribute.NewBadRequestResponse(errors.New("the key attributes are required to delete an item"))
+	}
+
+	keys, err := res.GetPrimaryKeyAttributeValue(data)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to get primary key: %w", err))
+	}
+
+	names := map[string]*string{}
+	values := map[string]*dynamodb.AttributeValue{}
+	commands := []string{}
+	for name, value := range res.Tombstone(time.Now()) {
+		names["#"+name] = aws.String(name)
+		values[":"+name] = value
+		commands = append(commands, fmt.Sprintf("#%s = :%s", name, name))
+	}
+	sort.Strings(commands)
+
+	conditions := []string{res.TombstoneCondition()}
+	for key := range keys {
+		names["#"+key] = aws.String(key)
+		conditions = append(conditions, fmt.Sprintf("attribute_exists(#%s)", key))
+	}
+	sort.Strings(conditions)
+
+	_, err = c.Client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(res.Properties.TableName),
+		Key:                       keys,
+		UpdateExpression:          aws.String("SET " + strings.Join(commands, ", ")),
+		ConditionExpression:       aws.String(strings.Join(conditions, " AND ")),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		var failed *dynamodb.ConditionalCheckFailedException
+		if errors.As(err, &failed) {
+			return lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindNotFound, "item not found"))
+		}
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to delete table item: %w", err))
+	}
+
+	return &lowcodeattribute.ExecutionResponse{
+		StatusCode: 200,
+	}
+}
+
+// restoreOnDynamoDB é uma função interna responsável por restaurar um ítem removido logicamente,
+// removendo os atributos que marcam a sua remoção ('Attribute' e 'TTLAttribute' da configuração
+// 'SoftDelete'). Se o ítem for restaurado com sucesso, a função retornará um código 200 com o ítem
+// restaurado, e um código 404 caso não exista um ítem removido com a chave informada.
+func (c *DynamoDB) restoreOnDynamoDB(ctx context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	res := &c.Config.Resources.Connector
+
+	if !res.SoftDeletes() {
+		return lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindNotFound, "the items are not deleted logically"))
+	}
+	if !res.HasFullKey(data) {
+		return lowcodeattribute.NewBadRequestResponse(errors.New("the key attributes are required to restore an item"))
+	}
+
+	keys, err := res.GetPrimaryKeyAttributeValue(data)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to get primary key: %w", err))
+	}
+
+	sd := res.Properties.SoftDelete
+	names := map[string]*string{"#" + sd.Attribute: aws.String(sd.Attribute)}
+	removed := []string{"#" + sd.Attribute}
+	if sd.TTLAttribute != "" {
+		names["#"+sd.TTLAttribute] = aws.String(sd.TTLAttribute)
+		removed = append(removed, "#"+sd.TTLAttribute)
+	}
+
+	output, err := c.Client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                aws.String(res.Properties.TableName),
+		Key:                      keys,
+		UpdateExpression:         aws.String("REMOVE " + strings.Join(removed, ", ")),
+		ConditionExpression:      aws.String(fmt.Sprintf("attribute_exists(#%s)", sd.Attribute)),
+		ExpressionAttributeNames: names,
+		ReturnValues:             aws.String(dynamodb.ReturnValueAllNew),
+	})
+	if err != nil {
+		var failed *dynamodb.ConditionalCheckFailedException
+		if errors.As(err, &failed) {
+			return lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindNotFound, "deleted item not found"))
+		}
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to restore table item: %w", err))
+	}
+
+	var item map[string]interface{}
+	if err := dynamodbattribute.UnmarshalMap(output.Attributes, &item); err != nil {
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to deserialize response: %w", err))
+	}
+
+	resolved, err := res.ResolveItem(item)
+	if err != nil {
+		return lowcodeattribute.NewErrorResponse(lowcodeattribute.NewError(lowcodeattribute.KindInternal, "failed to resolve item schema", err))
+	}
+
+	return &lowcodeattribute.ExecutionResponse{
+		StatusCode: 200,
+		Message:    resolved,
+	}
+}
@@ -0,0 +1,97 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+)
+
+// deletableTable keeps a single item, marking it as deleted and restoring it by the updates the
+// way DynamoDB evaluates their conditions on the tombstone
+func deletableTable(t *testing.T) *stubDynamoDB {
+	item := marshalItem(t, map[string]interface{}{"UserID": "1", "Name": "Ana", "Age": 30})
+	failed := &dynamodb.ConditionalCheckFailedException{Message_: aws.String("failed")}
+
+	client := &stubDynamoDB{}
+	client.getItem = func(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+		return &dynamodb.GetItemOutput{Item: item}, nil
+	}
+	client.updateItem = func(input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+		_, deleted := item["DeletedAt"]
+		condition := aws.StringValue(input.ConditionExpression)
+
+		switch expression := aws.StringValue(input.UpdateExpression); {
+		case strings.HasPrefix(expression, "SET "):
+			if !strings.Contains(condition, "attribute_not_exists(#DeletedAt)") {
+				t.Errorf("ConditionExpression = %s, want the items not deleted", condition)
+			}
+			if deleted {
+				return nil, failed
+			}
+			for name, attribute := range input.ExpressionAttributeNames {
+				if value := input.ExpressionAttributeValues[":"+aws.StringValue(attribute)]; value != nil {
+					item[strings.TrimPrefix(name, "#")] = value
+				}
+			}
+		case strings.HasPrefix(expression, "REMOVE "):
+			if condition != "attribute_exists(#DeletedAt)" {
+				t.Errorf("ConditionExpression = %s, want the items deleted", condition)
+			}
+			if !deleted {
+				return nil, failed
+			}
+			for _, attribute := range input.ExpressionAttributeNames {
+				delete(item, aws.StringValue(attribute))
+			}
+		default:
+			t.Fatalf("UpdateExpression = %s, want a tombstone written or removed", expression)
+		}
+
+		return &dynamodb.UpdateItemOutput{Attributes: item}, nil
+	}
+
+	return client
+}
+
+func TestSoftDeleteHidesTheItemUntilRestored(t *testing.T) {
+	conn := NewDynamoDB(loadConfig(t, "Keys:\n  UserID: EQ\nSoftDelete: {}\n"), deletableTable(t))
+	ctx := context.Background()
+	key := func() map[string]interface{} { return map[string]interface{}{"UserID": "1"} }
+
+	steps := []struct {
+		name   string
+		action func(context.Context, interface{}) int
+		want   int
+	}{
+		{name: "read", action: status(conn.Read), want: http.StatusOK},
+		{name: "delete", action: status(conn.Delete), want: http.StatusOK},
+		{name: "read deleted", action: status(conn.Read), want: http.StatusNotFound},
+		{name: "delete again", action: status(conn.Delete), want: http.StatusNotFound},
+		{name: "restore", action: status(conn.Restore), want: http.StatusOK},
+		{name: "read restored", action: status(conn.Read), want: http.StatusOK},
+		{name: "restore again", action: status(conn.Restore), want: http.StatusNotFound},
+	}
+
+	for _, step := range steps {
+		if got := step.action(ctx, key()); got != step.want {
+			t.Fatalf("%s = %d, want %d", step.name, got, step.want)
+		}
+	}
+
+	response := conn.Read(ctx, key())
+	if item, _ := response.Message.(map[string]interface{}); item["Name"] != "Ana" || item["DeletedAt"] != nil {
+		t.Errorf("Read() = %v, want the item restored without the tombstone", response.Message)
+	}
+}
+
+// status returns the status code of the responses of the operation
+func status(operation func(context.Context, interface{}) *lowcodeattribute.ExecutionResponse) func(context.Context, interface{}) int {
+	return func(ctx context.Context, data interface{}) int {
+		return operation(ctx, data).StatusCode
+	}
+}
@@ -0,0 +1,127 @@
+package connector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+)
+
+// maxClientRequestToken is the length limit of the client tokens of the transactions
+const maxClientRequestToken = 36
+
+// cancellationKinds maps the reasons of the items of a canceled transaction to the kinds of errors
+var cancellationKinds = map[string]lowcodeattribute.Kind{
+	"ConditionalCheckFailed":          lowcodeattribute.KindConflict,
+	"ItemCollectionSizeLimitExceeded": lowcodeattribute.KindConflict,
+	"TransactionConflict":             lowcodeattribute.KindConflict,
+	"ProvisionedThroughputExceeded":   lowcodeattribute.KindThrottled,
+	"ThrottlingError":                 lowcodeattribute.KindThrottled,
+	"RequestLimitExceeded":            lowcodeattribute.KindThrottled,
+	"ValidationError":                 lowcodeattribute.KindValidation,
+}
+
+// transactOnDynamoDB é uma função interna responsável por gravar, de forma atômica, os ítens da
+// transação configurada para o método da requisição ('Transactions'), por meio de uma única chamada
+// TransactWriteItems, que pode incluir ítens de outras tabelas.
+//
+// Cada ítem da transação grava (Put), atualiza (Update), remove (Delete) ou apenas verifica uma
+// condição (ConditionCheck) sobre o registro do conector ou sobre o registro montado pelas regras de
+// 'Mapping' do ítem. O header 'Idempotency-Key' (ou 'TokenHeader') é usado como token do cliente,
+// tornando as repetições da mesma transação idempotentes por dez minutos.
+//
+// Se a transação for cancelada, a resposta indica o motivo de cada ítem em 'items', com o código de
+// status do primeiro ítem que falhou, como 409 para as condições não atendidas.
+func (c *DynamoDB) transactOnDynamoDB(ctx context.Context, tx *config.Transaction, status int, data interface{}) *lowcodeattribute.ExecutionResponse {
+	res := &c.Config.Resources.Connector
+
+	record, ok := data.(map[string]interface{})
+	if !ok {
+		return lowcodeattribute.NewBadRequestResponse(fmt.Errorf("unsupported data structure: %T", data))
+	}
+
+	req, _ := mapping.FromContext(ctx)
+
+	items, err := res.TransactItems(tx, record, req)
+	if err != nil {
+		return lowcodeattribute.NewBadRequestResponse(err)
+	}
+
+	input := &dynamodb.TransactWriteItemsInput{TransactItems: items}
+	if token := mapping.HeaderValue(req.Header, tx.TokenHeaderName()); token != "" {
+		input.ClientRequestToken = aws.String(clientRequestToken(token))
+	}
+
+	if _, err := c.Client.TransactWriteItemsWithContext(ctx, input); err != nil {
+		var canceled *dynamodb.TransactionCanceledException
+		if errors.As(err, &canceled) {
+			return canceledTransaction(canceled.CancellationReasons)
+		}
+		return lowcodeattribute.NewErrorResponse(fmt.Errorf("failed to write transaction: %w", err))
+	}
+
+	response := &lowcodeattribute.ExecutionResponse{StatusCode: status}
+	if status == 201 {
+		response.Message = res.ItemKey(record)
+	}
+
+	return response
+}
+
+// canceledTransaction creates the response of a canceled transaction, listing the reason of each
+// one of its items
+func canceledTransaction(reasons []*dynamodb.CancellationReason) *lowcodeattribute.ExecutionResponse {
+	kind := lowcodeattribute.Kind("")
+	items := make([]lowcodeattribute.ItemResult, len(reasons))
+
+	for i, reason := range reasons {
+		code := aws.StringValue(reason.Code)
+		if code == "" || code == "None" {
+			items[i] = lowcodeattribute.ItemResult{Index: i, StatusCode: 424, Message: "the item was not written because the transaction was canceled"}
+			continue
+		}
+
+		itemKind, ok := cancellationKinds[code]
+		if !ok {
+			itemKind = lowcodeattribute.KindInternal
+		}
+		if kind == "" {
+			kind = itemKind
+		}
+
+		message := aws.StringValue(reason.Message)
+		if message == "" {
+			message = code
+		}
+		items[i] = lowcodeattribute.NewItemResult(i, lowcodeattribute.NewErrorResponse(lowcodeattribute.NewError(itemKind, message, nil)))
+	}
+
+	if kind == "" {
+		kind = lowcodeattribute.KindConflict
+	}
+
+	response := lowcodeattribute.NewErrorResponse(lowcodeattribute.NewError(kind, "the transaction was canceled", nil))
+	if problem, ok := response.Message.(*lowcodeattribute.Problem); ok {
+		problem.Items = items
+	}
+
+	return response
+}
+
+// clientRequestToken returns the client token of a transaction, hashing the keys longer than the
+// limit of DynamoDB
+func clientRequestToken(key string) string {
+	if len(key) <= maxClientRequestToken {
+		return key
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:maxClientRequestToken]
+}
@@ -0,0 +1,115 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+)
+
+const userTransactions = `
+Keys:
+  UserID: EQ
+Transactions:
+  POST:
+    Items:
+      - Condition: attribute_not_exists(#UserID)
+      - Action: ConditionCheck
+        TableName: accounts
+        Condition: attribute_exists(#UserID)
+`
+
+func TestTransactWritesTheItems(t *testing.T) {
+	var input *dynamodb.TransactWriteItemsInput
+
+	client := &stubDynamoDB{}
+	client.transactWriteItems = func(in *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+		input = in
+		return &dynamodb.TransactWriteItemsOutput{}, nil
+	}
+
+	conn := NewDynamoDB(loadConfig(t, userTransactions), client)
+
+	key := strings.Repeat("k", 40)
+	ctx := mapping.NewContext(context.Background(), mapping.Request{Header: map[string]string{"Idempotency-Key": key}})
+
+	response := conn.Create(ctx, map[string]interface{}{"UserID": "42", "Name": "Ana"})
+	if response.StatusCode != http.StatusCreated || response.Message.(map[string]interface{})["UserID"] != "42" {
+		t.Fatalf("Create() = %d %v, want 201 with the key", response.StatusCode, response.Message)
+	}
+
+	if len(input.TransactItems) != 2 || input.TransactItems[0].Put == nil || input.TransactItems[1].ConditionCheck == nil {
+		t.Errorf("TransactWriteItems() items = %v, want the put and the check", input.TransactItems)
+	}
+	if token := aws.StringValue(input.ClientRequestToken); len(token) != maxClientRequestToken || token == key[:maxClientRequestToken] {
+		t.Errorf("ClientRequestToken = %q, want the hash of the long key", token)
+	}
+}
+
+func TestTransactMapsTheCancellationReasons(t *testing.T) {
+	reason := func(code string) *dynamodb.CancellationReason {
+		return &dynamodb.CancellationReason{Code: aws.String(code)}
+	}
+
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		results []int
+	}{
+		{
+			name:    "condition failed",
+			err:     &dynamodb.TransactionCanceledException{CancellationReasons: []*dynamodb.CancellationReason{reason("ConditionalCheckFailed"), reason("None")}},
+			status:  http.StatusConflict,
+			results: []int{http.StatusConflict, http.StatusFailedDependency},
+		},
+		{
+			name:    "throttled",
+			err:     &dynamodb.TransactionCanceledException{CancellationReasons: []*dynamodb.CancellationReason{reason("None"), reason("ThrottlingError")}},
+			status:  http.StatusTooManyRequests,
+			results: []int{http.StatusFailedDependency, http.StatusTooManyRequests},
+		},
+		{
+			name:    "unknown reason",
+			err:     &dynamodb.TransactionCanceledException{CancellationReasons: []*dynamodb.CancellationReason{reason("Unknown"), reason("None")}},
+			status:  http.StatusInternalServerError,
+			results: []int{http.StatusInternalServerError, http.StatusFailedDependency},
+		},
+		{name: "other failure", err: errors.New("network"), status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &stubDynamoDB{}
+			client.transactWriteItems = func(*dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+				return nil, tt.err
+			}
+
+			conn := NewDynamoDB(loadConfig(t, userTransactions), client)
+
+			response := conn.Create(context.Background(), map[string]interface{}{"UserID": "42", "Name": "Ana"})
+			if response.StatusCode != tt.status {
+				t.Fatalf("Create() = %d, want %d", response.StatusCode, tt.status)
+			}
+			if tt.results == nil {
+				return
+			}
+
+			problem, ok := response.Message.(*lowcodeattribute.Problem)
+			if !ok || len(problem.Items) != len(tt.results) {
+				t.Fatalf("Create() message = %#v, want the result of each item", response.Message)
+			}
+			for i, status := range tt.results {
+				if problem.Items[i].StatusCode != status {
+					t.Errorf("items[%d] = %d, want %d", i, problem.Items[i].StatusCode, status)
+				}
+			}
+		})
+	}
+}
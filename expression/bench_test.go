@@ -0,0 +1,24 @@
+package expression
+
+import (
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+)
+
+func BenchmarkEval(b *testing.B) {
+	env, _ := newEnv(b)
+	program, err := env.Compile(`FirstName + " " + LastName`, cel.StringType)
+	if err != nil {
+		b.Fatal(err)
+	}
+	req := mapping.Request{Body: map[string]interface{}{"FirstName": "Ana", "LastName": "Silva", "Age": int32(30)}}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := program.Eval(req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// Package expression evaluates the CEL (Common Expression Language) expressions of the configuration:
+// computed fields, validation rules, routing conditions and filter values. The expressions are
+// compiled and type-checked when the configuration is loaded, using the fields of the receiver
+// schema as variables, and evaluated for each record.
+package expression
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/ext"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+// Variables available to every expression, besides the fields of the receiver schema.
+const (
+	VarBody   = "body"
+	VarPath   = "path"
+	VarHeader = "header"
+	VarQuery  = "query"
+	VarClaims = "claims"
+)
+
+// identifier matches the field names that can be used as variables
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reserved are the words that can't be used as variables by CEL
+var reserved = map[string]bool{
+	"as": true, "break": true, "const": true, "continue": true, "else": true, "false": true,
+	"for": true, "function": true, "if": true, "import": true, "in": true, "let": true,
+	"loop": true, "package": true, "namespace": true, "null": true, "return": true,
+	"true": true, "var": true, "void": true, "while": true,
+}
+
+type (
+	// Env declares the variables of the expressions.
+	Env struct {
+		env    *cel.Env
+		fields map[string]bool
+	}
+
+	// Program is a compiled expression.
+	Program struct {
+		Source string
+
+		program cel.Program
+		output  *cel.Type
+		fields  map[string]bool
+	}
+)
+
+// NewEnv creates the environment of the expressions. The top level fields of the record schema,
+// when there is one, are declared as variables typed by the schema, like 'FirstName', while the
+// whole record is available as 'body' and the parameters of the request as 'path', 'header',
+// 'query' and 'claims'. The string functions of the CEL extensions, like substring and
+// lowerAscii, are available.
+func NewEnv(s *schema.Schema) (*Env, error) {
+	e := &Env{fields: map[string]bool{}}
+
+	opts := []cel.EnvOption{
+		cel.Variable(VarBody, cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable(VarPath, cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable(VarHeader, cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable(VarQuery, cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable(VarClaims, cel.MapType(cel.StringType, cel.DynType)),
+		ext.Strings(),
+	}
+
+	if s != nil {
+		for _, field := range s.Element().Fields {
+			if !declarable(field.Name) || e.fields[field.Name] {
+				continue
+			}
+
+			e.fields[field.Name] = true
+			opts = append(opts, cel.Variable(field.Name, celType(field.Schema)))
+		}
+	}
+
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	e.env = env
+	return e, nil
+}
+
+// declarable reports whether a field can be declared as a variable
+func declarable(name string) bool {
+	switch name {
+	case VarBody, VarPath, VarHeader, VarQuery, VarClaims:
+		return false
+	}
+
+	return identifier.MatchString(name) && !reserved[name]
+}
+
+// celType returns the CEL type of the values of a schema
+func celType(s *schema.Schema) *cel.Type {
+	t := s.NonNull()
+
+	switch t.Type {
+	case schema.Boolean:
+		return cel.BoolType
+	case schema.Int, schema.Long:
+		return cel.IntType
+	case schema.Float, schema.Double:
+		return cel.DoubleType
+	case schema.String, schema.Enum:
+		return cel.StringType
+	case schema.Bytes, schema.Fixed:
+		return cel.BytesType
+	case schema.Array:
+		return cel.ListType(celType(t.Items))
+	case schema.Map:
+		return cel.MapType(cel.StringType, celType(t.Values))
+	case schema.Record:
+		return cel.MapType(cel.StringType, cel.DynType)
+	default:
+		return cel.DynType
+	}
+}
+
+// Declare adds a variable to the environment, like a computed field used by the next expressions.
+func (e *Env) Declare(name string, t *cel.Type) error {
+	if !declarable(name) {
+		return fmt.Errorf("%s cannot be used as a variable", name)
+	}
+
+	env, err := e.env.Extend(cel.Variable(name, t))
+	if err != nil {
+		return err
+	}
+
+	e.env = env
+	e.fields[name] = true
+	return nil
+}
+
+// Compile parses and type-checks the expression. When want is informed, the expression must
+// produce a value of that type.
+func (e *Env) Compile(source string, want *cel.Type) (*Program, error) {
+	ast, issues := e.env.Compile(source)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("invalid expression %q: %v", source, issues.Err())
+	}
+
+	output := ast.OutputType()
+	if want != nil && !output.IsAssignableType(want) && !output.IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("expression %q produces %s, expected %s", source, output, want)
+	}
+
+	program, err := e.env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %v", source, err)
+	}
+
+	fields := make(map[string]bool, len(e.fields))
+	for name := range e.fields {
+		fields[name] = true
+	}
+
+	return &Program{
+		Source:  source,
+		program: program,
+		output:  output,
+		fields:  fields,
+	}, nil
+}
+
+// OutputType returns the type of the values produced by the expression.
+func (p *Program) OutputType() *cel.Type {
+	return p.output
+}
+
+// Eval evaluates the expression with the values of the request, returning its native value.
+func (p *Program) Eval(req mapping.Request) (interface{}, error) {
+	vars := map[string]interface{}{
+		VarBody:   req.Body,
+		VarPath:   req.Path,
+		VarHeader: req.Header,
+		VarQuery:  req.Query,
+		VarClaims: req.Claims,
+	}
+
+	// absent parameters are empty maps, so has() and 'in' can be used on them
+	if req.Body == nil {
+		vars[VarBody] = map[string]interface{}{}
+	}
+	if req.Path == nil {
+		vars[VarPath] = map[string]string{}
+	}
+	if req.Header == nil {
+		vars[VarHeader] = map[string]string{}
+	}
+	if req.Query == nil {
+		vars[VarQuery] = map[string]string{}
+	}
+	if req.Claims == nil {
+		vars[VarClaims] = map[string]interface{}{}
+	}
+
+	for name := range p.fields {
+		// missing fields are left unbound, so they fail only when used; use has(body.Field)
+		if value, ok := req.Body[name]; ok {
+			vars[name] = value
+		}
+	}
+
+	out, _, err := p.program.Eval(vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed evaluating %q: %v", p.Source, err)
+	}
+
+	return native(out)
+}
+
+// EvalBool evaluates an expression that produces a boolean, like a condition.
+func (p *Program) EvalBool(req mapping.Request) (bool, error) {
+	value, err := p.Eval(req)
+	if err != nil {
+		return false, err
+	}
+
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %q produced %T, expected bool", p.Source, value)
+	}
+
+	return result, nil
+}
+
+var (
+	listType = reflect.TypeOf([]interface{}{})
+	mapType  = reflect.TypeOf(map[string]interface{}{})
+)
+
+// native converts a CEL value into the native values used by the schemas
+func native(value ref.Val) (interface{}, error) {
+	switch value.Type() {
+	case types.ListType:
+		return value.ConvertToNative(listType)
+	case types.MapType:
+		return value.ConvertToNative(mapType)
+	case types.TimestampType:
+		return value.Value().(time.Time).Format(time.RFC3339Nano), nil
+	case types.DurationType:
+		return value.Value().(time.Duration).String(), nil
+	case types.NullType:
+		return nil, nil
+	case types.UintType:
+		return int64(value.Value().(uint64)), nil
+	default:
+		return value.Value(), nil
+	}
+}
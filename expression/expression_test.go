@@ -0,0 +1,207 @@
+package expression
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+const userAvro = `{
+  "type": "record",
+  "name": "User",
+  "fields": [
+    { "name": "FirstName", "type": "string" },
+    { "name": "LastName", "type": "string" },
+    { "name": "Age", "type": "int" },
+    { "name": "FullName", "type": ["null", "string"], "default": null },
+    { "name": "Tags", "type": { "type": "array", "items": "string" } },
+    { "name": "in", "type": "string" }
+  ]
+}`
+
+func newEnv(t testing.TB) (*Env, *schema.Schema) {
+	t.Helper()
+
+	s, err := schema.Parse([]byte(userAvro))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	env, err := NewEnv(s)
+	if err != nil {
+		t.Fatalf("NewEnv() error = %v", err)
+	}
+
+	return env, s
+}
+
+func TestCompileChecksTheTypes(t *testing.T) {
+	env, _ := newEnv(t)
+
+	tests := []struct {
+		name    string
+		source  string
+		want    *cel.Type
+		wantErr bool
+	}{
+		{name: "field", source: `FirstName + " " + LastName`, want: cel.StringType},
+		{name: "request", source: `has(header.Tenant) && claims.sub != ""`, want: cel.BoolType},
+		{name: "dynamic body", source: `body.Nickname`, want: cel.StringType},
+		{name: "string extension", source: `FirstName.lowerAscii()`, want: cel.StringType},
+		{name: "wrong type", source: `Age + 1`, want: cel.StringType, wantErr: true},
+		{name: "unknown variable", source: `Unknown == 1`, wantErr: true},
+		{name: "reserved field", source: `in == "x"`, wantErr: true},
+		{name: "syntax", source: `Age +`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := env.Compile(tt.source, tt.want); (err != nil) != tt.wantErr {
+				t.Errorf("Compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEval(t *testing.T) {
+	env, _ := newEnv(t)
+
+	tests := []struct {
+		source string
+		want   interface{}
+	}{
+		{source: `FirstName + " " + LastName`, want: "Ana Silva"},
+		{source: `Age * 2`, want: int64(60)},
+		{source: `Tags.map(t, t.upperAscii())`, want: []interface{}{"A", "B"}},
+		{source: `{"name": FirstName}`, want: map[string]interface{}{"name": "Ana"}},
+		{source: `path.UserID`, want: "42"},
+		{source: `has(query.page)`, want: false},
+		{source: `timestamp("2024-01-02T03:04:05Z")`, want: "2024-01-02T03:04:05Z"},
+		{source: `duration("90s")`, want: "1m30s"},
+		{source: `null`, want: nil},
+		{source: `uint(3)`, want: int64(3)},
+	}
+
+	req := mapping.Request{
+		Body: map[string]interface{}{"FirstName": "Ana", "LastName": "Silva", "Age": int32(30), "Tags": []interface{}{"a", "b"}},
+		Path: map[string]string{"UserID": "42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			program, err := env.Compile(tt.source, nil)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+
+			got, err := program.Eval(req)
+			if err != nil {
+				t.Fatalf("Eval() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Eval() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalMissingField(t *testing.T) {
+	env, _ := newEnv(t)
+
+	program, _ := env.Compile(`FirstName == "Ana"`, cel.BoolType)
+	if _, err := program.EvalBool(mapping.Request{}); err == nil {
+		t.Errorf("EvalBool() error = nil, want the missing FirstName")
+	}
+
+	program, _ = env.Compile(`has(body.FirstName)`, cel.BoolType)
+	if got, err := program.EvalBool(mapping.Request{}); err != nil || got {
+		t.Errorf("EvalBool() = %v, %v, want false", got, err)
+	}
+}
+
+func TestExpressionsApply(t *testing.T) {
+	env, s := newEnv(t)
+
+	x := &Expressions{
+		Computed: []*Computed{
+			{Target: "FullName", Expression: `FirstName + " " + LastName`},
+			{Target: "Initials", Expression: `FirstName.substring(0, 1) + LastName.substring(0, 1)`},
+			{Target: "Greeting", Expression: `"Hi " + Initials`},
+		},
+		Validations: []*Validation{
+			{Expression: `Age >= 18`, Field: "Age", Message: "must be an adult"},
+		},
+	}
+	if err := x.Compile(env, s); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	got, err := x.Apply(mapping.Request{Body: map[string]interface{}{"FirstName": "Ana", "LastName": "Silva", "Age": int32(30)}})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got["FullName"] != "Ana Silva" || got["Initials"] != "AS" || got["Greeting"] != "Hi AS" {
+		t.Errorf("Apply() = %v, want the computed fields", got)
+	}
+
+	_, err = x.Apply(mapping.Request{Body: map[string]interface{}{"FirstName": "Ana", "LastName": "Silva", "Age": int32(10)}})
+
+	var validationErr *schema.ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Errors) != 1 || validationErr.Errors[0].Message != "must be an adult" {
+		t.Errorf("Apply() error = %v, want the validation message", err)
+	}
+}
+
+func TestExpressionsCompileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		x    *Expressions
+	}{
+		{name: "computed without target", x: &Expressions{Computed: []*Computed{{Expression: `1`}}}},
+		{name: "computed of another type", x: &Expressions{Computed: []*Computed{{Target: "Age", Expression: `"old"`}}}},
+		{name: "validation not bool", x: &Expressions{Validations: []*Validation{{Expression: `Age`}}}},
+		{name: "unknown action", x: &Expressions{Routes: []*Route{{Condition: `true`, Action: "PATCH"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, s := newEnv(t)
+			if err := tt.x.Compile(env, s); err == nil {
+				t.Errorf("Compile() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestRoute(t *testing.T) {
+	env, s := newEnv(t)
+
+	x := &Expressions{Routes: []*Route{
+		{Condition: `body.Status == "deleted"`, Action: "delete"},
+		{Condition: `Age < 0`, Action: ActionSkip},
+	}}
+	if err := x.Compile(env, s); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	tests := []struct {
+		body map[string]interface{}
+		want string
+	}{
+		{body: map[string]interface{}{"Status": "deleted", "Age": int32(1)}, want: "DELETE"},
+		{body: map[string]interface{}{"Age": int32(-1)}, want: ActionSkip},
+		{body: map[string]interface{}{"Age": int32(1)}, want: "POST"},
+		// the conditions that can't be evaluated are not satisfied
+		{body: map[string]interface{}{}, want: "POST"},
+	}
+
+	for _, tt := range tests {
+		if got := x.Route(mapping.Request{Body: tt.body}, "POST"); got != tt.want {
+			t.Errorf("Route(%v) = %s, want %s", tt.body, got, tt.want)
+		}
+	}
+}
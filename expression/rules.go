@@ -0,0 +1,188 @@
+package expression
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+// ActionSkip is the route action that ignores the record.
+const ActionSkip = "SKIP"
+
+type (
+	// Expressions is the 'Expressions' section of the configuration.
+	Expressions struct {
+		// Computed fields are added to the records written, in order, so a computed field can be
+		// used by the expressions that follow it
+		Computed []*Computed `yaml:"Computed"`
+		// Validations are the rules the records written must satisfy besides their schema
+		Validations []*Validation `yaml:"Validations"`
+		// Routes replace the action requested by the first route whose condition is satisfied
+		Routes []*Route `yaml:"Routes"`
+	}
+
+	// Computed writes the value of the expression into the field 'Target'.
+	Computed struct {
+		Target     string `yaml:"Target"`
+		Expression string `yaml:"Expression"`
+
+		program *Program
+	}
+
+	// Validation refuses the records for which the expression is false, reporting 'Message'
+	// on the path 'Field'.
+	Validation struct {
+		Expression string `yaml:"Expression"`
+		Field      string `yaml:"Field"`
+		Message    string `yaml:"Message"`
+
+		program *Program
+	}
+
+	// Route executes 'Action' (GET, POST, PUT, DELETE or SKIP) when the condition is true.
+	Route struct {
+		Condition string `yaml:"Condition"`
+		Action    string `yaml:"Action"`
+
+		program *Program
+	}
+)
+
+// Compile type-checks the expressions using the fields of the record schema, which may be nil.
+// A nil section is valid and has no effect.
+func (x *Expressions) Compile(env *Env, s *schema.Schema) error {
+	if x == nil {
+		return nil
+	}
+
+	var err error
+
+	for _, computed := range x.Computed {
+		if computed.Target == "" {
+			return fmt.Errorf("computed field requires a Target")
+		}
+
+		var want *cel.Type
+		if s != nil {
+			if field := s.Element().Field(computed.Target); field != nil {
+				want = celType(field.Schema)
+			}
+		}
+
+		if computed.program, err = env.Compile(computed.Expression, want); err != nil {
+			return fmt.Errorf("computed field %s: %v", computed.Target, err)
+		}
+
+		// the next expressions can use the computed field
+		if want == nil && declarable(computed.Target) {
+			if err := env.Declare(computed.Target, computed.program.OutputType()); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, validation := range x.Validations {
+		if validation.program, err = env.Compile(validation.Expression, cel.BoolType); err != nil {
+			return fmt.Errorf("validation: %v", err)
+		}
+	}
+
+	for _, route := range x.Routes {
+		switch strings.ToUpper(route.Action) {
+		case "GET", "POST", "PUT", "DELETE", ActionSkip:
+			route.Action = strings.ToUpper(route.Action)
+		default:
+			return fmt.Errorf("route action %s is not supported", route.Action)
+		}
+
+		if route.program, err = env.Compile(route.Condition, cel.BoolType); err != nil {
+			return fmt.Errorf("route: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Apply adds the computed fields to the body of the request and checks the validation rules,
+// returning the resulting record. A *schema.ValidationError is returned when a rule is not
+// satisfied or an expression can't be evaluated with the record received.
+func (x *Expressions) Apply(req mapping.Request) (map[string]interface{}, error) {
+	if x == nil || (len(x.Computed) == 0 && len(x.Validations) == 0) {
+		return req.Body, nil
+	}
+
+	record := make(map[string]interface{}, len(req.Body)+len(x.Computed))
+	for key, value := range req.Body {
+		record[key] = value
+	}
+	req.Body = record
+
+	errs := &schema.ValidationError{}
+
+	for _, computed := range x.Computed {
+		value, err := computed.program.Eval(req)
+		if err != nil {
+			errs.Errors = append(errs.Errors, schema.FieldError{
+				Path:     computed.Target,
+				Expected: computed.Expression,
+				Received: "error",
+				Message:  err.Error(),
+			})
+			continue
+		}
+
+		record[computed.Target] = value
+	}
+
+	for _, validation := range x.Validations {
+		ok, err := validation.program.EvalBool(req)
+		if ok {
+			continue
+		}
+
+		message := validation.Message
+		if message == "" {
+			message = fmt.Sprintf("must satisfy %s", validation.Expression)
+		}
+		if err != nil {
+			message = err.Error()
+		}
+
+		received := "missing"
+		if value, found := record[validation.Field]; found {
+			received = schema.Kind(value)
+		}
+
+		errs.Errors = append(errs.Errors, schema.FieldError{
+			Path:     validation.Field,
+			Expected: validation.Expression,
+			Received: received,
+			Message:  message,
+		})
+	}
+
+	if len(errs.Errors) > 0 {
+		return nil, errs
+	}
+
+	return record, nil
+}
+
+// Route returns the action of the first route whose condition is satisfied by the request, or the
+// action requested when there is none. Conditions that can't be evaluated are not satisfied.
+func (x *Expressions) Route(req mapping.Request, action string) string {
+	if x == nil {
+		return action
+	}
+
+	for _, route := range x.Routes {
+		if ok, _ := route.program.EvalBool(req); ok {
+			return route.Action
+		}
+	}
+
+	return action
+}
@@ -0,0 +1,210 @@
+// Package generator fills the attributes generated by the server on the records received, like
+// identifiers, sequential numbers, timestamps and expiration times, following the 'Generators'
+// section of the configuration.
+package generator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of generators.
+const (
+	TypeUUIDv4    = "uuidv4"
+	TypeUUIDv7    = "uuidv7"
+	TypeULID      = "ulid"
+	TypeKSUID     = "ksuid"
+	TypeCounter   = "counter"
+	TypeTimestamp = "timestamp"
+	TypeTTL       = "ttl"
+)
+
+// Formats of the timestamps, besides the Go layouts.
+const (
+	FormatRFC3339     = "rfc3339"
+	FormatRFC3339Nano = "rfc3339nano"
+	FormatUnix        = "unix"
+	FormatUnixMilli   = "unixmilli"
+	FormatDate        = "date"
+)
+
+// DefaultCounterKey is the partition key of the counter items when 'CounterKey' is not informed.
+const DefaultCounterKey = "Name"
+
+// Counter is implemented by the connectors able to increment sequential counters atomically.
+type Counter interface {
+	// Next increments the counter called name, stored on the item of table whose partition key
+	// key has this name, returning its new value
+	Next(ctx context.Context, table, key, name string) (int64, error)
+}
+
+type (
+	// Generators is the 'Generators' section of the configuration.
+	Generators []*Generator
+
+	// Generator writes a generated value into the field 'Target' of the records received by the
+	// methods listed in 'On', POST by default. The values supplied by the client are replaced,
+	// unless 'KeepProvided' is set.
+	Generator struct {
+		Target       string   `yaml:"Target"`
+		Type         string   `yaml:"Type"`
+		On           []string `yaml:"On"`
+		KeepProvided bool     `yaml:"KeepProvided"`
+
+		// Format of the timestamps: rfc3339 (default), rfc3339nano, unix, unixmilli, date or a Go
+		// layout like '2006-01-02 15:04'
+		Format string `yaml:"Format"`
+		// Duration added to the current time by the TTL, like '720h' or '30d'
+		Duration string `yaml:"Duration"`
+
+		// Table, partition key and name of the item of the counters
+		CounterTable string `yaml:"CounterTable"`
+		CounterKey   string `yaml:"CounterKey"`
+		CounterName  string `yaml:"CounterName"`
+
+		duration time.Duration
+	}
+)
+
+// Compile validates the generators. A nil section is valid and generates nothing.
+func (g Generators) Compile() error {
+	for _, gen := range g {
+		if err := gen.compile(); err != nil {
+			return fmt.Errorf("generator of %s: %v", gen.Target, err)
+		}
+	}
+
+	return nil
+}
+
+func (gen *Generator) compile() error {
+	if gen.Target == "" {
+		return fmt.Errorf("Target is required")
+	}
+
+	gen.Type = strings.ToLower(gen.Type)
+	switch gen.Type {
+	case TypeUUIDv4, TypeUUIDv7, TypeULID, TypeKSUID, TypeTimestamp:
+	case TypeCounter:
+		if gen.CounterTable == "" {
+			return fmt.Errorf("counter requires a CounterTable")
+		}
+		if gen.CounterKey == "" {
+			gen.CounterKey = DefaultCounterKey
+		}
+		if gen.CounterName == "" {
+			gen.CounterName = gen.Target
+		}
+	case TypeTTL:
+		duration, err := ParseDuration(gen.Duration)
+		if err != nil {
+			return fmt.Errorf("invalid TTL duration %q: %v", gen.Duration, err)
+		}
+		gen.duration = duration
+	default:
+		return fmt.Errorf("unsupported type %s", gen.Type)
+	}
+
+	if len(gen.On) == 0 {
+		gen.On = []string{"POST"}
+	}
+	for i, method := range gen.On {
+		gen.On[i] = strings.ToUpper(method)
+	}
+
+	return nil
+}
+
+// ParseDuration parses a Go duration, also accepting a number of days, like '30d'.
+func ParseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(value)
+}
+
+// Apply fills the generated fields of the record received by the method, returning the values
+// generated. Counters are incremented by the counter received, usually the connector.
+func (g Generators) Apply(ctx context.Context, method string, record map[string]interface{}, counter Counter) (map[string]interface{}, error) {
+	generated := map[string]interface{}{}
+	now := time.Now().UTC()
+
+	for _, gen := range g {
+		if !gen.runsOn(method) {
+			continue
+		}
+
+		if _, provided := record[gen.Target]; provided && gen.KeepProvided {
+			continue
+		}
+
+		value, err := gen.generate(ctx, now, counter)
+		if err != nil {
+			return nil, fmt.Errorf("failed generating %s: %v", gen.Target, err)
+		}
+
+		record[gen.Target] = value
+		generated[gen.Target] = value
+	}
+
+	return generated, nil
+}
+
+func (gen *Generator) runsOn(method string) bool {
+	for _, m := range gen.On {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (gen *Generator) generate(ctx context.Context, now time.Time, counter Counter) (interface{}, error) {
+	switch gen.Type {
+	case TypeUUIDv4:
+		return NewUUIDv4()
+	case TypeUUIDv7:
+		return NewUUIDv7(now)
+	case TypeULID:
+		return NewULID(now)
+	case TypeKSUID:
+		return NewKSUID(now)
+	case TypeCounter:
+		if counter == nil {
+			return nil, fmt.Errorf("the connector cannot increment counters")
+		}
+		return counter.Next(ctx, gen.CounterTable, gen.CounterKey, gen.CounterName)
+	case TypeTTL:
+		// DynamoDB expects the expiration time in seconds since the epoch
+		return now.Add(gen.duration).Unix(), nil
+	default:
+		return formatTime(now, gen.Format), nil
+	}
+}
+
+// formatTime formats the timestamp, which is a number for the unix formats
+func formatTime(t time.Time, format string) interface{} {
+	switch strings.ToLower(format) {
+	case "", FormatRFC3339:
+		return t.Format(time.RFC3339)
+	case FormatRFC3339Nano:
+		return t.Format(time.RFC3339Nano)
+	case FormatUnix:
+		return t.Unix()
+	case FormatUnixMilli:
+		return t.UnixMilli()
+	case FormatDate:
+		return t.Format(time.DateOnly)
+	default:
+		return t.Format(format)
+	}
+}
@@ -0,0 +1,176 @@
+package generator
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// fakeCounter counts the increments of each counter
+type fakeCounter map[string]int64
+
+func (f fakeCounter) Next(_ context.Context, table, key, name string) (int64, error) {
+	f[table+"/"+key+"/"+name]++
+	return f[table+"/"+key+"/"+name], nil
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "30d", want: 30 * 24 * time.Hour},
+		{value: "90m", want: 90 * time.Minute},
+		{value: "xd", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	g := Generators{
+		{Target: "Seq", Type: "COUNTER", CounterTable: "counters", On: []string{"post", "put"}},
+		{Target: "ExpiresAt", Type: TypeTTL, Duration: "7d"},
+	}
+	if err := g.Compile(); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if g[0].CounterKey != DefaultCounterKey || g[0].CounterName != "Seq" || g[0].On[1] != "PUT" {
+		t.Errorf("Compile() = %+v, want the defaults of the counter", g[0])
+	}
+	if g[1].duration != 7*24*time.Hour || g[1].On[0] != "POST" {
+		t.Errorf("Compile() = %+v, want the duration and POST", g[1])
+	}
+
+	for _, invalid := range []*Generator{
+		{Type: TypeUUIDv4},
+		{Target: "ID", Type: "snowflake"},
+		{Target: "Seq", Type: TypeCounter},
+		{Target: "ExpiresAt", Type: TypeTTL, Duration: "later"},
+	} {
+		if err := (Generators{invalid}).Compile(); err == nil {
+			t.Errorf("Compile(%+v) error = nil, want an error", invalid)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	g := Generators{
+		{Target: "UserID", Type: TypeUUIDv7},
+		{Target: "Code", Type: TypeULID, KeepProvided: true},
+		{Target: "Seq", Type: TypeCounter, CounterTable: "counters"},
+		{Target: "UpdatedAt", Type: TypeTimestamp, Format: FormatUnix, On: []string{"POST", "PUT"}},
+		{Target: "Day", Type: TypeTimestamp, Format: FormatDate},
+		{Target: "ExpiresAt", Type: TypeTTL, Duration: "1d"},
+	}
+	if err := g.Compile(); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	counter := fakeCounter{}
+	record := map[string]interface{}{"UserID": "client", "Code": "kept"}
+
+	generated, err := g.Apply(context.Background(), "post", record, counter)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	if record["UserID"] == "client" || record["Code"] != "kept" {
+		t.Errorf("Apply() = %v, want UserID replaced and Code kept", record)
+	}
+	if _, ok := generated["Code"]; ok {
+		t.Errorf("Apply() generated the Code provided")
+	}
+	if record["Seq"] != int64(1) || counter["counters/Name/Seq"] != 1 {
+		t.Errorf("Apply() Seq = %v, want the counter incremented", record["Seq"])
+	}
+	if now := time.Now().Unix(); record["UpdatedAt"].(int64) > now || record["ExpiresAt"].(int64) < now+86399 {
+		t.Errorf("Apply() = %v, want the current time and the expiration in a day", record)
+	}
+	if !regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`).MatchString(record["Day"].(string)) {
+		t.Errorf("Apply() Day = %v, want a date", record["Day"])
+	}
+
+	record = map[string]interface{}{}
+	if generated, _ := g.Apply(context.Background(), "PUT", record, counter); len(generated) != 1 || record["UpdatedAt"] == nil {
+		t.Errorf("Apply() = %v, want only UpdatedAt on PUT", generated)
+	}
+
+	if _, err := g.Apply(context.Background(), "POST", map[string]interface{}{}, nil); err == nil {
+		t.Errorf("Apply() error = nil, want the counter missing")
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC)
+
+	tests := []struct {
+		format string
+		want   interface{}
+	}{
+		{format: "", want: "2024-01-02T03:04:05Z"},
+		{format: FormatRFC3339Nano, want: "2024-01-02T03:04:05.006Z"},
+		{format: FormatUnix, want: now.Unix()},
+		{format: FormatUnixMilli, want: now.UnixMilli()},
+		{format: FormatDate, want: "2024-01-02"},
+		{format: "2006-01-02 15:04", want: "2024-01-02 03:04"},
+	}
+
+	for _, tt := range tests {
+		if got := formatTime(now, tt.format); got != tt.want {
+			t.Errorf("formatTime(%q) = %v, want %v", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestIdentifiers(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		new     func() (string, error)
+		pattern string
+	}{
+		{name: "uuidv4", new: NewUUIDv4, pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{name: "uuidv7", new: func() (string, error) { return NewUUIDv7(now) }, pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{name: "ulid", new: func() (string, error) { return NewULID(now) }, pattern: `^[0-7][0-9A-HJKMNP-TV-Z]{25}$`},
+		{name: "ksuid", new: func() (string, error) { return NewKSUID(now) }, pattern: `^[0-9A-Za-z]{27}$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, err := tt.new()
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			second, _ := tt.new()
+
+			if !regexp.MustCompile(tt.pattern).MatchString(first) {
+				t.Errorf("%s doesn't match %s", first, tt.pattern)
+			}
+			if first == second {
+				t.Errorf("the identifiers must be unique, both are %s", first)
+			}
+		})
+	}
+}
+
+func TestIdentifiersSortByTime(t *testing.T) {
+	earlier, later := time.Now(), time.Now().Add(2*time.Second)
+
+	for name, generate := range map[string]func(time.Time) (string, error){"uuidv7": NewUUIDv7, "ulid": NewULID, "ksuid": NewKSUID} {
+		first, _ := generate(earlier)
+		second, _ := generate(later)
+		if first >= second {
+			t.Errorf("%s: %s must sort before %s", name, first, second)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"time"
+)
+
+// crockford is the alphabet of the ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// base62 is the alphabet of the KSUIDs
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ksuidEpoch is the epoch of the KSUID timestamps, 2014-05-13T16:53:20Z
+const ksuidEpoch = 1400000000
+
+// random fills b with random bytes
+func random(b []byte) error {
+	_, err := rand.Read(b)
+	return err
+}
+
+// NewUUIDv4 returns a random UUID (RFC 9562, version 4).
+func NewUUIDv4() (string, error) {
+	var id [16]byte
+	if err := random(id[:]); err != nil {
+		return "", err
+	}
+
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+
+	return formatUUID(id), nil
+}
+
+// NewUUIDv7 returns a UUID ordered by its creation time (RFC 9562, version 7).
+func NewUUIDv7(now time.Time) (string, error) {
+	var id [16]byte
+	if err := random(id[6:]); err != nil {
+		return "", err
+	}
+
+	ms := uint64(now.UnixMilli())
+	id[0], id[1], id[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+	id[3], id[4], id[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+	id[6] = (id[6] & 0x0f) | 0x70
+	id[8] = (id[8] & 0x3f) | 0x80
+
+	return formatUUID(id), nil
+}
+
+func formatUUID(id [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], id[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], id[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], id[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], id[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], id[10:])
+
+	return string(buf[:])
+}
+
+// NewULID returns a lexicographically sortable identifier: 48 bits of the creation time in
+// milliseconds and 80 random bits, encoded as 26 characters of the Crockford base32.
+func NewULID(now time.Time) (string, error) {
+	var id [16]byte
+	if err := random(id[6:]); err != nil {
+		return "", err
+	}
+
+	ms := uint64(now.UnixMilli())
+	id[0], id[1], id[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+	id[3], id[4], id[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+
+	// 128 bits are encoded in 26 characters of 5 bits, the first one carrying only 3 bits
+	hi, lo := binary.BigEndian.Uint64(id[:8]), binary.BigEndian.Uint64(id[8:])
+
+	var buf [26]byte
+	for i := 25; i >= 0; i-- {
+		buf[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+
+	return string(buf[:]), nil
+}
+
+// NewKSUID returns a K-Sortable Unique IDentifier: 32 bits of the creation time in seconds since
+// the KSUID epoch and 128 random bits, encoded as 27 characters of base62.
+func NewKSUID(now time.Time) (string, error) {
+	var id [20]byte
+	if err := random(id[4:]); err != nil {
+		return "", err
+	}
+
+	binary.BigEndian.PutUint32(id[:4], uint32(now.Unix()-ksuidEpoch))
+
+	n := new(big.Int).SetBytes(id[:])
+	base, rem := big.NewInt(62), new(big.Int)
+
+	var buf [27]byte
+	for i := 26; i >= 0; i-- {
+		n.DivMod(n, base, rem)
+		buf[i] = base62[rem.Int64()]
+	}
+
+	return string(buf[:]), nil
+}
@@ -5,15 +5,19 @@ go 1.21.5
 require (
 	github.com/aws/aws-lambda-go v1.45.0
 	github.com/aws/aws-sdk-go v1.50.3
-	github.com/linkedin/goavro v2.1.0+incompatible
+	github.com/google/cel-go v0.21.0
 	github.com/linkedin/goavro/v2 v2.12.0
-	github.com/xeipuuv/gojsonschema v1.2.0
+	google.golang.org/protobuf v1.34.2
 	gopkg.in/yaml.v2 v2.2.8
 )
 
 require (
+	github.com/antlr4-go/antlr/v4 v4.13.0 // indirect
 	github.com/golang/snappy v0.0.4 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
-	github.com/xeipuuv/gojsonpointer v0.0.0-20180127040702-4e3ac2762d5f // indirect
-	github.com/xeipuuv/gojsonreference v0.0.0-20180127040603-bd5ef7bd5415 // indirect
+	github.com/stoewer/go-strcase v1.2.0 // indirect
+	golang.org/x/exp v0.0.0-20230515195305-f3d0a9c9a5cc // indirect
+	golang.org/x/text v0.13.0 // indirect
+	google.golang.org/genproto/googleapis/api v0.0.0-20230803162519-f966b187b2e5 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20230803162519-f966b187b2e5 // indirect
 )
@@ -0,0 +1,297 @@
+// Package idempotency keeps the writes retried by the clients, like the requests sent again by an
+// API client or the messages redelivered by a queue, from being executed twice, following the
+// 'Idempotency' section of the configuration.
+//
+// The first request carrying a key stores a record in progress on the idempotency table. When it
+// finishes, the record is completed with the response, which is replayed to the requests that
+// carry the same key until the record expires.
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/raywall/aws-lowcode-lambda-go/generator"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+)
+
+// Defaults of the settings.
+const (
+	DefaultHeader       = "Idempotency-Key"
+	DefaultKeyAttribute = "IdempotencyKey"
+	DefaultExpiration   = 24 * time.Hour
+	DefaultLockTimeout  = 5 * time.Minute
+)
+
+// MaxKeyLength is the length limit of the idempotency keys.
+const MaxKeyLength = 255
+
+// ReplayedHeader is added to the responses replayed from the idempotency table.
+const ReplayedHeader = "Idempotent-Replayed"
+
+// States of the records.
+const (
+	StatusInProgress = "IN_PROGRESS"
+	StatusCompleted  = "COMPLETED"
+)
+
+var (
+	// ErrInProgress is returned when a request with the same key is still being executed
+	ErrInProgress = errors.New("a request with the same idempotency key is in progress")
+	// ErrPayloadMismatch is returned when the key was used by a request with another payload
+	ErrPayloadMismatch = errors.New("the idempotency key was used by a request with another payload")
+	// ErrLockLost is returned when the record in progress was acquired by another request, after
+	// its lock expired, or completed by it
+	ErrLockLost = errors.New("the idempotency key was acquired by another request")
+)
+
+// Store is implemented by the connectors able to keep the records of the idempotency table.
+type Store interface {
+	// Acquire stores the record in progress, unless the table holds a record with the same key that
+	// didn't expire, which is returned instead
+	Acquire(ctx context.Context, settings *Settings, record *Record) (*Record, error)
+	// Complete stores the record completed with the response of its request, when the table still
+	// holds the record in progress acquired by the request, or returns ErrLockLost
+	Complete(ctx context.Context, settings *Settings, record *Record) error
+	// Release removes the record in progress acquired by the request, so the request can be
+	// retried. The records acquired or completed by other requests are kept.
+	Release(ctx context.Context, settings *Settings, record *Record) error
+}
+
+type (
+	// Settings is the 'Idempotency' section of the configuration. The API Gateway requests of the
+	// methods listed in 'Methods' are identified by the 'Header' they carry, and the records received
+	// from queues and topics by their 'RecordField', when it is informed.
+	Settings struct {
+		// TableName is the idempotency table, whose partition key is 'KeyAttribute'
+		TableName    string   `yaml:"TableName"`
+		KeyAttribute string   `yaml:"KeyAttribute"`
+		Header       string   `yaml:"Header"`
+		RecordField  string   `yaml:"RecordField"`
+		Methods      []string `yaml:"Methods"`
+		// Expiration is how long the responses are replayed, like '24h' or '7d'
+		Expiration string `yaml:"Expiration"`
+		// LockTimeout is how long a record in progress blocks the retries, the time left to the
+		// invocation by default
+		LockTimeout string `yaml:"LockTimeout"`
+
+		expiration  time.Duration
+		lockTimeout time.Duration
+	}
+
+	// Record is an item of the idempotency table.
+	Record struct {
+		Key         string `dynamodbav:"-"`
+		Status      string `dynamodbav:"Status"`
+		PayloadHash string `dynamodbav:"PayloadHash"`
+		// LockToken identifies the request that acquired the record in progress
+		LockToken string `dynamodbav:"LockToken,omitempty"`
+		// Response is the JSON of the response of a completed request
+		Response string `dynamodbav:"Response,omitempty"`
+		// ExpiresAt is the expiration of the record, in Unix seconds, usable as the TTL of the table
+		ExpiresAt     int64 `dynamodbav:"ExpiresAt"`
+		LockExpiresAt int64 `dynamodbav:"LockExpiresAt"`
+	}
+)
+
+// Compile validates the settings and fills their defaults. A nil section is valid and disables
+// the idempotency keys.
+func (s *Settings) Compile() error {
+	if s == nil {
+		return nil
+	}
+
+	if s.TableName == "" {
+		return fmt.Errorf("idempotency requires a TableName")
+	}
+	if s.KeyAttribute == "" {
+		s.KeyAttribute = DefaultKeyAttribute
+	}
+	if s.Header == "" {
+		s.Header = DefaultHeader
+	}
+	if len(s.Methods) == 0 {
+		s.Methods = []string{"POST", "PUT", "PATCH", "DELETE"}
+	}
+	for i, method := range s.Methods {
+		s.Methods[i] = strings.ToUpper(method)
+	}
+
+	s.expiration = DefaultExpiration
+	if s.Expiration != "" {
+		var err error
+		if s.expiration, err = generator.ParseDuration(s.Expiration); err != nil || s.expiration <= 0 {
+			return fmt.Errorf("invalid idempotency expiration %q", s.Expiration)
+		}
+	}
+
+	if s.LockTimeout != "" {
+		var err error
+		if s.lockTimeout, err = generator.ParseDuration(s.LockTimeout); err != nil || s.lockTimeout <= 0 {
+			return fmt.Errorf("invalid idempotency lock timeout %q", s.LockTimeout)
+		}
+	}
+
+	return nil
+}
+
+// Guards reports whether the requests of the method are identified by idempotency keys.
+func (s *Settings) Guards(method string) bool {
+	if s == nil {
+		return false
+	}
+
+	for _, m := range s.Methods {
+		if m == strings.ToUpper(method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Hash returns the hash of the parts of a payload, compared by the requests that reuse a key.
+func Hash(parts ...[]byte) string {
+	h := sha256.New()
+	for _, part := range parts {
+		fmt.Fprintf(h, "%d:", len(part))
+		h.Write(part)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Guard holds the record acquired by a request, which is completed or released when it finishes.
+type Guard struct {
+	store    Store
+	settings *Settings
+	record   *Record
+}
+
+// Begin acquires the key for the request whose payload has the hash received. The response of the
+// request that completed the key before is returned instead of the guard, and ErrInProgress or
+// ErrPayloadMismatch when the key can't be used.
+func Begin(ctx context.Context, store Store, s *Settings, key, hash string) (*Guard, *lowcodeattribute.ExecutionResponse, error) {
+	if len(key) > MaxKeyLength {
+		return nil, nil, lowcodeattribute.Errorf(lowcodeattribute.KindValidation, "the idempotency key is longer than %d characters", MaxKeyLength)
+	}
+
+	token, err := lockToken()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed acquiring idempotency key: %w", err)
+	}
+
+	now := time.Now()
+	record := &Record{
+		Key:           key,
+		Status:        StatusInProgress,
+		PayloadHash:   hash,
+		LockToken:     token,
+		ExpiresAt:     now.Add(s.expiration).Unix(),
+		LockExpiresAt: now.Add(s.lock(ctx, now)).Unix(),
+	}
+
+	existing, err := store.Acquire(ctx, s, record)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed acquiring idempotency key: %w", err)
+	}
+	if existing == nil {
+		return &Guard{store: store, settings: s, record: record}, nil, nil
+	}
+
+	switch {
+	case existing.PayloadHash != hash:
+		return nil, nil, lowcodeattribute.NewError(lowcodeattribute.KindValidation, "", ErrPayloadMismatch)
+	case existing.Status != StatusCompleted:
+		return nil, nil, lowcodeattribute.NewError(lowcodeattribute.KindConflict, "", ErrInProgress)
+	}
+
+	response, err := decodeResponse(existing.Response)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed replaying idempotency key: %w", err)
+	}
+	response.SetHeader(ReplayedHeader, "true")
+
+	return nil, response, nil
+}
+
+// lockToken returns a random token identifying the request that acquires a record
+func lockToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(token), nil
+}
+
+// lock returns how long the record in progress blocks the retries
+func (s *Settings) lock(ctx context.Context, now time.Time) time.Duration {
+	if s.lockTimeout > 0 {
+		return s.lockTimeout
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.After(now) {
+		return deadline.Sub(now)
+	}
+
+	return DefaultLockTimeout
+}
+
+// Finish completes the record with the response of the request. The failures that may succeed
+// when retried, like throttling, release the record instead, so the retries are executed.
+// ErrLockLost is returned when the lock of the record expired and another request acquired it.
+func (g *Guard) Finish(ctx context.Context, response *lowcodeattribute.ExecutionResponse) error {
+	if response.StatusCode >= 400 && lowcodeattribute.KindOfStatus(response.StatusCode).Retryable() {
+		return g.store.Release(ctx, g.settings, g.record)
+	}
+
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	g.record.Status = StatusCompleted
+	g.record.Response = string(encoded)
+
+	return g.store.Complete(ctx, g.settings, g.record)
+}
+
+// decodeResponse decodes the response stored by a completed record. The failures are decoded
+// as problem details.
+func decodeResponse(data string) (*lowcodeattribute.ExecutionResponse, error) {
+	var stored struct {
+		StatusCode int                    `json:"statusCode"`
+		Message    json.RawMessage        `json:"message"`
+		Headers    map[string]string      `json:"headers"`
+		Meta       map[string]interface{} `json:"meta"`
+	}
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, err
+	}
+
+	response := &lowcodeattribute.ExecutionResponse{
+		StatusCode: stored.StatusCode,
+		Headers:    stored.Headers,
+		Meta:       stored.Meta,
+	}
+
+	if len(stored.Message) > 0 && string(stored.Message) != "null" {
+		var message interface{}
+		if stored.StatusCode >= 300 {
+			message = &lowcodeattribute.Problem{}
+		}
+		if err := json.Unmarshal(stored.Message, &message); err != nil {
+			return nil, err
+		}
+		response.Message = message
+	}
+
+	return response, nil
+}
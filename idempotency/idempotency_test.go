@@ -0,0 +1,224 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+)
+
+// memoryStore keeps the records of the idempotency table in memory
+type memoryStore struct {
+	records map[string]*Record
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: map[string]*Record{}}
+}
+
+func (m *memoryStore) Acquire(_ context.Context, _ *Settings, record *Record) (*Record, error) {
+	if existing, ok := m.records[record.Key]; ok && existing.ExpiresAt > time.Now().Unix() {
+		if existing.Status == StatusCompleted || existing.LockExpiresAt > time.Now().Unix() {
+			return existing, nil
+		}
+	}
+
+	copied := *record
+	m.records[record.Key] = &copied
+	return nil, nil
+}
+
+func (m *memoryStore) Complete(_ context.Context, _ *Settings, record *Record) error {
+	if existing, ok := m.records[record.Key]; !ok || existing.Status != StatusInProgress || existing.LockToken != record.LockToken {
+		return ErrLockLost
+	}
+
+	copied := *record
+	m.records[record.Key] = &copied
+	return nil
+}
+
+func (m *memoryStore) Release(_ context.Context, _ *Settings, record *Record) error {
+	if existing, ok := m.records[record.Key]; ok && existing.Status == StatusInProgress && existing.LockToken == record.LockToken {
+		delete(m.records, record.Key)
+	}
+	return nil
+}
+
+func mustCompile(t *testing.T, s *Settings) *Settings {
+	t.Helper()
+
+	if err := s.Compile(); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	return s
+}
+
+func TestCompile(t *testing.T) {
+	s := mustCompile(t, &Settings{TableName: "idempotency", Methods: []string{"post"}, Expiration: "7d", LockTimeout: "30s"})
+
+	if s.KeyAttribute != DefaultKeyAttribute || s.Header != DefaultHeader {
+		t.Errorf("Compile() = %+v, want the defaults", s)
+	}
+	if s.expiration != 7*24*time.Hour || s.lockTimeout != 30*time.Second {
+		t.Errorf("Compile() expiration = %v, lock timeout = %v, want 168h and 30s", s.expiration, s.lockTimeout)
+	}
+	if !s.Guards("POST") || s.Guards("PUT") {
+		t.Errorf("Guards() doesn't follow the methods %v", s.Methods)
+	}
+
+	for _, invalid := range []*Settings{
+		{},
+		{TableName: "idempotency", Expiration: "soon"},
+		{TableName: "idempotency", Expiration: "-1h"},
+		{TableName: "idempotency", LockTimeout: "0s"},
+	} {
+		if err := invalid.Compile(); err == nil {
+			t.Errorf("Compile(%+v) error = nil, want an error", invalid)
+		}
+	}
+
+	var disabled *Settings
+	if err := disabled.Compile(); err != nil || disabled.Guards("POST") {
+		t.Errorf("a nil section must be valid and guard nothing")
+	}
+}
+
+func TestHash(t *testing.T) {
+	if Hash([]byte("ab"), []byte("c")) == Hash([]byte("a"), []byte("bc")) {
+		t.Errorf("Hash() must tell the parts apart")
+	}
+	if Hash([]byte("a")) != Hash([]byte("a")) {
+		t.Errorf("Hash() must be deterministic")
+	}
+}
+
+func TestBeginReplaysTheCompletedResponse(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	s := mustCompile(t, &Settings{TableName: "idempotency"})
+
+	guard, replayed, err := Begin(ctx, store, s, "key-1", "hash")
+	if err != nil || guard == nil || replayed != nil {
+		t.Fatalf("Begin() = %v, %v, %v, want the guard", guard, replayed, err)
+	}
+
+	// the retry arrives while the first request runs
+	if _, _, err := Begin(ctx, store, s, "key-1", "hash"); !errors.Is(err, ErrInProgress) {
+		t.Errorf("Begin() error = %v, want ErrInProgress", err)
+	}
+
+	response := &lowcodeattribute.ExecutionResponse{StatusCode: http.StatusCreated, Message: map[string]interface{}{"UserID": "42"}}
+	if err := guard.Finish(ctx, response); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	guard, replayed, err = Begin(ctx, store, s, "key-1", "hash")
+	if err != nil || guard != nil {
+		t.Fatalf("Begin() = %v, %v, want the replayed response", guard, err)
+	}
+	if replayed.StatusCode != http.StatusCreated || replayed.Headers[ReplayedHeader] != "true" ||
+		replayed.Message.(map[string]interface{})["UserID"] != "42" {
+		t.Errorf("Begin() replayed = %+v, want the response stored", replayed)
+	}
+
+	if _, _, err := Begin(ctx, store, s, "key-1", "other"); !errors.Is(err, ErrPayloadMismatch) ||
+		lowcodeattribute.KindOf(err) != lowcodeattribute.KindValidation {
+		t.Errorf("Begin() error = %v, want ErrPayloadMismatch", err)
+	}
+}
+
+func TestFinishReplaysTheFailures(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	s := mustCompile(t, &Settings{TableName: "idempotency"})
+
+	guard, _, _ := Begin(ctx, store, s, "key-1", "hash")
+	failure := lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindConflict, "the user exists"))
+	if err := guard.Finish(ctx, failure); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	_, replayed, err := Begin(ctx, store, s, "key-1", "hash")
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if problem, ok := replayed.Message.(*lowcodeattribute.Problem); !ok || problem.Detail != "the user exists" {
+		t.Errorf("Begin() replayed = %#v, want the problem details", replayed.Message)
+	}
+}
+
+func TestFinishReleasesTheRetryableFailures(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	s := mustCompile(t, &Settings{TableName: "idempotency"})
+
+	guard, _, _ := Begin(ctx, store, s, "key-1", "hash")
+	throttled := lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindThrottled, "slow down"))
+	if err := guard.Finish(ctx, throttled); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	if guard, _, err := Begin(ctx, store, s, "key-1", "hash"); err != nil || guard == nil {
+		t.Errorf("Begin() = %v, %v, want the key acquired again", guard, err)
+	}
+}
+
+func TestBeginRejectsLongKeys(t *testing.T) {
+	s := mustCompile(t, &Settings{TableName: "idempotency"})
+
+	_, _, err := Begin(context.Background(), newMemoryStore(), s, strings.Repeat("k", MaxKeyLength+1), "hash")
+	if lowcodeattribute.KindOf(err) != lowcodeattribute.KindValidation {
+		t.Errorf("Begin() error = %v, want a validation error", err)
+	}
+}
+
+func TestLockFollowsTheDeadline(t *testing.T) {
+	s := mustCompile(t, &Settings{TableName: "idempotency"})
+	now := time.Now()
+
+	if got := s.lock(context.Background(), now); got != DefaultLockTimeout {
+		t.Errorf("lock() = %v, want %v", got, DefaultLockTimeout)
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(time.Minute))
+	defer cancel()
+	if got := s.lock(ctx, now); got != time.Minute {
+		t.Errorf("lock() = %v, want the time left to the invocation", got)
+	}
+}
+
+func TestFinishKeepsTheRecordOfAnotherRequest(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	s := mustCompile(t, &Settings{TableName: "idempotency"})
+
+	first, _, _ := Begin(ctx, store, s, "key-1", "hash")
+
+	// the lock of the first request expires and the retry acquires the key
+	store.records["key-1"].LockExpiresAt = 0
+	second, _, err := Begin(ctx, store, s, "key-1", "hash")
+	if err != nil || second == nil {
+		t.Fatalf("Begin() = %v, %v, want the key acquired again", second, err)
+	}
+
+	if err := first.Finish(ctx, &lowcodeattribute.ExecutionResponse{StatusCode: http.StatusCreated}); !errors.Is(err, ErrLockLost) {
+		t.Errorf("Finish() error = %v, want ErrLockLost", err)
+	}
+	throttled := lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindThrottled, "slow down"))
+	if err := first.Finish(ctx, throttled); err != nil {
+		t.Errorf("Finish() error = %v, want the record of the retry kept", err)
+	}
+	if record := store.records["key-1"]; record == nil || record.LockToken != second.record.LockToken {
+		t.Fatalf("the table holds %+v, want the record of the retry", record)
+	}
+
+	if err := second.Finish(ctx, &lowcodeattribute.ExecutionResponse{StatusCode: http.StatusOK}); err != nil {
+		t.Errorf("Finish() error = %v", err)
+	}
+}
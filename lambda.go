@@ -2,71 +2,249 @@ package lowcode
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"sync/atomic"
 
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/connector"
 	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
 	"github.com/raywall/aws-lowcode-lambda-go/receiver"
 )
 
 type LowcodeFunction struct {
-	Settings config.Config
-	Client   *dynamodb.DynamoDB
-	Debug    bool
+	Client dynamodbiface.DynamoDBAPI
+	Debug  bool
+
+	state      atomic.Pointer[state]
+	reloader   *reloader
+	logger     *log.Logger
+	receivers  []receiver.Receiver
+	connectors map[string]connector.Factory
 }
 
-func (function *LowcodeFunction) NewWithConfig(filePath string) error {
-	conf := &config.Global
+// state is the configuration used by the function and the connector created from it, which are
+// replaced together when the configuration is reloaded
+type state struct {
+	settings *config.Config
+	conn     connector.Connector
+}
 
-	awsConfig := aws.Config{Region: aws.String(os.Getenv("AWS_REGION"))}
-	awsConfig.Endpoint = aws.String(os.Getenv("DYNAMO_ENDPOINT"))
+// New creates a LowcodeFunction using the options received. A configuration source is required,
+// while the remaining options fall back to defaults: an AWS session created from the environment,
+// the standard logger and the built-in receivers and connectors.
+func New(opts ...Option) (*LowcodeFunction, error) {
+	o := &options{
+		connectors: make(map[string]connector.Factory),
+	}
 
-	sess, _ := session.NewSession(&awsConfig)
-	function.Client = dynamodb.New(sess)
-	function.Debug = false
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
 
-	// read a configuration file content
-	data, err := os.ReadFile(filePath)
+	// load configuration
+	settings, err := o.loadSettings()
 	if err != nil {
-		log.Fatalf("failed reading lowcode role file: %v", err)
+		return nil, err
 	}
 
-	// load configuration
-	err = conf.Load(data)
+	client, err := o.dynamoDBClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := o.connectors["DynamoDB"]; !ok {
+		o.connectors["DynamoDB"] = connector.DynamoDBFactory(client)
+	}
+	if _, ok := o.connectors["RESTfulApi"]; !ok {
+		o.connectors["RESTfulApi"] = connector.RESTfulApiFactory(http.DefaultClient)
+	}
+
+	if o.logger == nil {
+		o.logger = log.Default()
+	}
+
+	function := &LowcodeFunction{
+		Client:     client,
+		Debug:      o.debug,
+		logger:     o.logger,
+		receivers:  o.receivers,
+		connectors: o.connectors,
+	}
+
+	conn, err := function.connector(settings)
 	if err != nil {
-		log.Fatalf("failed loading settings: %v", err)
+		return nil, err
+	}
+	function.state.Store(&state{settings: settings, conn: conn})
+
+	if o.reload > 0 {
+		function.reloader, err = newReloader(o, o.reload, settings)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return function, nil
+}
+
+// NewWithConfig loads the configuration file found at filePath into the function and creates the
+// DynamoDB client used by it, whose endpoint is read from the DYNAMO_ENDPOINT variable. Any failure
+// is returned to the caller, which decides how to handle it.
+//
+// Deprecated: use New with WithConfigFile and WithDynamoDBEndpoint.
+func (function *LowcodeFunction) NewWithConfig(filePath string) error {
+	created, err := New(
+		WithConfigFile(filePath),
+		WithDynamoDBEndpoint(os.Getenv("DYNAMO_ENDPOINT")),
+		WithDebug(function.Debug),
+	)
+	if err != nil {
+		return err
+	}
+
+	function.Client = created.Client
+	function.reloader = created.reloader
+	function.logger = created.logger
+	function.receivers = created.receivers
+	function.connectors = created.connectors
+	function.state.Store(created.state.Load())
+
+	return nil
+}
+
+// Settings returns the configuration currently used by the function
+func (function *LowcodeFunction) Settings() *config.Config {
+	if current := function.state.Load(); current != nil {
+		return current.settings
 	}
 
 	return nil
 }
 
+// connector creates the connector indicated by the 'ResourceType' of the 'Connector' resource
+func (function *LowcodeFunction) connector(conf *config.Config) (connector.Connector, error) {
+	resourceType := conf.Resources.Connector.ResourceType
+
+	factory, ok := function.connectors[resourceType]
+	if !ok {
+		return nil, fmt.Errorf("connector unsupported: %s", resourceType)
+	}
+
+	return factory(conf)
+}
+
+func (function *LowcodeFunction) debugf(format string, v ...interface{}) {
+	if function.Debug {
+		function.logger.Printf(format, v...)
+	}
+}
+
 func (function *LowcodeFunction) HandleRequest(ctx context.Context, evt interface{}) (interface{}, error) {
-	var event interface{} = evt
+	if function.reloader != nil {
+		function.reloader.reload(function)
+	}
+
+	current := function.state.Load()
+	if current == nil {
+		return "", errors.New("the function was not created by New")
+	}
 
-	log.Printf("received type: %T", event)
+	event := decodeEvent(evt)
+	conf, conn := current.settings, current.conn
 
-	if _, sam := os.LookupEnv("DYNAMO_ENDPOINT"); sam {
-		obj := events.APIGatewayProxyRequest{}
-		lowcodeattribute.SerializeLocalRequest(evt.(map[string]interface{}), &obj)
-		event = obj
+	function.debugf("received type: %T", event)
+
+	for _, r := range function.receivers {
+		response, handled, err := r.Handle(ctx, event, conf, conn)
+		if handled {
+			return response, err
+		}
 	}
 
 	switch e := event.(type) {
 	case events.APIGatewayProxyRequest:
-		return receiver.HandleAPIGatewayEvent(e, function.Client).ToGatewayResponse()
+		return receiver.GatewayResponse(e, conf, receiver.HandleAPIGatewayEvent(ctx, e, conf, conn))
 	case events.SNSEvent:
-		return receiver.HandleSNSEvent(e, function.Client), nil
+		return nil, receiver.HandleSNSEvent(ctx, e, conf, conn)
 	case events.SQSEvent:
-		return receiver.HandleSQSEvent(e, function.Client), nil
+		return receiver.HandleSQSEvent(ctx, e, conf, conn), nil
 	case events.DynamoDBEvent:
-		return receiver.HandleDynamoDBEvent(e, function.Client), nil
+		return receiver.HandleDynamoDBEvent(ctx, e, conf, conn), nil
 	default:
 		return "", fmt.Errorf("event unsupported: %T", e)
 	}
 }
+
+// decodeEvent converts the generic events received by the handler, like the ones sent by
+// 'sam local', into the typed event of their source. Events of unknown sources are returned as is.
+func decodeEvent(evt interface{}) interface{} {
+	raw, ok := evt.(map[string]interface{})
+	if !ok {
+		return evt
+	}
+
+	var event interface{}
+
+	switch eventSource(raw) {
+	case "apigateway":
+		event = &events.APIGatewayProxyRequest{}
+	case "aws:sns":
+		event = &events.SNSEvent{}
+	case "aws:sqs":
+		event = &events.SQSEvent{}
+	case "aws:dynamodb":
+		event = &events.DynamoDBEvent{}
+	default:
+		return evt
+	}
+
+	if err := lowcodeattribute.SerializeLocalRequest(raw, event); err != nil {
+		return evt
+	}
+
+	switch e := event.(type) {
+	case *events.APIGatewayProxyRequest:
+		return *e
+	case *events.SNSEvent:
+		return *e
+	case *events.SQSEvent:
+		return *e
+	case *events.DynamoDBEvent:
+		return *e
+	}
+
+	return evt
+}
+
+// eventSource identifies the source of a generic event using its attributes
+func eventSource(raw map[string]interface{}) string {
+	if _, ok := raw["httpMethod"]; ok {
+		return "apigateway"
+	}
+
+	records, ok := raw["Records"].([]interface{})
+	if !ok || len(records) == 0 {
+		return ""
+	}
+
+	record, ok := records[0].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	for _, key := range []string{"eventSource", "EventSource"} {
+		if source, ok := record[key].(string); ok {
+			return source
+		}
+	}
+
+	return ""
+}
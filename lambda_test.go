@@ -0,0 +1,240 @@
+package lowcode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/connector"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/receiver"
+)
+
+const memoryConfig = `Resources:
+  Receiver:
+    ResourceType: ApiGateway
+    ObjectPathSchema: user.json
+  Connector:
+    ResourceType: Memory
+    Properties:
+      Keys:
+        UserID: EQ
+`
+
+const dynamoDBConfig = `Resources:
+  Receiver:
+    ResourceType: ApiGateway
+  Connector:
+    ResourceType: DynamoDB
+    Properties:
+      TableName: users
+      Keys:
+        UserID: EQ
+`
+
+// memoryConnector answers the reads with the user of the key received
+type memoryConnector struct {
+	conf *config.Config
+}
+
+func (c *memoryConnector) Create(_ context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	return &lowcodeattribute.ExecutionResponse{StatusCode: http.StatusCreated, Message: data}
+}
+
+func (c *memoryConnector) Read(_ context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	return &lowcodeattribute.ExecutionResponse{StatusCode: http.StatusOK, Message: data}
+}
+
+func (c *memoryConnector) Update(_ context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	return &lowcodeattribute.ExecutionResponse{StatusCode: http.StatusOK, Message: data}
+}
+
+func (c *memoryConnector) Delete(_ context.Context, _ interface{}) *lowcodeattribute.ExecutionResponse {
+	return &lowcodeattribute.ExecutionResponse{StatusCode: http.StatusNoContent}
+}
+
+// memoryFactory creates memory connectors, counting them
+type memoryFactory struct {
+	mu      sync.Mutex
+	created int
+}
+
+func (f *memoryFactory) create(conf *config.Config) (connector.Connector, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.created++
+	return &memoryConnector{conf: conf}, nil
+}
+
+func (f *memoryFactory) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.created
+}
+
+const userSchema = `{
+  "type": "object",
+  "properties": {
+    "UserID": { "type": "string" },
+    "Name": { "type": "string" }
+  }
+}`
+
+func memorySource(document string) *config.MemorySource {
+	return config.NewMemorySource(map[string][]byte{
+		"config.yaml": []byte(document),
+		"user.json":   []byte(userSchema),
+	})
+}
+
+func TestNewRefusesInvalidOptions(t *testing.T) {
+	source := WithConfigSource(memorySource(memoryConfig), "config.yaml")
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "no configuration", opts: nil},
+		{name: "two configurations", opts: []Option{source, WithConfigFile("config.yaml")}},
+		{name: "nil configuration source", opts: []Option{WithConfigSource(nil, "config.yaml")}},
+		{name: "nil schema source", opts: []Option{source, WithSchemaSource(nil)}},
+		{name: "nil session", opts: []Option{source, WithSession(nil)}},
+		{name: "nil client", opts: []Option{source, WithDynamoDBClient(nil)}},
+		{name: "nil logger", opts: []Option{source, WithLogger(nil)}},
+		{name: "nil receiver", opts: []Option{source, WithReceiver(nil)}},
+		{name: "nil connector", opts: []Option{source, WithConnector("Memory", nil)}},
+		{name: "hot reload without interval", opts: []Option{source, WithHotReload(0)}},
+		{name: "hot reload without source", opts: []Option{
+			WithConfigBytes([]byte(dynamoDBConfig)), WithDynamoDBClient(&dynamodb.DynamoDB{}), WithHotReload(1),
+		}},
+		{name: "missing file", opts: []Option{WithConfigSource(memorySource(memoryConfig), "other.yaml")}},
+		{name: "invalid configuration", opts: []Option{WithConfigBytes([]byte("Resources: ["))}},
+		{name: "unsupported connector", opts: []Option{source, WithDynamoDBClient(&dynamodb.DynamoDB{})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if function, err := New(tt.opts...); err == nil {
+				t.Errorf("New() = %v, want an error", function)
+			}
+		})
+	}
+}
+
+func TestNewCreatesTheConnectorOnce(t *testing.T) {
+	factory := &memoryFactory{}
+
+	function, err := New(
+		WithConfigSource(memorySource(memoryConfig), "config.yaml"),
+		WithConnector("Memory", factory.create),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	event := events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/users/42", PathParameters: map[string]string{"UserID": "42"}}
+	for i := 0; i < 3; i++ {
+		response, err := function.HandleRequest(context.Background(), event)
+		if err != nil {
+			t.Fatalf("HandleRequest() error = %v", err)
+		}
+		if gateway := response.(events.APIGatewayProxyResponse); gateway.StatusCode != http.StatusOK {
+			t.Errorf("HandleRequest() = %d %s, want 200", gateway.StatusCode, gateway.Body)
+		}
+	}
+
+	if got := factory.count(); got != 1 {
+		t.Errorf("the factory created %d connectors, want 1", got)
+	}
+	if function.Settings().Resources.Connector.ResourceType != "Memory" {
+		t.Errorf("Settings() = %s, want the configuration loaded", function.Settings().Resources.Connector.ResourceType)
+	}
+}
+
+func TestNewWiresTheDynamoDBClient(t *testing.T) {
+	client := &dynamodb.DynamoDB{}
+
+	function, err := New(WithConfigBytes([]byte(dynamoDBConfig)), WithDynamoDBClient(client))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	conn, ok := function.state.Load().conn.(*connector.DynamoDB)
+	if function.Client != client || !ok || conn.Client != client {
+		t.Errorf("New() client = %v, connector %T, want the client informed", function.Client, function.state.Load().conn)
+	}
+}
+
+func TestNewConsultsTheReceiversFirst(t *testing.T) {
+	var logged bytes.Buffer
+
+	custom := receiver.ReceiverFunc(func(_ context.Context, event interface{}, conf *config.Config, conn connector.Connector) (interface{}, bool, error) {
+		name, ok := event.(string)
+		if !ok {
+			return nil, false, nil
+		}
+		return conn.Read(context.Background(), name).Message, true, nil
+	})
+
+	function, err := New(
+		WithConfigSource(memorySource(memoryConfig), "config.yaml"),
+		WithConnector("Memory", (&memoryFactory{}).create),
+		WithReceiver(custom),
+		WithLogger(log.New(&logged, "", 0)),
+		WithDebug(true),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	response, err := function.HandleRequest(context.Background(), "ana")
+	if err != nil || response != "ana" {
+		t.Errorf("HandleRequest() = %v, %v, want the response of the receiver", response, err)
+	}
+	if logged.Len() == 0 {
+		t.Errorf("HandleRequest() logged nothing, want the debug messages")
+	}
+
+	if _, err := function.HandleRequest(context.Background(), 42); err == nil {
+		t.Errorf("HandleRequest() error = nil, want the event unsupported")
+	}
+}
+
+func TestNewWithConfig(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("DYNAMO_ENDPOINT", "http://localhost:8000")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(dynamoDBConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	function := &LowcodeFunction{}
+	if err := function.NewWithConfig(path); err != nil {
+		t.Fatalf("NewWithConfig() error = %v", err)
+	}
+	if function.Client == nil || function.Settings() == nil || function.Settings().Resources.Connector.Properties.TableName != "users" {
+		t.Errorf("NewWithConfig() = %+v, want the configuration and the client", function)
+	}
+
+	if err := (&LowcodeFunction{}).NewWithConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Errorf("NewWithConfig() error = nil, want the file missing")
+	}
+}
+
+func TestHandleRequestRequiresNew(t *testing.T) {
+	if _, err := (&LowcodeFunction{}).HandleRequest(context.Background(), events.SQSEvent{}); err == nil || errors.Is(err, context.Canceled) {
+		t.Errorf("HandleRequest() error = %v, want the function refused", err)
+	}
+}
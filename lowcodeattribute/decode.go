@@ -1,26 +1,34 @@
 package lowcodeattribute
 
 import (
-	"os"
+	"fmt"
 
-	goavro "github.com/linkedin/goavro/v2"
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
 )
 
-func DeserializeAvro(avroData []byte, avroSchemaPath string) (map[string]interface{}, error) {
-	avroSchema, err := os.ReadFile(avroSchemaPath)
+// DeserializeAvro decodes data in Avro's textual JSON encoding into the native map described by the
+// schema received.
+func DeserializeAvro(avroData []byte, s *schema.Schema) (map[string]interface{}, error) {
+	codec, err := s.Codec()
 	if err != nil {
 		return nil, err
 	}
 
-	codec, err := goavro.NewCodec(string(avroSchema))
+	native, _, err := codec.NativeFromTextual(avroData)
 	if err != nil {
 		return nil, err
 	}
 
-	native, _, err := codec.NativeFromTextual(avroData)
+	// unwraps the union values and types the data as the other formats
+	value, err := s.Coerce(native)
 	if err != nil {
 		return nil, err
 	}
 
-	return native.(map[string]interface{}), nil
+	data, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("avro data is not a record: %T", value)
+	}
+
+	return data, nil
 }
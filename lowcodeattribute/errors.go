@@ -0,0 +1,269 @@
+package lowcodeattribute
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+// ContentTypeProblem is the content type of the RFC 7807 problem details.
+const ContentTypeProblem = "application/problem+json"
+
+// ProblemTypePrefix prefixes the kind of the errors to build the type of their problem details.
+const ProblemTypePrefix = "urn:lowcode:problem:"
+
+// Kind classifies the errors by their cause, deciding the status of the response and whether the
+// operation can be retried.
+type Kind string
+
+const (
+	// KindValidation identifies requests that are invalid or don't match the schema
+	KindValidation Kind = "validation"
+	// KindNotFound identifies requests for items or resources that don't exist
+	KindNotFound Kind = "not_found"
+	// KindConflict identifies requests refused by the current state of the item, like a condition
+	KindConflict Kind = "conflict"
+	// KindForbidden identifies requests the function is not allowed to execute
+	KindForbidden Kind = "forbidden"
+	// KindThrottled identifies requests refused by the capacity or rate limits of a service
+	KindThrottled Kind = "throttled"
+	// KindUpstream identifies failures of the services the function depends on
+	KindUpstream Kind = "upstream"
+	// KindInternal identifies the other failures of the function
+	KindInternal Kind = "internal"
+)
+
+// Status returns the HTTP status of the errors of the kind.
+func (kind Kind) Status() int {
+	switch kind {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindThrottled:
+		return http.StatusTooManyRequests
+	case KindUpstream:
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Retryable reports whether an operation that failed with an error of the kind may succeed when
+// retried, like the messages of a queue. Requests refused for their content fail again.
+func (kind Kind) Retryable() bool {
+	switch kind {
+	case KindValidation, KindNotFound, KindConflict, KindForbidden:
+		return false
+	default:
+		return true
+	}
+}
+
+// KindOfStatus returns the kind of the errors answered with the HTTP status received.
+func KindOfStatus(status int) Kind {
+	switch {
+	case status == http.StatusNotFound || status == http.StatusGone:
+		return KindNotFound
+	case status == http.StatusConflict || status == http.StatusPreconditionFailed:
+		return KindConflict
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return KindForbidden
+	case status == http.StatusTooManyRequests:
+		return KindThrottled
+	case status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout:
+		return KindUpstream
+	case status >= 400 && status < 500:
+		return KindValidation
+	default:
+		return KindInternal
+	}
+}
+
+// awsKinds maps the error codes of the AWS services to the kinds of errors
+var awsKinds = map[string]Kind{
+	"ValidationException":                      KindValidation,
+	"SerializationException":                   KindValidation,
+	"ResourceNotFoundException":                KindNotFound,
+	"ConditionalCheckFailedException":          KindConflict,
+	"TransactionCanceledException":             KindConflict,
+	"TransactionConflictException":             KindConflict,
+	"IdempotentParameterMismatchException":     KindConflict,
+	"ItemCollectionSizeLimitExceededException": KindConflict,
+	"AccessDeniedException":                    KindForbidden,
+	"ProvisionedThroughputExceededException":   KindThrottled,
+	"RequestLimitExceeded":                     KindThrottled,
+	"ThrottlingException":                      KindThrottled,
+	"LimitExceededException":                   KindThrottled,
+	"TransactionInProgressException":           KindThrottled,
+	"InternalServerError":                      KindUpstream,
+	"ServiceUnavailable":                       KindUpstream,
+	request.CanceledErrorCode:                  KindUpstream,
+}
+
+// Error is an error classified by its kind.
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+// NewError creates an error of the kind received, caused by err, which may be nil.
+func NewError(kind Kind, message string, err error) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+// Errorf creates an error of the kind received with a formatted message.
+func Errorf(kind Kind, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Err == nil:
+		return e.Message
+	case e.Message == "":
+		return e.Err.Error()
+	default:
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf classifies an error: the kind of an *Error, validation for the errors of the schemas,
+// the kind mapped from the code of the AWS errors and internal for the others.
+func KindOf(err error) Kind {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed.Kind
+	}
+
+	var validationErr *schema.ValidationError
+	if errors.As(err, &validationErr) {
+		return KindValidation
+	}
+
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		if kind, ok := awsKinds[awsErr.Code()]; ok {
+			return kind
+		}
+
+		var failure awserr.RequestFailure
+		if errors.As(err, &failure) && failure.StatusCode() >= 500 {
+			return KindUpstream
+		}
+		return KindInternal
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return KindUpstream
+	}
+
+	return KindInternal
+}
+
+// IsRetryable reports whether the operation that failed with err may succeed when retried. Joined
+// errors are retryable when any of them is.
+func IsRetryable(err error) bool {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			if IsRetryable(e) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return KindOf(err).Retryable()
+}
+
+// Problem is the body of the failed responses, following the RFC 7807 problem details. The fields
+// that don't match the schema are listed in 'errors', and the result of each item of the operations
+// that write several items together, like transactions, in 'items'.
+type Problem struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail,omitempty"`
+	Instance string              `json:"instance,omitempty"`
+	Code     string              `json:"code"`
+	Errors   []schema.FieldError `json:"errors,omitempty"`
+	Items    []ItemResult        `json:"items,omitempty"`
+}
+
+// NewProblem creates the problem details of an error answered with the status received. The causes
+// of the server failures are not detailed to the clients.
+func NewProblem(status int, err error) *Problem {
+	kind := KindOf(err)
+
+	problem := &Problem{
+		Type:   ProblemTypePrefix + string(kind),
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   string(kind),
+	}
+	if err != nil && status < 500 {
+		problem.Detail = err.Error()
+	}
+
+	var validationErr *schema.ValidationError
+	if errors.As(err, &validationErr) {
+		problem.Code = "validation_failed"
+		problem.Detail = "the request does not match the schema"
+		problem.Errors = validationErr.Errors
+	} else if kind == KindValidation {
+		problem.Code = "invalid_request"
+	}
+
+	return problem
+}
+
+// NewErrorResponse creates the response of an error, whose status is decided by its kind and whose
+// body describes it as problem details.
+func NewErrorResponse(err error) *ExecutionResponse {
+	status := KindOf(err).Status()
+
+	return &ExecutionResponse{
+		StatusCode: status,
+		Message:    NewProblem(status, err),
+		Error:      err,
+	}
+}
+
+// ProblemOf returns the problem details of a failed response, describing the messages that are not
+// problem details, like the bodies returned by a downstream api.
+func ProblemOf(response *ExecutionResponse) *Problem {
+	if problem, ok := response.Message.(*Problem); ok {
+		return problem
+	}
+
+	err := response.Error
+	switch message := response.Message.(type) {
+	case nil:
+	case string:
+		err = errors.New(message)
+	default:
+		data, _ := json.Marshal(message)
+		err = errors.New(string(data))
+	}
+
+	if err == nil {
+		err = errors.New(http.StatusText(response.StatusCode))
+	}
+
+	return NewProblem(response.StatusCode, NewError(KindOfStatus(response.StatusCode), "", err))
+}
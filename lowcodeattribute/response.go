@@ -2,29 +2,159 @@ package lowcodeattribute
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
+	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
 )
 
 type ExecutionResponse struct {
-	StatusCode int         `json:"statusCode"`
-	Message    interface{} `json:"message"`
-	Error      error       `json:"error"`
+	StatusCode int               `json:"statusCode"`
+	Message    interface{}       `json:"message"`
+	Error      error             `json:"error"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	// Meta describes the result, like the number of items examined, in the response envelope
+	Meta map[string]interface{} `json:"meta,omitempty"`
+}
+
+// SetHeader adds a header to the response sent to the client
+func (response *ExecutionResponse) SetHeader(name, value string) {
+	if response.Headers == nil {
+		response.Headers = map[string]string{}
+	}
+	response.Headers[name] = value
 }
 
+// MarshalJSON encodes the response with the message of its error, which is otherwise encoded as
+// an empty object
+func (response ExecutionResponse) MarshalJSON() ([]byte, error) {
+	type plain ExecutionResponse
+
+	encoded := struct {
+		plain
+		Error string `json:"error,omitempty"`
+	}{plain: plain(response)}
+
+	if response.Error != nil {
+		encoded.Error = response.Error.Error()
+	}
+
+	return json.Marshal(encoded)
+}
+
+// ToGatewayResponse converts the response into a JSON response of the API Gateway. Failures are
+// described by problem details, and their errors are logged instead of failing the invocation.
 func (response *ExecutionResponse) ToGatewayResponse() (events.APIGatewayProxyResponse, error) {
-	content := ""
-	data, _ := json.Marshal(response.Message)
+	message, contentType := response.Message, "application/json"
+	if response.StatusCode >= 400 {
+		message, contentType = ProblemOf(response), ContentTypeProblem
+	}
 
-	if response.Message != nil {
+	content := ""
+	if message != nil {
+		data, _ := json.Marshal(message)
 		content = string(data)
 	}
 
-	log.Println(content)
+	if response.Error != nil {
+		log.Println(response.Error)
+	}
+
+	headers := map[string]string{}
+	for name, value := range response.Headers {
+		headers[name] = value
+	}
+	if content != "" {
+		headers["Content-Type"] = contentType
+	}
 
 	return events.APIGatewayProxyResponse{
 		StatusCode: response.StatusCode,
+		Headers:    headers,
 		Body:       content,
-	}, response.Error
+	}, nil
+}
+
+// NewBadRequestResponse creates a 400 (Bad Request) response describing why the request was refused.
+// Validation errors are detailed field by field, with the path of each violating field, the
+// expected type or constraint and the kind of value received.
+func NewBadRequestResponse(err error) *ExecutionResponse {
+	if KindOf(err) != KindValidation {
+		err = NewError(KindValidation, "", err)
+	}
+
+	return NewErrorResponse(err)
+}
+
+// IsValidationError reports whether err was caused by data that doesn't fit a schema
+func IsValidationError(err error) bool {
+	var validationErr *schema.ValidationError
+	return errors.As(err, &validationErr)
+}
+
+// ItemResult is the result of one of the records of a batch
+type ItemResult struct {
+	Index      int         `json:"index"`
+	StatusCode int         `json:"statusCode"`
+	Message    interface{} `json:"message,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// NewItemResult creates the result of the record found at index from its execution response. The
+// errors of the records failing with a 5xx status are only described by the text of the status.
+func NewItemResult(index int, response *ExecutionResponse) ItemResult {
+	result := ItemResult{
+		Index:      index,
+		StatusCode: response.StatusCode,
+		Message:    response.Message,
+	}
+
+	if result.Failed() {
+		result.Message = ProblemOf(response)
+	}
+
+	// like the problem details, the internal errors are not described to the clients
+	switch {
+	case response.Error == nil:
+	case result.StatusCode >= 500:
+		result.Error = http.StatusText(result.StatusCode)
+	default:
+		result.Error = response.Error.Error()
+	}
+
+	return result
+}
+
+// Failed reports whether the record was not processed successfully
+func (result ItemResult) Failed() bool {
+	return result.StatusCode >= 300
+}
+
+// NewBatchResponse creates the response of a batch with the result of each record: the status of
+// the records when all of them succeeded with the same status, like 201 (Created), 200 (OK) when
+// they succeeded with different ones, or 207 (Multi-Status) when any of them failed.
+func NewBatchResponse(results []ItemResult) *ExecutionResponse {
+	status := 0
+	for _, result := range results {
+		if result.Failed() {
+			status = 207
+			break
+		}
+
+		if status == 0 {
+			status = result.StatusCode
+		} else if status != result.StatusCode {
+			status = 200
+		}
+	}
+	if status == 0 {
+		status = 200
+	}
+
+	return &ExecutionResponse{
+		StatusCode: status,
+		Message:    results,
+	}
 }
@@ -0,0 +1,451 @@
+// Package mapping transforms the records decoded by the receiver into the records written by the
+// connector, and the records read by the connector back into the shape of the receiver, following
+// the declarative rules of the 'Mapping' section of the configuration.
+package mapping
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+// Scopes of the values that can be copied into a record.
+const (
+	ScopeBody   = "body"
+	ScopePath   = "path"
+	ScopeHeader = "header"
+	ScopeQuery  = "query"
+	ScopeClaims = "claims"
+)
+
+type (
+	// Mapping lists the rules applied, in order, to build the connector record from the request.
+	// The response rules build the receiver record from the connector record; when they are not
+	// informed, the inverse of the request rules is used.
+	Mapping struct {
+		Request  []*Rule `yaml:"Request"`
+		Response []*Rule `yaml:"Response"`
+		// Passthrough copies the fields of the body not referenced by the rules as they are
+		Passthrough bool `yaml:"Passthrough"`
+	}
+
+	// Rule writes a value into the field 'Target' of the record, a dotted path like
+	// 'Address.Street' whose parent objects are created when missing. The value is one of:
+	//   - Source: copied from 'scope.path', where scope is body (default), path, header, query
+	//     or claims, like 'body.Address.Street' or 'path.UserID'
+	//   - Concat: the text values of several sources joined by 'Separator'
+	//   - Constant: a fixed value
+	// and is converted to the type indicated by 'Cast': string, int, long, float, double or
+	// boolean. An object copied into an empty 'Target' is flattened into the record, its fields
+	// prefixed by 'Prefix'.
+	Rule struct {
+		Target    string      `yaml:"Target"`
+		Source    string      `yaml:"Source"`
+		Concat    []string    `yaml:"Concat"`
+		Separator string      `yaml:"Separator"`
+		Constant  interface{} `yaml:"Constant"`
+		Cast      string      `yaml:"Cast"`
+		Prefix    string      `yaml:"Prefix"`
+
+		source *reference
+		concat []*reference
+		cast   *schema.Schema
+	}
+
+	// Request carries the values available to the rules: the record decoded from the body and
+	// the parameters of the request.
+	Request struct {
+		Body   map[string]interface{}
+		Path   map[string]string
+		Header map[string]string
+		Query  map[string]string
+		Claims map[string]interface{}
+	}
+
+	// reference is a parsed source of a rule
+	reference struct {
+		scope string
+		path  []string
+	}
+)
+
+// Compile validates the rules of the mapping, failing on the unknown scopes and cast types. A nil
+// mapping is valid and keeps the records unchanged.
+func (m *Mapping) Compile() error {
+	if m == nil {
+		return nil
+	}
+
+	for i, rule := range m.Request {
+		if err := rule.compile(); err != nil {
+			return fmt.Errorf("invalid request rule %d: %v", i, err)
+		}
+	}
+
+	for i, rule := range m.Response {
+		if err := rule.compile(); err != nil {
+			return fmt.Errorf("invalid response rule %d: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Rule) compile() error {
+	var err error
+
+	sources := 0
+	if r.Source != "" {
+		sources++
+		if r.source, err = parseReference(r.Source); err != nil {
+			return err
+		}
+	}
+
+	if len(r.Concat) > 0 {
+		sources++
+		r.concat = make([]*reference, len(r.Concat))
+		for i, source := range r.Concat {
+			if r.concat[i], err = parseReference(source); err != nil {
+				return err
+			}
+		}
+	}
+
+	if r.Constant != nil {
+		sources++
+	}
+
+	if sources != 1 {
+		return fmt.Errorf("target %q requires exactly one of Source, Concat or Constant", r.Target)
+	}
+
+	if r.Target == "" && r.source == nil {
+		return fmt.Errorf("only a Source can be flattened into the record")
+	}
+
+	if r.Cast != "" {
+		if r.cast, err = castSchema(r.Cast); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseReference parses a source like 'header.Authorization', using the body when the scope is
+// not informed
+func parseReference(source string) (*reference, error) {
+	parts := strings.Split(source, ".")
+
+	switch parts[0] {
+	case ScopeBody, ScopePath, ScopeHeader, ScopeQuery, ScopeClaims:
+		ref := &reference{scope: parts[0], path: parts[1:]}
+		if ref.scope != ScopeBody && len(ref.path) != 1 {
+			return nil, fmt.Errorf("source %q must name a single %s parameter", source, ref.scope)
+		}
+		return ref, nil
+	default:
+		return &reference{scope: ScopeBody, path: parts}, nil
+	}
+}
+
+// castSchema returns the schema used to convert the values to a cast type
+func castSchema(cast string) (*schema.Schema, error) {
+	switch t := schema.Type(cast); t {
+	case schema.String, schema.Int, schema.Long, schema.Float, schema.Double, schema.Boolean:
+		return &schema.Schema{Type: t}, nil
+	default:
+		return nil, fmt.Errorf("unsupported cast type %s", cast)
+	}
+}
+
+// Apply builds the connector record from the request. Without a mapping, the body is returned as
+// received. A *schema.ValidationError is returned when a value can't be cast.
+func (m *Mapping) Apply(req Request) (map[string]interface{}, error) {
+	if m == nil {
+		return req.Body, nil
+	}
+
+	return apply(m.Request, req, m.Passthrough)
+}
+
+// Reverse builds the receiver record from a record read by the connector, using the response
+// rules or, when there are none, the inverse of the request rules. Without a mapping, the record
+// is returned as received.
+func (m *Mapping) Reverse(record map[string]interface{}) (map[string]interface{}, error) {
+	if m == nil {
+		return record, nil
+	}
+
+	if len(m.Response) > 0 {
+		return apply(m.Response, Request{Body: record}, m.Passthrough)
+	}
+
+	return m.inverse(record), nil
+}
+
+// apply executes the rules on the request
+func apply(rules []*Rule, req Request, passthrough bool) (map[string]interface{}, error) {
+	record := map[string]interface{}{}
+	errs := &schema.ValidationError{}
+
+	if passthrough {
+		used := map[string]bool{}
+		for _, rule := range rules {
+			for _, ref := range rule.references() {
+				if ref.scope == ScopeBody && len(ref.path) > 0 {
+					used[ref.path[0]] = true
+				}
+			}
+		}
+
+		for key, value := range req.Body {
+			if !used[key] {
+				record[key] = value
+			}
+		}
+	}
+
+	for _, rule := range rules {
+		value, found := rule.value(req)
+		if !found {
+			continue
+		}
+
+		if rule.cast != nil {
+			cast, err := castValue(rule.cast, value)
+			if err != nil {
+				errs.Errors = append(errs.Errors, schema.FieldError{
+					Path:     rule.Target,
+					Expected: rule.Cast,
+					Received: schema.Kind(value),
+					Message:  fmt.Sprintf("cannot convert %s into %s", schema.Kind(value), rule.Cast),
+				})
+				continue
+			}
+			value = cast
+		}
+
+		if rule.Target == "" {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				errs.Errors = append(errs.Errors, schema.FieldError{
+					Path:     rule.Source,
+					Expected: "object",
+					Received: schema.Kind(value),
+					Message:  fmt.Sprintf("expected object, received %s", schema.Kind(value)),
+				})
+				continue
+			}
+
+			for key, v := range object {
+				record[rule.Prefix+key] = v
+			}
+			continue
+		}
+
+		set(record, strings.Split(rule.Target, "."), value)
+	}
+
+	if len(errs.Errors) > 0 {
+		return nil, errs
+	}
+
+	return record, nil
+}
+
+// references returns the sources read by the rule
+func (r *Rule) references() []*reference {
+	if r.source != nil {
+		return []*reference{r.source}
+	}
+
+	return r.concat
+}
+
+// value returns the value produced by the rule, and whether its sources were found
+func (r *Rule) value(req Request) (interface{}, bool) {
+	switch {
+	case r.source != nil:
+		return req.lookup(r.source)
+	case len(r.concat) > 0:
+		parts := make([]string, 0, len(r.concat))
+		for _, ref := range r.concat {
+			if value, found := req.lookup(ref); found && value != nil {
+				parts = append(parts, text(value))
+			}
+		}
+
+		if len(parts) == 0 {
+			return nil, false
+		}
+		return strings.Join(parts, r.Separator), true
+	default:
+		return r.Constant, true
+	}
+}
+
+// lookup returns the value of a reference on the request
+func (req Request) lookup(ref *reference) (interface{}, bool) {
+	switch ref.scope {
+	case ScopePath:
+		value, ok := req.Path[ref.path[0]]
+		return value, ok
+	case ScopeQuery:
+		value, ok := req.Query[ref.path[0]]
+		return value, ok
+	case ScopeHeader:
+		value, ok := lookupHeader(req.Header, ref.path[0])
+		if !ok {
+			return nil, false
+		}
+		return value, true
+	case ScopeClaims:
+		value, ok := req.Claims[ref.path[0]]
+		return value, ok
+	default:
+		return get(req.Body, ref.path)
+	}
+}
+
+// HeaderValue returns the value of a header, ignoring the case of its name.
+func HeaderValue(headers map[string]string, name string) string {
+	value, _ := lookupHeader(headers, name)
+	return value
+}
+
+// lookupHeader finds a header, ignoring the case of its name
+func lookupHeader(headers map[string]string, name string) (string, bool) {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// inverse moves the fields written by the body copies of the request rules back to their sources.
+// The fields produced from constants, concatenations or request parameters are dropped.
+func (m *Mapping) inverse(record map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	used := map[string]bool{}
+
+	for _, rule := range m.Request {
+		if rule.Target != "" {
+			used[strings.Split(rule.Target, ".")[0]] = true
+		}
+
+		if rule.source == nil || rule.source.scope != ScopeBody {
+			continue
+		}
+
+		// flattened objects are nested back from the prefixed fields
+		if rule.Target == "" {
+			object := map[string]interface{}{}
+			for key, value := range record {
+				if strings.HasPrefix(key, rule.Prefix) {
+					object[strings.TrimPrefix(key, rule.Prefix)] = value
+					used[key] = true
+				}
+			}
+
+			if len(object) > 0 {
+				set(result, rule.source.path, object)
+			}
+			continue
+		}
+
+		if value, found := get(record, strings.Split(rule.Target, ".")); found {
+			set(result, rule.source.path, value)
+		}
+	}
+
+	if m.Passthrough {
+		for key, value := range record {
+			if _, exists := result[key]; !exists && !used[key] {
+				result[key] = value
+			}
+		}
+	}
+
+	return result
+}
+
+// get returns the value found on the path of the record
+func get(record map[string]interface{}, path []string) (interface{}, bool) {
+	var current interface{} = record
+
+	for _, name := range path {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		if current, ok = object[name]; !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// set writes the value on the path of the record, creating the missing parent objects
+func set(record map[string]interface{}, path []string, value interface{}) {
+	if len(path) == 0 {
+		return
+	}
+
+	current := record
+	for _, name := range path[:len(path)-1] {
+		child, ok := current[name].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			current[name] = child
+		}
+		current = child
+	}
+
+	current[path[len(path)-1]] = value
+}
+
+// castValue converts the value into the type of the cast schema
+func castValue(s *schema.Schema, value interface{}) (interface{}, error) {
+	if s.Type == schema.String {
+		if value == nil {
+			return nil, nil
+		}
+		return text(value), nil
+	}
+
+	return s.Coerce(value)
+}
+
+// text returns the textual representation of a value
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of the context carrying the request, so the connectors can evaluate
+// the values derived from it.
+func NewContext(ctx context.Context, req Request) context.Context {
+	return context.WithValue(ctx, contextKey{}, req)
+}
+
+// FromContext returns the request carried by the context, if any.
+func FromContext(ctx context.Context) (Request, bool) {
+	req, ok := ctx.Value(contextKey{}).(Request)
+	return req, ok
+}
@@ -0,0 +1,178 @@
+package mapping
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+func mustCompile(t testing.TB, m *Mapping) *Mapping {
+	t.Helper()
+
+	if err := m.Compile(); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	return m
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name string
+		rule *Rule
+	}{
+		{name: "no source", rule: &Rule{Target: "A"}},
+		{name: "two sources", rule: &Rule{Target: "A", Source: "B", Constant: 1}},
+		{name: "nested parameter", rule: &Rule{Target: "A", Source: "header.X.Y"}},
+		{name: "flattened constant", rule: &Rule{Constant: 1}},
+		{name: "unknown cast", rule: &Rule{Target: "A", Source: "B", Cast: "date"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := (&Mapping{Request: []*Rule{tt.rule}}).Compile(); err == nil {
+				t.Errorf("Compile() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	m := mustCompile(t, &Mapping{
+		Request: []*Rule{
+			{Target: "PK", Concat: []string{"body.Country", "path.UserID"}, Separator: "#"},
+			{Target: "Profile.Name", Source: "Name"},
+			{Target: "Age", Source: "body.Age", Cast: "int"},
+			{Target: "Tenant", Source: "header.X-Tenant"},
+			{Target: "Owner", Source: "claims.sub"},
+			{Target: "Page", Source: "query.page", Cast: "long"},
+			{Target: "Kind", Constant: "user"},
+			{Source: "body.Address", Prefix: "Address_"},
+		},
+		Passthrough: true,
+	})
+
+	got, err := m.Apply(Request{
+		Body: map[string]interface{}{
+			"Country": "PT",
+			"Name":    "Ana",
+			"Age":     "30",
+			"Address": map[string]interface{}{"City": "Lisbon"},
+			"Email":   "ana@example.com",
+		},
+		Path:   map[string]string{"UserID": "42"},
+		Header: map[string]string{"x-tenant": "acme"},
+		Query:  map[string]string{"page": "2"},
+		Claims: map[string]interface{}{"sub": "user-1"},
+	})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"PK":           "PT#42",
+		"Profile":      map[string]interface{}{"Name": "Ana"},
+		"Age":          int32(30),
+		"Tenant":       "acme",
+		"Owner":        "user-1",
+		"Page":         int64(2),
+		"Kind":         "user",
+		"Address_City": "Lisbon",
+		"Email":        "ana@example.com",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() = %#v, want %#v", got, want)
+	}
+}
+
+func TestApplyListsTheCastErrors(t *testing.T) {
+	m := mustCompile(t, &Mapping{Request: []*Rule{
+		{Target: "Age", Source: "Age", Cast: "int"},
+		{Target: "Active", Source: "Active", Cast: "boolean"},
+		{Source: "Address"},
+	}})
+
+	_, err := m.Apply(Request{Body: map[string]interface{}{"Age": "old", "Active": "maybe", "Address": "Main"}})
+
+	var validationErr *schema.ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Errors) != 3 {
+		t.Fatalf("Apply() error = %v, want the 3 failures", err)
+	}
+}
+
+func TestReverse(t *testing.T) {
+	m := mustCompile(t, &Mapping{
+		Request: []*Rule{
+			{Target: "Profile.Name", Source: "Name"},
+			{Target: "Kind", Constant: "user"},
+			{Target: "Tenant", Source: "header.X-Tenant"},
+			{Source: "Address", Prefix: "Address_"},
+		},
+		Passthrough: true,
+	})
+
+	got, err := m.Reverse(map[string]interface{}{
+		"Profile":      map[string]interface{}{"Name": "Ana"},
+		"Kind":         "user",
+		"Tenant":       "acme",
+		"Address_City": "Lisbon",
+		"Email":        "ana@example.com",
+	})
+	if err != nil {
+		t.Fatalf("Reverse() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"Name":    "Ana",
+		"Address": map[string]interface{}{"City": "Lisbon"},
+		"Email":   "ana@example.com",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Reverse() = %#v, want %#v", got, want)
+	}
+
+	m = mustCompile(t, &Mapping{
+		Request:  []*Rule{{Target: "Name", Source: "FullName"}},
+		Response: []*Rule{{Target: "DisplayName", Source: "Name"}},
+	})
+	if got, _ := m.Reverse(map[string]interface{}{"Name": "Ana"}); !reflect.DeepEqual(got, map[string]interface{}{"DisplayName": "Ana"}) {
+		t.Errorf("Reverse() = %v, want the response rules applied", got)
+	}
+}
+
+func TestNilMapping(t *testing.T) {
+	var m *Mapping
+	body := map[string]interface{}{"A": 1}
+
+	if got, err := m.Apply(Request{Body: body}); err != nil || !reflect.DeepEqual(got, body) {
+		t.Errorf("Apply() = %v, %v, want the body", got, err)
+	}
+	if got, err := m.Reverse(body); err != nil || !reflect.DeepEqual(got, body) {
+		t.Errorf("Reverse() = %v, %v, want the record", got, err)
+	}
+}
+
+func TestHeaderValue(t *testing.T) {
+	headers := map[string]string{"Idempotency-Key": "abc"}
+
+	if got := HeaderValue(headers, "idempotency-key"); got != "abc" {
+		t.Errorf("HeaderValue() = %q, want abc", got)
+	}
+	if got := HeaderValue(headers, "Accept"); got != "" {
+		t.Errorf("HeaderValue() = %q, want empty", got)
+	}
+}
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Errorf("FromContext() ok = true on an empty context")
+	}
+
+	req := Request{Path: map[string]string{"UserID": "42"}}
+	if got, ok := FromContext(NewContext(context.Background(), req)); !ok || got.Path["UserID"] != "42" {
+		t.Errorf("FromContext() = %v, %v, want the request", got, ok)
+	}
+}
@@ -0,0 +1,268 @@
+package lowcode
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/connector"
+	"github.com/raywall/aws-lowcode-lambda-go/receiver"
+)
+
+// Option configures the LowcodeFunction created by New.
+type Option func(*options) error
+
+type options struct {
+	configSource config.Source
+	configName   string
+	configData   []byte
+	schemaSource config.Source
+	session      *session.Session
+	endpoint     string
+	client       dynamodbiface.DynamoDBAPI
+	logger       *log.Logger
+	debug        bool
+	reload       time.Duration
+	receivers    []receiver.Receiver
+	connectors   map[string]connector.Factory
+}
+
+func (o *options) setConfigSource(src config.Source, name string, data []byte) error {
+	if o.configSource != nil || o.configData != nil {
+		return errors.New("only one configuration source can be used")
+	}
+
+	o.configSource = src
+	o.configName = name
+	o.configData = data
+	return nil
+}
+
+// WithConfigFile loads the configuration from the file found at path.
+func WithConfigFile(path string) Option {
+	return func(o *options) error {
+		return o.setConfigSource(config.OSSource{}, path, nil)
+	}
+}
+
+// WithConfigBytes loads the configuration from the content received.
+func WithConfigBytes(data []byte) Option {
+	return func(o *options) error {
+		if data == nil {
+			data = []byte{}
+		}
+
+		return o.setConfigSource(nil, "", data)
+	}
+}
+
+// WithConfigReader loads the configuration from the content read from r.
+func WithConfigReader(r io.Reader) Option {
+	return func(o *options) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed reading lowcode role file: %v", err)
+		}
+
+		return o.setConfigSource(nil, "", data)
+	}
+}
+
+// WithConfigFS loads the configuration from the file called name inside fsys, like an embed.FS
+// compiled into the binary. The schema files are also read from fsys.
+func WithConfigFS(fsys fs.FS, name string) Option {
+	return func(o *options) error {
+		return o.setConfigSource(config.FSSource{FS: fsys}, name, nil)
+	}
+}
+
+// WithConfigSource loads the configuration from the file called name retrieved from src, like an
+// S3 bucket or the SSM parameter store. The schema files are also read from src.
+func WithConfigSource(src config.Source, name string) Option {
+	return func(o *options) error {
+		if src == nil {
+			return errors.New("configuration source cannot be nil")
+		}
+
+		return o.setConfigSource(src, name, nil)
+	}
+}
+
+// WithSchemaSource defines the source from which the schema files indicated by 'ObjectPathSchema'
+// are read, when it differs from the configuration source. The local file system is used when
+// the configuration is informed by WithConfigBytes or WithConfigReader.
+func WithSchemaSource(src config.Source) Option {
+	return func(o *options) error {
+		if src == nil {
+			return errors.New("schema source cannot be nil")
+		}
+
+		o.schemaSource = src
+		return nil
+	}
+}
+
+// WithSession defines the AWS session used to create the clients of the function.
+func WithSession(sess *session.Session) Option {
+	return func(o *options) error {
+		if sess == nil {
+			return errors.New("session cannot be nil")
+		}
+
+		o.session = sess
+		return nil
+	}
+}
+
+// WithDynamoDBEndpoint overrides the endpoint of the DynamoDB client created by the function,
+// which is useful when running against a local DynamoDB.
+func WithDynamoDBEndpoint(endpoint string) Option {
+	return func(o *options) error {
+		o.endpoint = endpoint
+		return nil
+	}
+}
+
+// WithDynamoDBClient defines the DynamoDB client used by the function, skipping the creation of
+// a new one.
+func WithDynamoDBClient(client dynamodbiface.DynamoDBAPI) Option {
+	return func(o *options) error {
+		if client == nil {
+			return errors.New("dynamodb client cannot be nil")
+		}
+
+		o.client = client
+		return nil
+	}
+}
+
+// WithLogger defines the logger used by the function.
+func WithLogger(logger *log.Logger) Option {
+	return func(o *options) error {
+		if logger == nil {
+			return errors.New("logger cannot be nil")
+		}
+
+		o.logger = logger
+		return nil
+	}
+}
+
+// WithDebug enables or disables the debug messages of the function.
+func WithDebug(debug bool) Option {
+	return func(o *options) error {
+		o.debug = debug
+		return nil
+	}
+}
+
+// WithHotReload enables the reload of the configuration between warm invocations. The version of
+// the configuration and schema files is checked at most once per interval, and a new configuration
+// is only used after being loaded and validated successfully. The sources must implement
+// config.Versioner.
+func WithHotReload(interval time.Duration) Option {
+	return func(o *options) error {
+		if interval <= 0 {
+			return errors.New("hot reload interval must be greater than zero")
+		}
+
+		o.reload = interval
+		return nil
+	}
+}
+
+// WithReceiver registers a custom receiver, which is consulted before the built-in ones.
+func WithReceiver(r receiver.Receiver) Option {
+	return func(o *options) error {
+		if r == nil {
+			return errors.New("receiver cannot be nil")
+		}
+
+		o.receivers = append(o.receivers, r)
+		return nil
+	}
+}
+
+// WithConnector registers the factory used to create the connector of the resourceType informed,
+// replacing the built-in one when it exists.
+func WithConnector(resourceType string, factory connector.Factory) Option {
+	return func(o *options) error {
+		if factory == nil {
+			return fmt.Errorf("connector factory of %s cannot be nil", resourceType)
+		}
+
+		o.connectors[resourceType] = factory
+		return nil
+	}
+}
+
+// loadSettings reads and loads the configuration from the source informed by the options
+func (o *options) loadSettings() (*config.Config, error) {
+	data := o.configData
+	schemaSource := o.schemaSource
+
+	switch {
+	case o.configData != nil:
+		if schemaSource == nil {
+			schemaSource = config.OSSource{}
+		}
+	case o.configSource != nil:
+		var err error
+
+		data, err = o.configSource.ReadFile(o.configName)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading lowcode role file: %v", err)
+		}
+
+		if schemaSource == nil {
+			schemaSource = o.configSource
+		}
+	default:
+		return nil, errors.New("no configuration source was informed")
+	}
+
+	settings := &config.Config{}
+	if err := settings.Load(data); err != nil {
+		return nil, fmt.Errorf("failed loading settings: %v", err)
+	}
+
+	// schemas are compiled once, failing fast when one of them is invalid
+	settings.SetSource(schemaSource)
+	if err := settings.Compile(); err != nil {
+		return nil, fmt.Errorf("failed loading settings: %v", err)
+	}
+
+	return settings, nil
+}
+
+// dynamoDBClient returns the client informed by the options or creates a new one
+func (o *options) dynamoDBClient() (dynamodbiface.DynamoDBAPI, error) {
+	if o.client != nil {
+		return o.client, nil
+	}
+
+	sess := o.session
+	if sess == nil {
+		var err error
+
+		sess, err = session.NewSession()
+		if err != nil {
+			return nil, fmt.Errorf("failed creating aws session: %v", err)
+		}
+	}
+
+	awsConfig := aws.Config{}
+	if o.endpoint != "" {
+		awsConfig.Endpoint = aws.String(o.endpoint)
+	}
+
+	return dynamodb.New(sess, &awsConfig), nil
+}
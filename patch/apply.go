@@ -0,0 +1,221 @@
+package patch
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// removed marks the attributes removed by an operation
+type removed struct{}
+
+// Apply returns a copy of the document with the operations of the patch applied, as the update of
+// the item applies them, so the rules of the records can be checked on the patched document. The
+// merge patches create the missing objects holding the attributes they assign; the other
+// operations on attributes whose parents don't exist are ignored, like the tests, which are left to
+// the update of the item.
+func (p *Patch) Apply(document map[string]interface{}) map[string]interface{} {
+	result := copyValue(document).(map[string]interface{})
+
+	operations := p
+	if p.Merge {
+		operations, _ = p.CreateParents(func(path []string) bool {
+			return exists(result, path)
+		})
+	}
+
+	for _, operation := range operations.Operations {
+		apply(result, operation.Path, operation)
+	}
+
+	return result
+}
+
+// apply changes the value found on the path by the operation, returning the container changed
+func apply(current interface{}, path []string, operation Operation) interface{} {
+	if len(path) == 0 {
+		return change(current, operation)
+	}
+
+	switch container := current.(type) {
+	case map[string]interface{}:
+		child, found := container[path[0]]
+		if !found && len(path) > 1 {
+			return container
+		}
+
+		switch value := apply(child, path[1:], operation); value.(type) {
+		case removed:
+			delete(container, path[0])
+		default:
+			container[path[0]] = value
+		}
+	case []interface{}:
+		index, err := strconv.Atoi(path[0])
+		if err != nil || index < 0 || index >= len(container) {
+			return container
+		}
+
+		switch value := apply(container[index], path[1:], operation); value.(type) {
+		case removed:
+			return append(container[:index:index], container[index+1:]...)
+		default:
+			container[index] = value
+		}
+	}
+
+	return current
+}
+
+// change returns the value of an attribute changed by the operation
+func change(current interface{}, operation Operation) interface{} {
+	switch operation.Op {
+	case OpSet:
+		return copyValue(operation.Value)
+	case OpSetIfMissing:
+		if current != nil {
+			return current
+		}
+		return copyValue(operation.Value)
+	case OpRemove:
+		return removed{}
+	case OpIncrement:
+		return increment(current, operation.Value)
+	case OpAppend:
+		list, _ := current.([]interface{})
+		values, _ := operation.Value.([]interface{})
+		return append(append([]interface{}{}, list...), values...)
+	case OpAddToSet, OpDeleteFromSet:
+		set, _ := current.([]interface{})
+		values, _ := operation.Value.([]interface{})
+		return changeSet(set, values, operation.Op == OpAddToSet)
+	default:
+		return current
+	}
+}
+
+// increment adds the delta to the number, keeping the type of the number
+func increment(current, delta interface{}) interface{} {
+	if current == nil {
+		return delta
+	}
+
+	a, aInteger := integer(current)
+	b, bInteger := integer(delta)
+	if aInteger && bInteger {
+		switch current.(type) {
+		case int32:
+			return int32(a + b)
+		case int:
+			return int(a + b)
+		}
+		return a + b
+	}
+
+	sum := float(current) + float(delta)
+	if _, ok := current.(float32); ok {
+		return float32(sum)
+	}
+
+	return sum
+}
+
+// changeSet adds the values missing from the set, or removes the values found on it
+func changeSet(set, values []interface{}, add bool) []interface{} {
+	result := []interface{}{}
+	for _, item := range set {
+		if add || !contains(values, item) {
+			result = append(result, item)
+		}
+	}
+
+	if add {
+		for _, value := range values {
+			if !contains(result, value) {
+				result = append(result, value)
+			}
+		}
+	}
+
+	return result
+}
+
+func contains(values []interface{}, value interface{}) bool {
+	for _, item := range values {
+		if reflect.DeepEqual(item, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func integer(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+
+	return 0, false
+}
+
+func float(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	}
+
+	n, _ := integer(value)
+	return float64(n)
+}
+
+// exists reports whether the document holds a value on the path, which may go through maps and lists
+func exists(document map[string]interface{}, path []string) bool {
+	var current interface{} = document
+
+	for _, segment := range path {
+		switch container := current.(type) {
+		case map[string]interface{}:
+			value, found := container[segment]
+			if !found {
+				return false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(container) {
+				return false
+			}
+			current = container[index]
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// copyValue copies the maps and lists of a value, so the changes don't reach the original
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = copyValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = copyValue(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
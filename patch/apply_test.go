@@ -0,0 +1,88 @@
+package patch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	document := map[string]interface{}{
+		"Name":    "Ana",
+		"Age":     30.0,
+		"Tags":    []interface{}{"a", "b"},
+		"Address": map[string]interface{}{"Street": "Main", "Phones": []interface{}{"1", "2"}},
+	}
+
+	tests := []struct {
+		name  string
+		patch *Patch
+		want  map[string]interface{}
+	}{
+		{
+			name:  "merge patch creating the parents",
+			patch: mustParse(t, ContentTypeMergePatch, `{"Name": null, "Geo": {"Lat": 1}, "Address": {"Street": "Elm"}}`),
+			want: map[string]interface{}{
+				"Age":     30.0,
+				"Tags":    []interface{}{"a", "b"},
+				"Geo":     map[string]interface{}{"Lat": 1.0},
+				"Address": map[string]interface{}{"Street": "Elm", "Phones": []interface{}{"1", "2"}},
+			},
+		},
+		{
+			name: "operations",
+			patch: &Patch{Operations: []Operation{
+				{Op: OpIncrement, Path: []string{"Age"}, Value: 2.0},
+				{Op: OpAppend, Path: []string{"Tags"}, Value: []interface{}{"c"}},
+				{Op: OpSetIfMissing, Path: []string{"Name"}, Value: "Bia"},
+				{Op: OpSetIfMissing, Path: []string{"Email"}, Value: "ana@example.com"},
+				{Op: OpRemove, Path: []string{"Address", "Phones", "0"}},
+				{Op: OpTest, Path: []string{"Name"}, Value: "Bia"},
+			}},
+			want: map[string]interface{}{
+				"Name":    "Ana",
+				"Age":     32.0,
+				"Email":   "ana@example.com",
+				"Tags":    []interface{}{"a", "b", "c"},
+				"Address": map[string]interface{}{"Street": "Main", "Phones": []interface{}{"2"}},
+			},
+		},
+		{
+			name: "sets and missing parents",
+			patch: &Patch{Operations: []Operation{
+				{Op: OpAddToSet, Path: []string{"Tags"}, Value: []interface{}{"b", "c"}},
+				{Op: OpDeleteFromSet, Path: []string{"Tags"}, Value: []interface{}{"a"}},
+				{Op: OpSet, Path: []string{"Geo", "Lat"}, Value: 1.0},
+			}},
+			want: map[string]interface{}{
+				"Name":    "Ana",
+				"Age":     30.0,
+				"Tags":    []interface{}{"b", "c"},
+				"Address": map[string]interface{}{"Street": "Main", "Phones": []interface{}{"1", "2"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.patch.Apply(document); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	// the document received is not changed
+	if len(document) != 4 || len(document["Tags"].([]interface{})) != 2 {
+		t.Errorf("Apply() changed the document received: %#v", document)
+	}
+}
+
+func mustParse(t *testing.T, media string, body string) *Patch {
+	t.Helper()
+
+	p, err := Parse(media, []byte(body))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	return p
+}
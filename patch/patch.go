@@ -0,0 +1,325 @@
+// Package patch parses the bodies of the PATCH requests into the operations of a partial update:
+// JSON Merge Patch (RFC 7386) documents, whose null members remove the attributes, and JSON Patch
+// (RFC 6902) documents, extended with the operations DynamoDB supports on numbers, lists and sets.
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Content types of the patch documents.
+const (
+	ContentTypeMergePatch = "application/merge-patch+json"
+	ContentTypeJSONPatch  = "application/json-patch+json"
+)
+
+// Op is the kind of change applied by an operation.
+type Op string
+
+const (
+	// OpSet assigns the value to the attribute
+	OpSet Op = "set"
+	// OpRemove removes the attribute
+	OpRemove Op = "remove"
+	// OpIncrement adds the value to a number, starting from zero
+	OpIncrement Op = "increment"
+	// OpAppend appends the values to the end of a list, starting from an empty list
+	OpAppend Op = "append"
+	// OpAddToSet adds the values to a set
+	OpAddToSet Op = "add_to_set"
+	// OpDeleteFromSet deletes the values from a set
+	OpDeleteFromSet Op = "delete_from_set"
+	// OpSetIfMissing assigns the value only when the attribute doesn't exist
+	OpSetIfMissing Op = "if_not_exists"
+	// OpTest requires the attribute to be equal to the value, failing the patch otherwise
+	OpTest Op = "test"
+)
+
+type (
+	// Operation is a change applied to the attribute found on the path, whose first segment is the
+	// name of an attribute of the item and the others are the names of nested attributes or the
+	// indexes of list elements.
+	Operation struct {
+		Op    Op
+		Path  []string
+		Value interface{}
+		// MustExist requires the attribute to exist, like the replace and remove operations of a
+		// JSON Patch
+		MustExist bool
+		// Insert marks the add operations of a JSON Patch on an index, which insert the value into
+		// a list instead of replacing its element
+		Insert bool
+	}
+
+	// Patch is the list of operations of a partial update.
+	Patch struct {
+		Operations []Operation
+		// Merge is set on the merge patches, which create the missing objects holding the nested
+		// attributes they assign
+		Merge bool
+	}
+
+	// jsonPatchOperation is an operation of a JSON Patch document
+	jsonPatchOperation struct {
+		Op    string           `json:"op"`
+		Path  string           `json:"path"`
+		Value *json.RawMessage `json:"value"`
+		From  string           `json:"from"`
+	}
+)
+
+// Parse parses the body of a PATCH request according to its media type. JSON bodies are handled as
+// merge patches.
+func Parse(mediaType string, data []byte) (*Patch, error) {
+	switch mediaType {
+	case ContentTypeMergePatch, "application/json", "":
+		return ParseMergePatch(data)
+	case ContentTypeJSONPatch:
+		return ParseJSONPatch(data)
+	default:
+		return nil, fmt.Errorf("unsupported patch content type %s", mediaType)
+	}
+}
+
+// ParseMergePatch parses a JSON Merge Patch document: the null members remove the attributes, the
+// objects are merged into the nested attributes and the other values replace the attributes.
+func ParseMergePatch(data []byte) (*Patch, error) {
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %v", err)
+	}
+
+	p := &Patch{Merge: true}
+	p.merge(nil, document)
+
+	return p, nil
+}
+
+// merge adds the operations of the members of a merge patch object found on the path
+func (p *Patch) merge(path []string, document map[string]interface{}) {
+	names := make([]string, 0, len(document))
+	for name := range document {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := document[name]
+		member := append(append([]string{}, path...), name)
+
+		switch v := value.(type) {
+		case nil:
+			p.Operations = append(p.Operations, Operation{Op: OpRemove, Path: member})
+		case map[string]interface{}:
+			if len(v) == 0 {
+				p.Operations = append(p.Operations, Operation{Op: OpSet, Path: member, Value: v})
+				continue
+			}
+			p.merge(member, v)
+		default:
+			p.Operations = append(p.Operations, Operation{Op: OpSet, Path: member, Value: v})
+		}
+	}
+}
+
+// ParseJSONPatch parses a JSON Patch document. Besides the add, replace, remove and test operations,
+// it accepts the increment, append, add_to_set, delete_from_set and if_not_exists operations; adding
+// to the end of a list ('/-') appends the value. The move and copy operations are not supported.
+func ParseJSONPatch(data []byte) (*Patch, error) {
+	var document []jsonPatchOperation
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("invalid json patch: %v", err)
+	}
+
+	p := &Patch{}
+	for i, raw := range document {
+		operation, err := raw.operation()
+		if err != nil {
+			return nil, fmt.Errorf("invalid json patch operation %d: %v", i, err)
+		}
+		p.Operations = append(p.Operations, operation)
+	}
+
+	return p, nil
+}
+
+// operation converts an operation of a JSON Patch document
+func (raw jsonPatchOperation) operation() (Operation, error) {
+	path, err := ParsePointer(raw.Path)
+	if err != nil {
+		return Operation{}, err
+	}
+
+	operation := Operation{Path: path}
+
+	switch Op(raw.Op) {
+	case OpRemove:
+		operation.Op, operation.MustExist = OpRemove, true
+		return operation, nil
+	case "add":
+		operation.Op = OpSet
+		switch last := len(path) - 1; {
+		case last > 0 && path[last] == "-":
+			operation.Op, operation.Path = OpAppend, path[:last]
+		case last > 0 && IsIndex(path[last]):
+			operation.Insert = true
+		}
+	case "replace":
+		operation.Op, operation.MustExist = OpSet, true
+	case OpTest, OpIncrement, OpAppend, OpAddToSet, OpDeleteFromSet, OpSetIfMissing:
+		operation.Op = Op(raw.Op)
+	case "move", "copy":
+		return Operation{}, fmt.Errorf("the %s operation is not supported", raw.Op)
+	default:
+		return Operation{}, fmt.Errorf("unknown operation %q", raw.Op)
+	}
+
+	if raw.Value == nil {
+		return Operation{}, fmt.Errorf("the %s operation requires a value", raw.Op)
+	}
+	if err := json.Unmarshal(*raw.Value, &operation.Value); err != nil {
+		return Operation{}, err
+	}
+
+	// adding to the end of a list appends a single value
+	if raw.Op == "add" && operation.Op == OpAppend {
+		operation.Value = []interface{}{operation.Value}
+	}
+
+	return operation, nil
+}
+
+// ParsePointer splits a JSON Pointer (RFC 6901) into its segments. The pointer must address an
+// attribute of the item, not the whole document.
+func ParsePointer(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, "/") || pointer == "/" {
+		return nil, fmt.Errorf("invalid path %q: must point to an attribute", pointer)
+	}
+
+	segments := strings.Split(pointer[1:], "/")
+	for i, segment := range segments {
+		segments[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+	}
+
+	return segments, nil
+}
+
+// IsIndex reports whether a segment of a path, other than the first, is the index of a list element
+func IsIndex(segment string) bool {
+	index, err := strconv.Atoi(segment)
+	return err == nil && index >= 0 && strconv.Itoa(index) == segment
+}
+
+// Set adds an operation assigning the value to the attribute called name, like the attributes
+// generated by the server.
+func (p *Patch) Set(name string, value interface{}) {
+	p.Operations = append(p.Operations, Operation{Op: OpSet, Path: []string{name}, Value: value})
+}
+
+// Take removes the top-level attributes assigned by the patch whose names are listed, returning
+// their values. The merge patches carry the keys of the item along with its changes.
+func (p *Patch) Take(names []string) map[string]interface{} {
+	taken := map[string]interface{}{}
+
+	operations := p.Operations[:0]
+	for _, operation := range p.Operations {
+		if operation.Op == OpSet && len(operation.Path) == 1 && slices.Contains(names, operation.Path[0]) {
+			taken[operation.Path[0]] = operation.Value
+			continue
+		}
+		operations = append(operations, operation)
+	}
+	p.Operations = operations
+
+	return taken
+}
+
+// Attributes returns the top-level attributes changed or tested by the patch
+func (p *Patch) Attributes() []string {
+	var names []string
+	for _, operation := range p.Operations {
+		if !slices.Contains(names, operation.Path[0]) {
+			names = append(names, operation.Path[0])
+		}
+	}
+
+	return names
+}
+
+// CreateParents returns the patch with the operations on the nested attributes whose parents don't
+// exist, according to exists, replaced by the assignment of the missing parents holding the values
+// assigned under them, as a merge patch creates them. The removals under the missing parents are
+// dropped. It also reports whether any parent was missing.
+func (p *Patch) CreateParents(exists func(path []string) bool) (*Patch, bool) {
+	created := &Patch{Merge: p.Merge}
+	parents := map[string]map[string]interface{}{}
+	missing := false
+
+	for _, operation := range p.Operations {
+		depth := 0
+		for i := 1; i < len(operation.Path); i++ {
+			if !exists(operation.Path[:i]) {
+				depth = i
+				break
+			}
+		}
+
+		if depth == 0 {
+			created.Operations = append(created.Operations, operation)
+			continue
+		}
+		missing = true
+
+		if operation.Op != OpSet {
+			if operation.Op != OpRemove {
+				created.Operations = append(created.Operations, operation)
+			}
+			continue
+		}
+
+		parent := operation.Path[:depth]
+		id := strings.Join(parent, "/")
+		value, found := parents[id]
+		if !found {
+			value = map[string]interface{}{}
+			parents[id] = value
+			created.Operations = append(created.Operations, Operation{Op: OpSet, Path: parent, Value: value})
+		}
+
+		// the intermediate objects are created along with the parent
+		for _, segment := range operation.Path[depth : len(operation.Path)-1] {
+			next, ok := value[segment].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				value[segment] = next
+			}
+			value = next
+		}
+		value[operation.Path[len(operation.Path)-1]] = operation.Value
+	}
+
+	return created, missing
+}
+
+// PathString returns the path of the operation as written on the validation errors, like
+// 'Address.Street' or 'Phones[0]'
+func (o Operation) PathString() string {
+	var builder strings.Builder
+	for i, segment := range o.Path {
+		switch {
+		case i > 0 && IsIndex(segment):
+			fmt.Fprintf(&builder, "[%s]", segment)
+		case i > 0:
+			builder.WriteString("." + segment)
+		default:
+			builder.WriteString(segment)
+		}
+	}
+
+	return builder.String()
+}
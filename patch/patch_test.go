@@ -0,0 +1,133 @@
+package patch
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMergePatch(t *testing.T) {
+	p, err := ParseMergePatch([]byte(`{"UserID": "42", "Nickname": null, "Address": {"City": "Lisbon", "Geo": {}}}`))
+	if err != nil {
+		t.Fatalf("ParseMergePatch() error = %v", err)
+	}
+
+	want := []Operation{
+		{Op: OpSet, Path: []string{"Address", "City"}, Value: "Lisbon"},
+		{Op: OpSet, Path: []string{"Address", "Geo"}, Value: map[string]interface{}{}},
+		{Op: OpRemove, Path: []string{"Nickname"}},
+		{Op: OpSet, Path: []string{"UserID"}, Value: "42"},
+	}
+	if !p.Merge || !reflect.DeepEqual(p.Operations, want) {
+		t.Errorf("ParseMergePatch() = %+v, want merge %+v", p, want)
+	}
+}
+
+func TestParseJSONPatch(t *testing.T) {
+	p, err := ParseJSONPatch([]byte(`[
+		{"op": "test", "path": "/Status", "value": "active"},
+		{"op": "replace", "path": "/Name", "value": "Ana"},
+		{"op": "remove", "path": "/Nickname"},
+		{"op": "add", "path": "/Phones/-", "value": "+351"},
+		{"op": "add", "path": "/Phones/0", "value": "+1"},
+		{"op": "add", "path": "/a~1b/c~0d", "value": 1},
+		{"op": "increment", "path": "/LoginCount", "value": 1}
+	]`))
+	if err != nil {
+		t.Fatalf("ParseJSONPatch() error = %v", err)
+	}
+
+	want := []Operation{
+		{Op: OpTest, Path: []string{"Status"}, Value: "active"},
+		{Op: OpSet, Path: []string{"Name"}, Value: "Ana", MustExist: true},
+		{Op: OpRemove, Path: []string{"Nickname"}, MustExist: true},
+		{Op: OpAppend, Path: []string{"Phones"}, Value: []interface{}{"+351"}},
+		{Op: OpSet, Path: []string{"Phones", "0"}, Value: "+1", Insert: true},
+		{Op: OpSet, Path: []string{"a/b", "c~d"}, Value: 1.0},
+		{Op: OpIncrement, Path: []string{"LoginCount"}, Value: 1.0},
+	}
+	if p.Merge || !reflect.DeepEqual(p.Operations, want) {
+		t.Errorf("ParseJSONPatch() = %+v, want %+v", p.Operations, want)
+	}
+}
+
+func TestParseJSONPatchErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     string
+	}{
+		{name: "move", document: `[{"op": "move", "from": "/a", "path": "/b"}]`, want: "not supported"},
+		{name: "unknown", document: `[{"op": "merge", "path": "/a", "value": 1}]`, want: "unknown operation"},
+		{name: "missing value", document: `[{"op": "add", "path": "/a"}]`, want: "requires a value"},
+		{name: "root", document: `[{"op": "add", "path": "/", "value": 1}]`, want: "must point to an attribute"},
+		{name: "not a list", document: `{"op": "add"}`, want: "invalid json patch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseJSONPatch([]byte(tt.document))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseJSONPatch() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseByMediaType(t *testing.T) {
+	if p, err := Parse("application/json", []byte(`{"a": 1}`)); err != nil || !p.Merge {
+		t.Errorf("Parse(application/json) = %+v, %v, want a merge patch", p, err)
+	}
+	if p, err := Parse(ContentTypeJSONPatch, []byte(`[]`)); err != nil || p.Merge {
+		t.Errorf("Parse(%s) = %+v, %v, want a json patch", ContentTypeJSONPatch, p, err)
+	}
+	if _, err := Parse("text/plain", nil); err == nil {
+		t.Errorf("Parse(text/plain) error = nil, want an error")
+	}
+}
+
+func TestTake(t *testing.T) {
+	p, _ := ParseMergePatch([]byte(`{"UserID": "42", "Name": "Ana", "Address": {"UserID": "x"}}`))
+
+	taken := p.Take([]string{"UserID"})
+	if want := map[string]interface{}{"UserID": "42"}; !reflect.DeepEqual(taken, want) {
+		t.Errorf("Take() = %v, want %v", taken, want)
+	}
+	if got := p.Attributes(); !reflect.DeepEqual(got, []string{"Address", "Name"}) {
+		t.Errorf("Attributes() = %v, want [Address Name]", got)
+	}
+}
+
+func TestCreateParents(t *testing.T) {
+	p, _ := ParseMergePatch([]byte(`{"Name": "Ana", "Address": {"Street": "Main", "Geo": {"Lat": 1}, "Old": null}}`))
+
+	existing := map[string]bool{"Name": true}
+	created, missing := p.CreateParents(func(path []string) bool {
+		return existing[strings.Join(path, "/")]
+	})
+	if !missing {
+		t.Fatalf("CreateParents() missing = false, want true")
+	}
+
+	want := []Operation{
+		{Op: OpSet, Path: []string{"Address"}, Value: map[string]interface{}{
+			"Street": "Main",
+			"Geo":    map[string]interface{}{"Lat": 1.0},
+		}},
+		{Op: OpSet, Path: []string{"Name"}, Value: "Ana"},
+	}
+	if !created.Merge || !reflect.DeepEqual(created.Operations, want) {
+		t.Errorf("CreateParents() = %+v, want %+v", created.Operations, want)
+	}
+
+	if _, missing := p.CreateParents(func([]string) bool { return true }); missing {
+		t.Errorf("CreateParents() missing = true with every parent, want false")
+	}
+}
+
+func TestPathString(t *testing.T) {
+	o := Operation{Path: []string{"Address", "Phones", "0", "Number"}}
+	if got := o.PathString(); got != "Address.Phones[0].Number" {
+		t.Errorf("PathString() = %q, want Address.Phones[0].Number", got)
+	}
+}
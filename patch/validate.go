@@ -0,0 +1,127 @@
+package patch
+
+import (
+	"fmt"
+
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+// Validate checks the operations against the schema of the items, converting their values into the
+// types of the attributes they change. The attributes must be declared by the schema, only the
+// optional ones can be removed, increments require numbers and the list operations require lists.
+// The lists of the schema are stored as DynamoDB lists, so they can't be changed as sets, and the
+// values can't be inserted into them, only appended. When an operation doesn't fit the schema, a
+// *schema.ValidationError listing every violation is returned.
+func (p *Patch) Validate(s *schema.Schema) error {
+	var errors []schema.FieldError
+
+	for i, operation := range p.Operations {
+		value, fieldError := validate(s.Element(), operation)
+		if fieldError != nil {
+			fieldError.Path = operation.PathString()
+			errors = append(errors, *fieldError)
+			continue
+		}
+		p.Operations[i].Value = value
+
+		// the indexes validated are keys of maps, whose values are assigned
+		p.Operations[i].Insert = false
+	}
+
+	if len(errors) > 0 {
+		return &schema.ValidationError{Errors: errors}
+	}
+
+	return nil
+}
+
+// validate checks an operation, returning its value converted into the type of the attribute
+func validate(s *schema.Schema, operation Operation) (interface{}, *schema.FieldError) {
+	target, field, err := resolve(s, operation.Path, operation.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	t := target.NonNull()
+
+	if operation.Insert {
+		parent, _, _ := resolve(s, operation.Path[:len(operation.Path)-1], operation.Value)
+		if parent != nil && parent.NonNull().Type == schema.Array {
+			return nil, fail(parent.String(), operation.Value, "values can't be inserted into lists, use '-' to append or replace the element")
+		}
+	}
+
+	switch operation.Op {
+	case OpRemove:
+		if field != nil && !field.Optional && !field.HasDefault && !field.Schema.Nullable() {
+			return nil, fail(target.String(), nil, "required attribute cannot be removed")
+		}
+		return nil, nil
+	case OpIncrement:
+		switch t.Type {
+		case schema.Int, schema.Long, schema.Float, schema.Double:
+		default:
+			return nil, fail(t.String(), operation.Value, "only numbers can be incremented")
+		}
+		return coerce(t, operation.Value)
+	case OpAppend:
+		if t.Type != schema.Array {
+			return nil, fail(t.String(), operation.Value, "the %s operation requires a list", operation.Op)
+		}
+		return coerce(t, operation.Value)
+	case OpAddToSet, OpDeleteFromSet:
+		// the arrays are written as lists (L), which DynamoDB doesn't change as sets (SS, NS)
+		return nil, fail(t.String(), operation.Value, "the %s operation doesn't apply to lists, use append or set", operation.Op)
+	default:
+		return coerce(target, operation.Value)
+	}
+}
+
+// resolve returns the schema of the attribute found on the path and, when it is the field of a
+// record, the field itself
+func resolve(s *schema.Schema, path []string, value interface{}) (*schema.Schema, *schema.Field, *schema.FieldError) {
+	current := s
+	var field *schema.Field
+
+	for i, segment := range path {
+		t := current.NonNull()
+
+		switch {
+		case t.Type == schema.Record:
+			if field = t.Field(segment); field == nil {
+				return nil, nil, fail("declared attribute", value, "unknown attribute %s", segment)
+			}
+			current = field.Schema
+		case t.Type == schema.Map:
+			field, current = nil, t.Values
+		case t.Type == schema.Array && i > 0 && IsIndex(segment):
+			field, current = nil, t.Items
+		default:
+			return nil, nil, fail(t.String(), value, "segment %s cannot be resolved on %s", segment, t)
+		}
+	}
+
+	return current, field, nil
+}
+
+// coerce converts a value into the type of the schema
+func coerce(s *schema.Schema, value interface{}) (interface{}, *schema.FieldError) {
+	coerced, err := s.Coerce(value)
+	if err != nil {
+		if validationErr, ok := err.(*schema.ValidationError); ok && len(validationErr.Errors) > 0 {
+			fieldError := validationErr.Errors[0]
+			return nil, &fieldError
+		}
+		return nil, fail(s.String(), value, "%v", err)
+	}
+
+	return coerced, nil
+}
+
+func fail(expected string, value interface{}, format string, args ...interface{}) *schema.FieldError {
+	return &schema.FieldError{
+		Expected: expected,
+		Received: schema.Kind(value),
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
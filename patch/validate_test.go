@@ -0,0 +1,96 @@
+package patch
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+const userSchema = `{
+  "type": "object",
+  "properties": {
+    "UserID": { "type": "string" },
+    "Name": { "type": "string" },
+    "Nickname": { "type": "string" },
+    "LoginCount": { "type": "integer" },
+    "Phones": { "type": "array", "items": { "type": "string" } },
+    "Labels": { "type": "object", "additionalProperties": { "type": "string" } },
+    "Address": {
+      "type": "object",
+      "properties": { "Street": { "type": "string" } }
+    }
+  },
+  "required": ["UserID", "Name"]
+}`
+
+func TestValidate(t *testing.T) {
+	s, err := schema.Parse([]byte(userSchema))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		document  string
+		wantError string
+	}{
+		{name: "set nested", document: `[{"op": "add", "path": "/Address/Street", "value": "Main"}]`},
+		{name: "increment", document: `[{"op": "increment", "path": "/LoginCount", "value": 1}]`},
+		{name: "append", document: `[{"op": "add", "path": "/Phones/-", "value": "+351"}]`},
+		{name: "replace element", document: `[{"op": "replace", "path": "/Phones/0", "value": "+351"}]`},
+		{name: "add to map", document: `[{"op": "add", "path": "/Labels/0", "value": "zero"}]`},
+		{name: "remove optional", document: `[{"op": "remove", "path": "/Nickname"}]`},
+		{name: "unknown attribute", document: `[{"op": "add", "path": "/Unknown", "value": 1}]`, wantError: "unknown attribute"},
+		{name: "remove required", document: `[{"op": "remove", "path": "/Name"}]`, wantError: "cannot be removed"},
+		{name: "increment string", document: `[{"op": "increment", "path": "/Name", "value": 1}]`, wantError: "only numbers"},
+		{name: "append to string", document: `[{"op": "append", "path": "/Name", "value": ["a"]}]`, wantError: "requires a list"},
+		{name: "insert into list", document: `[{"op": "add", "path": "/Phones/0", "value": "+1"}]`, wantError: "can't be inserted"},
+		{name: "set operation on list", document: `[{"op": "add_to_set", "path": "/Phones", "value": ["+1"]}]`, wantError: "doesn't apply to lists"},
+		{name: "wrong type", document: `[{"op": "replace", "path": "/LoginCount", "value": "many"}]`, wantError: "expected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseJSONPatch([]byte(tt.document))
+			if err != nil {
+				t.Fatalf("ParseJSONPatch() error = %v", err)
+			}
+
+			err = p.Validate(s)
+			if tt.wantError == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var validationErr *schema.ValidationError
+			if !errors.As(err, &validationErr) || len(validationErr.Errors) != 1 {
+				t.Fatalf("Validate() error = %v, want a *schema.ValidationError", err)
+			}
+			if message := validationErr.Errors[0].Message; !strings.Contains(message, tt.wantError) {
+				t.Errorf("Validate() message = %q, want %q", message, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestValidateConvertsTheValues(t *testing.T) {
+	s, _ := schema.Parse([]byte(userSchema))
+	p, _ := ParseJSONPatch([]byte(`[
+		{"op": "replace", "path": "/LoginCount", "value": "7"},
+		{"op": "add", "path": "/Labels/0", "value": "zero"}
+	]`))
+
+	if err := p.Validate(s); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if got := p.Operations[0].Value; got != int64(7) {
+		t.Errorf("Value = %#v, want int64(7)", got)
+	}
+	if p.Operations[1].Insert {
+		t.Errorf("Insert = true on a map key, want false")
+	}
+}
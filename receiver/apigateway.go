@@ -1,30 +1,36 @@
 package receiver
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/connector"
 	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+	"github.com/raywall/aws-lowcode-lambda-go/render"
 )
 
 type ActionRequested string
 
-// Conf refers to the lambda function's configuration, containing all the necessary information
-// about the request, database, and response parameters that the client uses to orchestrate requests.
-var conf = &config.Global
-var svc *dynamodb.DynamoDB
-
 const (
 	Create ActionRequested = "POST"
 	Read   ActionRequested = "GET"
 	Update ActionRequested = "PUT"
 	Delete ActionRequested = "DELETE"
+	Patch  ActionRequested = "PATCH"
+	// Restore is requested by a POST to the 'RestorePath' of the soft deletes of the connector
+	Restore ActionRequested = "RESTORE"
+)
+
+// Paths that receive the batches of records to read and delete with the POST method, for the
+// clients unable to send bodies with the GET and DELETE methods, like '/users/_batch/get'.
+const (
+	BatchReadPath   = "/_batch/get"
+	BatchDeletePath = "/_batch/delete"
 )
 
 // handleAPIGatewayEvent é uma função interna que valida a requisição recebida do gateway e
@@ -33,228 +39,181 @@ const (
 // É importante destacar, que apenas os métodos http indicados como permitido na configuração
 // da função serão permitidos.
 //
-// Caso o método enviado não seja suportado pela função ainda, ela responderá com um código 400
-func HandleAPIGatewayEvent(event events.APIGatewayProxyRequest, client *dynamodb.DynamoDB) *lowcodeattribute.ExecutionResponse {
-	svc = client
-
-	var data map[string]interface{}
-	err := json.Unmarshal([]byte(event.Body), &data)
-	if err != nil {
-		return &lowcodeattribute.ExecutionResponse{
-			StatusCode: 500,
-			Error:      err,
-		}
-	}
-
-	jsonMap, err := conf.Resources.Receiver.EncodeJSON(data)
-	if err != nil {
-		return &lowcodeattribute.ExecutionResponse{
-			StatusCode: 500,
-			Error:      err,
-		}
-	}
-
-	switch ActionRequested(event.HTTPMethod) {
-	case Create:
-		return saveToDynamoDB(jsonMap)
-	case Read:
-		return readFromDynamoDB(jsonMap)
-	case Update:
-		return updateOnDynamoDB(jsonMap)
-	case Delete:
-		return deleteOnDynamoDB(jsonMap)
-	default:
-		return &lowcodeattribute.ExecutionResponse{
-			StatusCode: 404,
-			Message:    fmt.Sprintf("method unsupported: %s", event.HTTPMethod),
-		}
-	}
-}
-
-// saveToDynamoDB é uma função interna responsável por inserir um novo registro do DynamoDB na tabela
-// que foi previamente indicado na configuração da função.
+// Caso o método enviado não seja suportado pela função ainda, ela responderá com um código 400.
 //
-// Se o novo ítem for inserido com sucesso na tabela, a função retornará um código de status 201, indicando
-// que o registro foi criado com sucesso, no entanto, se algo der errado, ele deverá retornar um erro 500 e
-// indicar uma mensagem com o erro ocorrido.
+// O corpo da requisição pode ser um json ou, de acordo com o header Content-Type, um registro avro
+// binário (avro/binary, codificado em base64 pelo API Gateway) ou um Object Container File avro com
+// um lote de registros (application/vnd.apache.avro+ocf). Receivers descritos por
+// uma mensagem protobuf ('ProtoMessage') aceitam também o formato binário (application/x-protobuf)
+// e o mapeamento JSON do protobuf.
 //
-// Os dados a serem registrados na tabela devem ser indicados no corpo da requisição e o caminho do
-// arquivo avsc (avro) com a estrutura do objeto deve ter sido especificado na configuração da função
+// Se a configuração tiver a seção 'Mapping', o registro do receiver é transformado no registro do
+// conector pelas regras da seção, que podem usar também os parâmetros de path, headers, query string
+// e claims da requisição, e os registros retornados pelo conector são convertidos de volta. A seção
+// 'Expressions' pode calcular campos, validar regras entre campos e trocar a ação executada por
+// meio de expressões CEL.
 //
-// Para usar esta função, você também precisa especificar o Nome da Tabela do DynamoDB e as chaves que
-// compõem a chave primária da tabela.
-func saveToDynamoDB(data interface{}) *lowcodeattribute.ExecutionResponse {
-	item, err := dynamodbattribute.MarshalMap(data)
-	if err != nil {
-		return &lowcodeattribute.ExecutionResponse{
-			StatusCode: 500,
-			Message:    fmt.Sprintf("failed marshal data: %v", err),
-			Error:      err,
+// Os atributos declarados na seção 'Generators', como identificadores, contadores, datas de criação e
+// TTL, são gerados pela função antes da validação do registro. Na criação de um ítem, a resposta 201
+// traz as chaves do ítem criado e o header Location, montado a partir do 'AllowedPath' do método GET.
+//
+// Os parâmetros do path, como o 'UserID' de '/users/{UserID}', são incluídos no registro das
+// leituras (GET), substituições (PUT) e remoções (DELETE), e devem coincidir com o corpo.
+//
+// Arrays json e Object Container Files carregam lotes de registros, que são criados (POST), lidos
+// (GET ou POST em '/_batch/get') ou removidos (DELETE ou POST em '/_batch/delete') em conjunto pelos
+// conectores que suportam lotes, como o DynamoDB, e individualmente pelos demais. A resposta traz o
+// resultado de cada registro, com o código 207 quando algum deles falhar.
+//
+// O método PUT substitui o ítem inteiro pelo registro recebido, validado pelo schema do receiver,
+// enquanto o método PATCH aplica uma atualização parcial descrita por um JSON Merge Patch
+// (application/merge-patch+json, padrão para corpos json), no qual os atributos nulos são removidos,
+// ou por um JSON Patch (application/json-patch+json), cujas operações incluem incrementos, adições e
+// remoções em conjuntos e listas. Os caminhos do patch usam os nomes dos atributos do conector.
+//
+// Se a configuração tiver a seção 'Idempotency', as escritas que trazem o header 'Idempotency-Key'
+// são executadas uma única vez: a resposta da primeira requisição é gravada na tabela de
+// idempotência e repetida, com o header 'Idempotent-Replayed', para as requisições com a mesma chave
+// até que ela expire. Uma chave usada por outro payload é recusada com o código 422, e uma chave cuja
+// requisição ainda está em andamento, com o código 409.
+//
+// Com a configuração 'SoftDelete' do conector, o método DELETE apenas marca os ítens como removidos,
+// escondendo-os das leituras, e um POST no caminho 'RestorePath' (como '/users/_restore') restaura os
+// ítens indicados no corpo da requisição.
+//
+// Quando o corpo da requisição não for válido ou não corresponder ao schema do receiver, a
+// função responderá com um código 400 indicando cada campo inválido. As demais falhas são
+// classificadas pelo seu tipo (não encontrado, conflito, acesso negado, limite excedido, falha de
+// um serviço ou erro interno), que define o código de status, e descritas no formato
+// application/problem+json (RFC 7807).
+//
+// A configuração (conf) contém todas as informações necessárias sobre a requisição, o banco de dados
+// e os parâmetros de resposta usados para orquestrar as requisições, enquanto o conector (conn) é
+// responsável por executar a ação solicitada.
+func HandleAPIGatewayEvent(ctx context.Context, event events.APIGatewayProxyRequest, conf *config.Config, conn connector.Connector) *lowcodeattribute.ExecutionResponse {
+	contentType := mapping.HeaderValue(event.Headers, "Content-Type")
+
+	body := []byte(event.Body)
+	if event.IsBase64Encoded {
+		var err error
+		if body, err = decodeBase64(event.Body); err != nil {
+			return lowcodeattribute.NewBadRequestResponse(err)
 		}
 	}
 
-	input := &dynamodb.PutItemInput{
-		Item:      item,
-		TableName: aws.String(conf.Resources.Connector.Properties.TableName),
+	// the retries of the writes identified by a key replay the response of their first request
+	if key := idempotencyKey(event, conf); key != "" {
+		return idempotent(ctx, key, requestHash(event, body), conf, conn, func() *lowcodeattribute.ExecutionResponse {
+			return handleRequest(ctx, event, body, contentType, conf, conn)
+		})
 	}
 
-	_, err = svc.PutItem(input)
-	if err != nil {
-		return &lowcodeattribute.ExecutionResponse{
-			StatusCode: 500,
-			Message:    fmt.Sprintf("failed input new item: %v", err),
-			Error:      err,
-		}
-	}
+	return handleRequest(ctx, event, body, contentType, conf, conn)
+}
 
-	return &lowcodeattribute.ExecutionResponse{
-		StatusCode: 201,
+// handleRequest executes the action requested by the body of an API Gateway request
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest, body []byte, contentType string, conf *config.Config, conn connector.Connector) *lowcodeattribute.ExecutionResponse {
+	// the patches describe changes to an item, not a record of the receiver
+	if ActionRequested(event.HTTPMethod) == Patch {
+		return patchItem(ctx, event, body, conf, conn)
 	}
-}
 
-// readFromDynamoDB é uma função interna que possibilita realizar consultas em uma tabela do DynamoDB
-// previamente indicada nas configurações da função.
-//
-// Se você setar o atributo 'ProjectionCols', a consulta irá retornar apenas as colunas que foram
-// préviamente indicadas.
-//
-// Se você setar os atributos 'Filter' e 'FilterValues', este filtro será aplicado a query, realizando
-// uma consulta mais específica mediante as regras indicadas.
-//
-// Para usar esta função, você também precisa especificar o Nome da Tabela do DynamoDB e as chaves que
-// compõem a chave primária da tabela.
-func readFromDynamoDB(data interface{}) *lowcodeattribute.ExecutionResponse {
-	names, err := conf.Resources.Connector.GetKeyAttributeNames(data)
+	records, batch, err := decodePayload(ctx, body, contentType, &conf.Resources.Receiver)
 	if err != nil {
-		return &lowcodeattribute.ExecutionResponse{
-			StatusCode: 500,
-			Message:    fmt.Sprintf("failed getting attribute names: %v", err),
-			Error:      err,
-		}
+		return lowcodeattribute.NewBadRequestResponse(err)
 	}
 
-	values, err := conf.Resources.Connector.GetKeyAttributeValues(data)
-	if err != nil {
-		return &lowcodeattribute.ExecutionResponse{
-			StatusCode: 500,
-			Message:    fmt.Sprintf("failed getting attribute values: %v", err),
-			Error:      err,
+	action := requestedAction(event, conf)
+
+	// arrays and container files carry a batch of records, which can't be updated together
+	if batch {
+		if action == Update {
+			return lowcodeattribute.NewBadRequestResponse(fmt.Errorf("batches are not accepted by the %s method", Update))
 		}
+
+		return lowcodeattribute.NewBatchResponse(processRecords(ctx, action, records, gatewayRequest(event), conf, conn))
 	}
 
-	conditions, err := conf.Resources.Connector.GetKeyConditions(data)
-	if err != nil {
-		return &lowcodeattribute.ExecutionResponse{
-			StatusCode: 500,
-			Message:    fmt.Sprintf("failed to execute a table query: %v", err),
-			Error:      err,
+	// the items read, replaced and deleted are addressed by the parameters of the path too
+	if action == Read || action == Update || action == Delete {
+		if err := mergePathParameters(event, records[0]); err != nil {
+			return lowcodeattribute.NewBadRequestResponse(err)
 		}
 	}
 
-	queryInput := &dynamodb.QueryInput{
-		TableName:                 aws.String(conf.Resources.Connector.Properties.TableName),
-		KeyConditionExpression:    aws.String(conditions),
-		ExpressionAttributeNames:  names,
-		ExpressionAttributeValues: values,
-	}
-	log.Println("queryInput:", queryInput) // remover
-	result, err := svc.Query(queryInput)
+	jsonMap, err := encodeRecord(ctx, action, records[0], conf, conn)
 	if err != nil {
-		return &lowcodeattribute.ExecutionResponse{
-			StatusCode: 500,
-			Message:    fmt.Sprintf("failed to execute a table query: %v", err),
-			Error:      err,
-		}
+		return failure(err)
 	}
 
-	var jsonMap []map[string]interface{}
-	err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &jsonMap)
-	if err != nil {
-		return &lowcodeattribute.ExecutionResponse{
-			StatusCode: 500,
-			Error:      fmt.Errorf("failed to deserialize response: %v", err),
-		}
+	response := execute(ctx, action, jsonMap, gatewayRequest(event), conf, conn)
+
+	if url := location(event, conf, response); url != "" {
+		response.SetHeader("Location", url)
 	}
 
-	jsonResponse, err := json.Marshal(jsonMap)
-	if err != nil {
-		return &lowcodeattribute.ExecutionResponse{
-			StatusCode: 500,
-			Error:      fmt.Errorf("failed to serialize query result: %v", err),
+	return response
+}
+
+// mergePathParameters copies the parameters of the path, like the 'UserID' of '/users/{UserID}',
+// into the record, failing when the body informs another value for them
+func mergePathParameters(event events.APIGatewayProxyRequest, record map[string]interface{}) error {
+	for name, value := range event.PathParameters {
+		current, found := record[name]
+		if !found {
+			record[name] = value
+			continue
+		}
+		if fmt.Sprint(current) != value {
+			return fmt.Errorf("the attribute %s of the body doesn't match the path", name)
 		}
 	}
 
-	return &lowcodeattribute.ExecutionResponse{
-		StatusCode: 200,
-		Message:    string(jsonResponse),
-	}
+	return nil
 }
 
-// updateOnDynamoDB é uma função interna responsável por atualizar, remover ou adicionar os atributos
-// de uma tabela do DynamoDB previamente especificada nas configurações da função. Se o ítem for atualizado
-// com sucesso, a função retornará um código de status 200 em resposta a sua requisição, entretant,
-// se algo der errado, ela retornará o status 500 jutamente com a descrição do erro.
-//
-// Os atributos do ítem que serão modificados, juntamente com os atributos da chave primária precisam ser
-// enviados no corpo da requisição para que a atualização seja efetuada.
-func updateOnDynamoDB(data interface{}) *lowcodeattribute.ExecutionResponse {
-	keys, _ := conf.Resources.Connector.GetPrimaryKeyAttributeValue(data)
-	names, _ := conf.Resources.Connector.GetAttributeNames(data)
-	values, _ := conf.Resources.Connector.GetAttributeValues(data)
-	updateExpr, _ := conf.Resources.Connector.GetUpdateExpression(data)
-
-	updateInput := &dynamodb.UpdateItemInput{
-		TableName:                 aws.String(conf.Resources.Connector.Properties.TableName),
-		UpdateExpression:          aws.String(updateExpr),
-		ExpressionAttributeNames:  names,
-		ExpressionAttributeValues: values,
-		Key:                       keys,
+// requestedAction returns the action requested by the method of the request or, on the POST
+// requests to the batch and restore paths, by the path
+func requestedAction(event events.APIGatewayProxyRequest, conf *config.Config) ActionRequested {
+	action := ActionRequested(event.HTTPMethod)
+	if action != Create {
+		return action
 	}
 
-	_, err := svc.UpdateItem(updateInput)
-	if err != nil {
-		return &lowcodeattribute.ExecutionResponse{
-			StatusCode: 500,
-			Error:      err,
-		}
-	}
+	path := strings.TrimSuffix(event.Path, "/")
+	restore := strings.TrimSuffix(conf.Resources.Connector.RestorePath(), "/")
 
-	return &lowcodeattribute.ExecutionResponse{
-		StatusCode: 200,
+	switch {
+	case restore != "" && strings.HasSuffix(path, restore):
+		return Restore
+	case strings.HasSuffix(path, BatchReadPath):
+		return Read
+	case strings.HasSuffix(path, BatchDeletePath):
+		return Delete
+	default:
+		return action
 	}
 }
 
-// delete is an internal function responsible for remove an item of the DynamoDB table using the settings
-// specified in your configuration file. If the item is removed successfully, you will receive a 200 (Ok)
-// status code in response of your request. However, if something goes wrong, you will receive a 500 status
-// code and an error specifying the problem
-//
-// you need to send the values of the keys in your request to properly remove the item
-//
-// To use this function, you need to specify the 'TableName' and 'Keys' in your configuration file.
-func deleteOnDynamoDB(data interface{}) *lowcodeattribute.ExecutionResponse {
-	keys, err := conf.Resources.Connector.GetPrimaryKeyAttributeValue(data.(map[string]interface{}))
-	if err != nil {
-		return &lowcodeattribute.ExecutionResponse{
-			StatusCode: 500,
-			Error:      fmt.Errorf("failed to get primary key: %v", err),
-		}
+// GatewayResponse converts the response of an API Gateway request into the response sent to its
+// client, following the 'Response' section of the configuration: the format is negotiated with the
+// Accept header, the fields are selected by the 'fields' query parameter and the body is compressed
+// when the client accepts it.
+func GatewayResponse(event events.APIGatewayProxyRequest, conf *config.Config, response *lowcodeattribute.ExecutionResponse) (events.APIGatewayProxyResponse, error) {
+	req := render.Request{
+		Accept:         mapping.HeaderValue(event.Headers, "Accept"),
+		AcceptEncoding: mapping.HeaderValue(event.Headers, "Accept-Encoding"),
+		Fields:         render.ParseFields(event.QueryStringParameters["fields"]),
+		Instance:       event.Path,
 	}
 
-	deleteInput := dynamodb.DeleteItemInput{
-		TableName: aws.String(conf.Resources.Connector.Properties.TableName),
-		Key:       keys,
+	// the failures are answered to the client, so their causes are only logged
+	if response.StatusCode >= 500 && response.Error != nil {
+		log.Printf("request %s %s failed: %v", event.HTTPMethod, event.Path, response.Error)
 	}
 
-	_, err = svc.DeleteItem(&deleteInput)
-	if err != nil {
-		return &lowcodeattribute.ExecutionResponse{
-			StatusCode: 500,
-			Error:      fmt.Errorf("failed to remove table item: %v", err),
-		}
-	}
+	// the records are rendered in the shape of the receiver, so its schema encodes them
+	s, _ := conf.Resources.Receiver.Schema()
 
-	return &lowcodeattribute.ExecutionResponse{
-		StatusCode: 200,
-	}
+	return conf.Resources.Response.Render(req, response, s)
 }
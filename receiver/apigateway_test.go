@@ -0,0 +1,137 @@
+package receiver
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+)
+
+const userSchema = `{
+  "type": "object",
+  "properties": {
+    "UserID": { "type": "string" },
+    "Name": { "type": "string" },
+    "Age": { "type": "integer" }
+  },
+  "required": ["UserID", "Name"]
+}`
+
+const userConfig = `Resources:
+  Receiver:
+    ResourceType: ApiGateway
+    ObjectPathSchema: user.json
+    Properties:
+      AllowedMethods: [GET, POST, PUT, DELETE]
+      AllowedPath:
+        GET: /users/{UserID}
+  Connector:
+    ResourceType: DynamoDB
+    Properties:
+      TableName: users
+      Keys:
+        PK: EQ
+  Mapping:
+    Request:
+      - Target: PK
+        Source: UserID
+      - Target: Profile.Name
+        Source: Name
+      - Target: Age
+        Source: Age
+  Response:
+    Envelope: true
+`
+
+// stubConnector keeps the records it receives and answers them back
+type stubConnector struct {
+	received interface{}
+}
+
+func (c *stubConnector) answer(status int, data interface{}) *lowcodeattribute.ExecutionResponse {
+	c.received = data
+	return &lowcodeattribute.ExecutionResponse{StatusCode: status, Message: data}
+}
+
+func (c *stubConnector) Create(_ context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	return c.answer(http.StatusCreated, data)
+}
+
+func (c *stubConnector) Read(_ context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	return c.answer(http.StatusOK, map[string]interface{}{"PK": "42", "Profile": map[string]interface{}{"Name": "Ana"}, "Age": 30})
+}
+
+func (c *stubConnector) Update(_ context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	return c.answer(http.StatusOK, data)
+}
+
+func (c *stubConnector) Delete(_ context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	return c.answer(http.StatusNoContent, nil)
+}
+
+func loadConfig(t testing.TB) *config.Config {
+	t.Helper()
+
+	conf, err := config.LoadFrom(config.NewMemorySource(map[string][]byte{
+		"config.yaml": []byte(userConfig),
+		"user.json":   []byte(userSchema),
+	}), "config.yaml")
+	if err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+
+	return conf
+}
+
+func TestHandleAPIGatewayEventReadsByThePath(t *testing.T) {
+	conf, conn := loadConfig(t), &stubConnector{}
+
+	event := events.APIGatewayProxyRequest{
+		HTTPMethod:     "GET",
+		Path:           "/users/42",
+		PathParameters: map[string]string{"UserID": "42"},
+	}
+	response := HandleAPIGatewayEvent(context.Background(), event, conf, conn)
+
+	if got := conn.received.(map[string]interface{})["PK"]; got != "42" {
+		t.Errorf("the connector received %v, want the key of the path", conn.received)
+	}
+
+	gateway, err := GatewayResponse(event, conf, response)
+	if err != nil {
+		t.Fatalf("GatewayResponse() error = %v", err)
+	}
+	if want := `{"data":{"Age":30,"Name":"Ana","UserID":"42"}}`; gateway.Body != want {
+		t.Errorf("GatewayResponse() body = %s, want %s", gateway.Body, want)
+	}
+}
+
+func TestHandleAPIGatewayEventRefusesInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name  string
+		event events.APIGatewayProxyRequest
+	}{
+		{name: "invalid json", event: events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"UserID":`}},
+		{name: "schema", event: events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"UserID": "42"}`}},
+		{name: "batch update", event: events.APIGatewayProxyRequest{HTTPMethod: "PUT", Body: `[{"UserID": "42", "Name": "Ana"}]`}},
+		{name: "path mismatch", event: events.APIGatewayProxyRequest{
+			HTTPMethod:     "DELETE",
+			Body:           `{"UserID": "1"}`,
+			PathParameters: map[string]string{"UserID": "42"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &stubConnector{}
+
+			response := HandleAPIGatewayEvent(context.Background(), tt.event, loadConfig(t), conn)
+			if response.StatusCode != http.StatusBadRequest || conn.received != nil {
+				t.Errorf("HandleAPIGatewayEvent() = %d, connector received %v, want 400", response.StatusCode, conn.received)
+			}
+		})
+	}
+}
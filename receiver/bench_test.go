@@ -0,0 +1,30 @@
+package receiver
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// BenchmarkHandleAPIGatewayEvent measures the path of a request through the function: the body is
+// decoded, validated by the receiver schema and mapped, and the response is mapped back and
+// rendered.
+func BenchmarkHandleAPIGatewayEvent(b *testing.B) {
+	conf, conn := loadConfig(b), &stubConnector{}
+	event := events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Path:       "/users",
+		Headers:    map[string]string{"Content-Type": "application/json", "Accept": "application/json"},
+		Body:       `{"UserID": "42", "Name": "Ana", "Age": 30}`,
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		response := HandleAPIGatewayEvent(context.Background(), event, conf, conn)
+		if _, err := GatewayResponse(event, conf, response); err != nil || response.StatusCode != http.StatusCreated {
+			b.Fatalf("HandleAPIGatewayEvent() = %d, %v", response.StatusCode, err)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package receiver
 
 import (
+	"context"
+
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/connector"
 )
 
-func HandleDynamoDBEvent(event events.DynamoDBEvent, client *dynamodb.DynamoDB) string {
+func HandleDynamoDBEvent(ctx context.Context, event events.DynamoDBEvent, conf *config.Config, conn connector.Connector) string {
 	return "DynamoDB event received"
 }
@@ -0,0 +1,90 @@
+package receiver
+
+import (
+	"context"
+
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/connector"
+	"github.com/raywall/aws-lowcode-lambda-go/expression"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+)
+
+// execute runs the action requested for a record encoded by the receiver. The routes of the
+// configuration may replace the action, the computed fields and validation rules are applied to
+// the records written, and the record is mapped into the connector record before the connector
+// executes the action. The request is carried by the context, so the connector can evaluate the
+// values derived from it.
+func execute(ctx context.Context, action ActionRequested, data interface{}, req mapping.Request, conf *config.Config, conn connector.Connector) *lowcodeattribute.ExecutionResponse {
+	action, mapped, req, response := prepare(action, data, req, conf)
+	if response != nil {
+		return response
+	}
+
+	return dispatch(mapping.NewContext(ctx, req), action, mapped, conf, conn)
+}
+
+// dispatch executes the action requested for a record mapped into the connector record, converting
+// the records returned back into the shape of the receiver
+func dispatch(ctx context.Context, action ActionRequested, mapped interface{}, conf *config.Config, conn connector.Connector) *lowcodeattribute.ExecutionResponse {
+	var response *lowcodeattribute.ExecutionResponse
+
+	switch action {
+	case Create:
+		response = conn.Create(ctx, mapped)
+	case Read:
+		response = conn.Read(ctx, mapped)
+	case Update:
+		response = conn.Update(ctx, mapped)
+	case Delete:
+		response = conn.Delete(ctx, mapped)
+	case Restore:
+		restorer, ok := conn.(connector.Restorer)
+		if !ok {
+			return lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindNotFound, "method unsupported: %s", action))
+		}
+		response = restorer.Restore(ctx, mapped)
+	default:
+		return lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindNotFound, "method unsupported: %s", action))
+	}
+
+	return mapResponse(conf.Resources.Mapping, response)
+}
+
+// prepare routes the record encoded by the receiver, applies the computed fields and validation
+// rules to the records written and maps the record into the connector record. The response is
+// informed when the record must not reach the connector.
+func prepare(action ActionRequested, data interface{}, req mapping.Request, conf *config.Config) (ActionRequested, interface{}, mapping.Request, *lowcodeattribute.ExecutionResponse) {
+	record, ok := data.(map[string]interface{})
+	if !ok {
+		record = map[string]interface{}{}
+	}
+	req.Body = record
+
+	expressions := conf.Resources.Expressions
+
+	action = ActionRequested(expressions.Route(req, string(action)))
+	if action == expression.ActionSkip {
+		return action, nil, req, &lowcodeattribute.ExecutionResponse{StatusCode: 204}
+	}
+
+	var err error
+	if action == Create || action == Update {
+		if req.Body, err = expressions.Apply(req); err != nil {
+			return action, nil, req, failure(err)
+		}
+	}
+
+	mapped, err := mapRecord(conf.Resources.Mapping, req, req.Body)
+	if err != nil {
+		return action, nil, req, failure(err)
+	}
+
+	return action, mapped, req, nil
+}
+
+// failure creates the response of an error found before the execution of the connector, whose
+// status is decided by the kind of the error
+func failure(err error) *lowcodeattribute.ExecutionResponse {
+	return lowcodeattribute.NewErrorResponse(err)
+}
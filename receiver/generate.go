@@ -0,0 +1,72 @@
+package receiver
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/connector"
+	"github.com/raywall/aws-lowcode-lambda-go/generator"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+)
+
+// pathParameter matches the parameters of the allowed paths, like '{UserID}'
+var pathParameter = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// generate fills the attributes generated by the server on the record received, before it is
+// validated by the receiver schema. The counters are incremented by the connector, when it
+// supports them.
+func generate(ctx context.Context, action ActionRequested, record map[string]interface{}, conf *config.Config, conn connector.Connector) error {
+	if len(conf.Resources.Generators) == 0 {
+		return nil
+	}
+
+	counter, _ := conn.(generator.Counter)
+
+	_, err := conf.Resources.Generators.Apply(ctx, string(action), record, counter)
+	return err
+}
+
+// location returns the URL of the item created, built from the path of the request and the GET
+// path allowed by the receiver, filled with the keys returned by the connector. It is empty when
+// the item can't be addressed.
+func location(event events.APIGatewayProxyRequest, conf *config.Config, response *lowcodeattribute.ExecutionResponse) string {
+	if response == nil || response.StatusCode != 201 {
+		return ""
+	}
+
+	keys, ok := response.Message.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	paths := conf.Resources.Receiver.Properties.AllowedPath
+
+	get, ok := paths[string(Read)]
+	if !ok {
+		return ""
+	}
+
+	missing := false
+	path := pathParameter.ReplaceAllStringFunc(get, func(parameter string) string {
+		value, found := keys[strings.Trim(parameter, "{}")]
+		if !found || value == nil {
+			missing = true
+			return parameter
+		}
+		return url.PathEscape(fmt.Sprint(value))
+	})
+	if missing {
+		return ""
+	}
+
+	// the allowed paths are relative to the base path where the function is exposed
+	base := strings.TrimSuffix(event.Path, "/")
+	base = strings.TrimSuffix(base, strings.TrimSuffix(paths[string(Create)], "/"))
+
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,139 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/connector"
+	"github.com/raywall/aws-lowcode-lambda-go/idempotency"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+)
+
+// idempotencyKey returns the idempotency key carried by the request, which is empty when the
+// requests of its method are not identified by keys
+func idempotencyKey(event events.APIGatewayProxyRequest, conf *config.Config) string {
+	settings := conf.Resources.Idempotency
+	if !settings.Guards(event.HTTPMethod) {
+		return ""
+	}
+
+	return mapping.HeaderValue(event.Headers, settings.Header)
+}
+
+// requestHash returns the hash of the method, path and body of the request. JSON bodies are
+// compared regardless of their formatting and the order of their fields.
+func requestHash(event events.APIGatewayProxyRequest, body []byte) string {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err == nil && !decoder.More() {
+		body, _ = json.Marshal(value)
+	}
+
+	return idempotency.Hash([]byte(event.HTTPMethod), []byte(event.Path), body)
+}
+
+// idempotent runs the request identified by the idempotency key received, replaying the response
+// of the request that used the key before. The key can't be reused by other payloads, answered
+// with 422 (Unprocessable Entity), nor while its first request is in progress, answered with 409.
+func idempotent(ctx context.Context, key, hash string, conf *config.Config, conn connector.Connector, run func() *lowcodeattribute.ExecutionResponse) *lowcodeattribute.ExecutionResponse {
+	store, ok := conn.(idempotency.Store)
+	if !ok {
+		return failure(lowcodeattribute.Errorf(lowcodeattribute.KindInternal, "the connector doesn't support idempotency keys"))
+	}
+
+	guard, replay, err := idempotency.Begin(ctx, store, conf.Resources.Idempotency, key, hash)
+	switch {
+	case errors.Is(err, idempotency.ErrPayloadMismatch):
+		return unprocessable(err)
+	case err != nil:
+		return failure(err)
+	case replay != nil:
+		return replay
+	}
+
+	response := run()
+	if err := guard.Finish(ctx, response); err != nil {
+		log.Printf("failed finishing idempotency key %s: %v", key, err)
+	}
+
+	return response
+}
+
+// unprocessable creates the response of the requests reusing the idempotency key of another payload
+func unprocessable(err error) *lowcodeattribute.ExecutionResponse {
+	problem := lowcodeattribute.NewProblem(http.StatusUnprocessableEntity, err)
+	problem.Code = "idempotency_key_reused"
+
+	return &lowcodeattribute.ExecutionResponse{
+		StatusCode: http.StatusUnprocessableEntity,
+		Message:    problem,
+		Error:      err,
+	}
+}
+
+// guardedRecord is a record of a message that is processed, guarded by its idempotency key
+type guardedRecord struct {
+	index int
+	guard *idempotency.Guard
+}
+
+// acquireRecords acquires the idempotency keys read from the 'RecordField' of the records of a
+// message, returning the records that must be processed. The records processed before are skipped,
+// and the ones that can't be processed now are reported as failures.
+func acquireRecords(ctx context.Context, records []map[string]interface{}, conf *config.Config, conn connector.Connector) ([]map[string]interface{}, []guardedRecord, []error) {
+	settings := conf.Resources.Idempotency
+
+	guarded := make([]guardedRecord, 0, len(records))
+	if settings == nil || settings.RecordField == "" {
+		for i := range records {
+			guarded = append(guarded, guardedRecord{index: i})
+		}
+		return records, guarded, nil
+	}
+
+	store, ok := conn.(idempotency.Store)
+	if !ok {
+		return nil, nil, []error{lowcodeattribute.Errorf(lowcodeattribute.KindInternal, "the connector doesn't support idempotency keys")}
+	}
+
+	var (
+		pending  []map[string]interface{}
+		failures []error
+	)
+
+	for i, record := range records {
+		value, found := record[settings.RecordField]
+		if !found || value == nil {
+			pending = append(pending, record)
+			guarded = append(guarded, guardedRecord{index: i})
+			continue
+		}
+
+		data, _ := json.Marshal(record)
+		guard, replay, err := idempotency.Begin(ctx, store, settings, fmt.Sprint(value), idempotency.Hash(data))
+		switch {
+		case errors.Is(err, idempotency.ErrInProgress):
+			// retried later, when the other delivery of the record finished
+			failures = append(failures, lowcodeattribute.Errorf(lowcodeattribute.KindThrottled, "record %d: %v", i, err))
+		case err != nil:
+			failures = append(failures, fmt.Errorf("record %d: %w", i, err))
+		case replay != nil:
+			continue
+		default:
+			pending = append(pending, record)
+			guarded = append(guarded, guardedRecord{index: i, guard: guard})
+		}
+	}
+
+	return pending, guarded, failures
+}
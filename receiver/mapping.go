@@ -0,0 +1,78 @@
+package receiver
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+)
+
+// gatewayRequest returns the parameters of the API Gateway request available to the mapping rules.
+// The claims are the ones of a Cognito authorizer or, for the other authorizers, their context.
+func gatewayRequest(event events.APIGatewayProxyRequest) mapping.Request {
+	claims, ok := event.RequestContext.Authorizer["claims"].(map[string]interface{})
+	if !ok {
+		claims = event.RequestContext.Authorizer
+	}
+
+	return mapping.Request{
+		Path:   event.PathParameters,
+		Header: event.Headers,
+		Query:  event.QueryStringParameters,
+		Claims: claims,
+	}
+}
+
+// mapRecord builds the connector record from the record encoded by the receiver
+func mapRecord(m *mapping.Mapping, req mapping.Request, data interface{}) (interface{}, error) {
+	record, ok := data.(map[string]interface{})
+	if !ok || m == nil {
+		return data, nil
+	}
+
+	req.Body = record
+	return m.Apply(req)
+}
+
+// mapResponse converts the records returned by a successful execution of the connector back into
+// the shape of the receiver
+func mapResponse(m *mapping.Mapping, response *lowcodeattribute.ExecutionResponse) *lowcodeattribute.ExecutionResponse {
+	if m == nil || response == nil || response.StatusCode >= 300 || response.Message == nil {
+		return response
+	}
+
+	mapped, err := reverse(m, response.Message)
+	if err != nil {
+		return &lowcodeattribute.ExecutionResponse{StatusCode: 500, Error: err}
+	}
+	response.Message = mapped
+
+	return response
+}
+
+// reverse converts a record, or a list of records, into the shape of the receiver
+func reverse(m *mapping.Mapping, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return m.Reverse(v)
+	case []map[string]interface{}:
+		records := make([]interface{}, len(v))
+		for i, record := range v {
+			var err error
+			if records[i], err = m.Reverse(record); err != nil {
+				return nil, err
+			}
+		}
+		return records, nil
+	case []interface{}:
+		records := make([]interface{}, len(v))
+		for i, item := range v {
+			var err error
+			if records[i], err = reverse(m, item); err != nil {
+				return nil, err
+			}
+		}
+		return records, nil
+	default:
+		return value, nil
+	}
+}
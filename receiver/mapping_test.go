@@ -0,0 +1,30 @@
+package receiver
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestHandleAPIGatewayEventMapsTheRecord(t *testing.T) {
+	conf, conn := loadConfig(t), &stubConnector{}
+
+	response := HandleAPIGatewayEvent(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Path:       "/users",
+		Body:       `{"UserID": "42", "Name": "Ana", "Age": "30"}`,
+	}, conf, conn)
+
+	if response.StatusCode != http.StatusCreated {
+		t.Fatalf("HandleAPIGatewayEvent() = %d %v, want 201", response.StatusCode, response.Message)
+	}
+
+	// the record is typed by the receiver schema before it is mapped
+	want := map[string]interface{}{"PK": "42", "Profile": map[string]interface{}{"Name": "Ana"}, "Age": int64(30)}
+	if !reflect.DeepEqual(conn.received, want) {
+		t.Errorf("the connector received %#v, want %#v", conn.received, want)
+	}
+}
@@ -0,0 +1,63 @@
+package receiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/connector"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+)
+
+// isContentTypeAttribute reports whether a message attribute informs the content type of its body
+func isContentTypeAttribute(name string) bool {
+	return strings.EqualFold(name, "Content-Type") || strings.EqualFold(name, "contentType")
+}
+
+// handleMessage decodes the body of a message received from a queue or topic and creates its records.
+// The errors returned are classified, so the receivers can decide whether the message is retried.
+// The records carrying the idempotency field of the configuration are created only once.
+func handleMessage(ctx context.Context, body string, contentType string, conf *config.Config, conn connector.Connector) error {
+	payload := []byte(body)
+	if isBinary(contentType) {
+		var err error
+		if payload, err = decodeBase64(body); err != nil {
+			return lowcodeattribute.NewError(lowcodeattribute.KindValidation, "", err)
+		}
+	}
+
+	records, _, err := decodePayload(ctx, payload, contentType, &conf.Resources.Receiver)
+	if err != nil {
+		return lowcodeattribute.NewError(lowcodeattribute.KindValidation, "", err)
+	}
+
+	// the records delivered again, identified by their idempotency field, are skipped
+	records, guarded, failures := acquireRecords(ctx, records, conf, conn)
+
+	for _, result := range processRecords(ctx, Create, records, mapping.Request{}, conf, conn) {
+		record := guarded[result.Index]
+		if record.guard != nil {
+			response := &lowcodeattribute.ExecutionResponse{StatusCode: result.StatusCode, Message: result.Message}
+			if err := record.guard.Finish(ctx, response); err != nil {
+				log.Printf("failed finishing idempotency key of record %d: %v", record.index, err)
+			}
+		}
+
+		if !result.Failed() {
+			continue
+		}
+
+		reason := result.Error
+		if reason == "" {
+			reason = fmt.Sprint(result.Message)
+		}
+		failures = append(failures, lowcodeattribute.Errorf(lowcodeattribute.KindOfStatus(result.StatusCode),
+			"record %d failed with status %d: %s", record.index, result.StatusCode, reason))
+	}
+
+	return errors.Join(failures...)
+}
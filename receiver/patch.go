@@ -0,0 +1,168 @@
+package receiver
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/connector"
+	"github.com/raywall/aws-lowcode-lambda-go/expression"
+	"github.com/raywall/aws-lowcode-lambda-go/generator"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+	"github.com/raywall/aws-lowcode-lambda-go/patch"
+)
+
+// patchItem applies the patch carried by the body of a PATCH request to the item identified by the
+// path parameters or, on merge patches, by the key attributes of the body. The paths of the patch
+// name the attributes of the connector, so the patch is validated with the connector schema and
+// only the key record goes through the mapping rules.
+func patchItem(ctx context.Context, event events.APIGatewayProxyRequest, body []byte, conf *config.Config, conn connector.Connector) *lowcodeattribute.ExecutionResponse {
+	patcher, ok := conn.(connector.Patcher)
+	if !ok {
+		return lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindNotFound, "method unsupported: %s", Patch))
+	}
+
+	media := mediaType(mapping.HeaderValue(event.Headers, "Content-Type"))
+
+	p, err := patch.Parse(media, body)
+	if err != nil {
+		return lowcodeattribute.NewBadRequestResponse(err)
+	}
+
+	res := &conf.Resources.Connector
+
+	record := map[string]interface{}{}
+	for name, value := range event.PathParameters {
+		record[name] = value
+	}
+
+	// only the merge patches carry the keys of the item, the operations of a JSON Patch can't
+	// change them
+	var keys []string
+	if media != patch.ContentTypeJSONPatch {
+		keys = res.KeyFields()
+	}
+	for name, value := range p.Take(keys) {
+		if current, found := record[name]; found && fmt.Sprint(current) != fmt.Sprint(value) {
+			return lowcodeattribute.NewBadRequestResponse(fmt.Errorf("the key attribute %s of the body doesn't match the path", name))
+		}
+		record[name] = value
+	}
+
+	if len(conf.Resources.Generators) > 0 {
+		counter, _ := conn.(generator.Counter)
+
+		generated, err := conf.Resources.Generators.Apply(ctx, string(Patch), map[string]interface{}{}, counter)
+		if err != nil {
+			return failure(err)
+		}
+		for name, value := range generated {
+			p.Set(name, value)
+		}
+	}
+
+	if err := validatePatch(res, p); err != nil {
+		return failure(err)
+	}
+
+	req := gatewayRequest(event)
+
+	data, err := mapRecord(conf.Resources.Mapping, req, record)
+	if err != nil {
+		return failure(err)
+	}
+
+	req.Body = record
+	ctx = mapping.NewContext(ctx, req)
+
+	if x := conf.Resources.Expressions; x != nil && (len(x.Computed) > 0 || len(x.Validations) > 0 || len(x.Routes) > 0) {
+		if response := patchRules(ctx, req, data, p, conf, conn); response != nil {
+			return response
+		}
+	}
+
+	return mapResponse(conf.Resources.Mapping, patcher.Patch(ctx, data, p))
+}
+
+// patchRules runs the item patched through the routes, computed fields and validation rules of the
+// expressions, like the records of the other writes. The current item is read and patched in
+// memory, so the rules see the whole record, and the fields changed by the computed fields are
+// added to the patch. A response is returned when the request is answered by a route or refused
+// by a rule.
+func patchRules(ctx context.Context, req mapping.Request, data interface{}, p *patch.Patch, conf *config.Config, conn connector.Connector) *lowcodeattribute.ExecutionResponse {
+	current := conn.Read(ctx, data)
+	if current.StatusCode >= 300 {
+		return mapResponse(conf.Resources.Mapping, current)
+	}
+
+	item, ok := current.Message.(map[string]interface{})
+	if !ok {
+		return lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindNotFound, "item not found"))
+	}
+
+	document, err := conf.Resources.Mapping.Reverse(p.Apply(item))
+	if err != nil {
+		return failure(err)
+	}
+
+	// the items read hold the numbers as floats, while the rules use the types of the receiver
+	if receiver := &conf.Resources.Receiver; receiver.HasSchema() {
+		encoded, err := receiver.EncodePartialJSON(document)
+		if err != nil {
+			return failure(err)
+		}
+		document, _ = encoded.(map[string]interface{})
+	}
+
+	req.Body = document
+	expressions := conf.Resources.Expressions
+
+	switch action := ActionRequested(expressions.Route(req, string(Patch))); action {
+	case Patch:
+	case expression.ActionSkip:
+		return &lowcodeattribute.ExecutionResponse{StatusCode: 204}
+	default:
+		return execute(ctx, action, document, req, conf, conn)
+	}
+
+	applied, err := expressions.Apply(req)
+	if err != nil {
+		return failure(err)
+	}
+
+	// the computed fields are compared on the connector records, whose attributes the patch changes
+	before, err := mapRecord(conf.Resources.Mapping, req, document)
+	if err != nil {
+		return failure(err)
+	}
+	after, err := mapRecord(conf.Resources.Mapping, req, applied)
+	if err != nil {
+		return failure(err)
+	}
+
+	previous, _ := before.(map[string]interface{})
+	computed, _ := after.(map[string]interface{})
+	for name, value := range computed {
+		if old, found := previous[name]; !found || !reflect.DeepEqual(old, value) {
+			p.Set(name, value)
+		}
+	}
+
+	if err := validatePatch(&conf.Resources.Connector, p); err != nil {
+		return failure(err)
+	}
+
+	return nil
+}
+
+// validatePatch checks the patch against the schema of the connector, when it has one
+func validatePatch(res *config.ResourceItem, p *patch.Patch) error {
+	if s, err := res.Schema(); err == nil {
+		return p.Validate(s)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package receiver
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/patch"
+)
+
+const patchConfig = `Resources:
+  Receiver:
+    ResourceType: ApiGateway
+    ObjectPathSchema: user.json
+  Connector:
+    ResourceType: DynamoDB
+    ObjectPathSchema: user.json
+    Properties:
+      TableName: users
+      Keys:
+        UserID: EQ
+  Expressions:
+    Computed:
+      - Target: Name
+        Expression: 'Age >= 60 ? Name + " (senior)" : Name'
+    Validations:
+      - Expression: Age >= 18
+        Field: Age
+        Message: must be an adult
+    Routes:
+      - Condition: Age > 150
+        Action: SKIP
+`
+
+// patchConnector answers the reads with the stored user and keeps the patches it receives
+type patchConnector struct {
+	stubConnector
+	patch *patch.Patch
+}
+
+func (c *patchConnector) Read(_ context.Context, data interface{}) *lowcodeattribute.ExecutionResponse {
+	return &lowcodeattribute.ExecutionResponse{StatusCode: http.StatusOK, Message: map[string]interface{}{"UserID": "42", "Name": "Ana", "Age": 30.0}}
+}
+
+func (c *patchConnector) Patch(_ context.Context, data interface{}, p *patch.Patch) *lowcodeattribute.ExecutionResponse {
+	c.patch = p
+	return &lowcodeattribute.ExecutionResponse{StatusCode: http.StatusOK, Message: data}
+}
+
+func TestPatchItemAppliesTheExpressions(t *testing.T) {
+	conf, err := config.LoadFrom(config.NewMemorySource(map[string][]byte{
+		"config.yaml": []byte(patchConfig),
+		"user.json":   []byte(userSchema),
+	}), "config.yaml")
+	if err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   []patch.Operation
+	}{
+		{
+			name:   "rules satisfied",
+			body:   `{"Age": 40}`,
+			status: http.StatusOK,
+			want:   []patch.Operation{{Op: patch.OpSet, Path: []string{"Age"}, Value: int64(40)}},
+		},
+		{
+			name:   "computed field",
+			body:   `{"Age": 65}`,
+			status: http.StatusOK,
+			want: []patch.Operation{
+				{Op: patch.OpSet, Path: []string{"Age"}, Value: int64(65)},
+				{Op: patch.OpSet, Path: []string{"Name"}, Value: "Ana (senior)"},
+			},
+		},
+		{name: "validation refused", body: `{"Age": 10}`, status: http.StatusBadRequest},
+		{name: "route skipped", body: `{"Age": 200}`, status: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &patchConnector{}
+
+			event := events.APIGatewayProxyRequest{
+				HTTPMethod:     "PATCH",
+				Body:           tt.body,
+				Headers:        map[string]string{"Content-Type": patch.ContentTypeMergePatch},
+				PathParameters: map[string]string{"UserID": "42"},
+			}
+
+			response := HandleAPIGatewayEvent(context.Background(), event, conf, conn)
+			if response.StatusCode != tt.status {
+				t.Fatalf("HandleAPIGatewayEvent() = %d %v, want %d", response.StatusCode, response.Error, tt.status)
+			}
+
+			if tt.want == nil {
+				if conn.patch != nil {
+					t.Errorf("the connector received the patch %+v, want none", conn.patch.Operations)
+				}
+				return
+			}
+			if conn.patch == nil || !reflect.DeepEqual(conn.patch.Operations, tt.want) {
+				t.Errorf("the connector received %+v, want %+v", conn.patch, tt.want)
+			}
+		})
+	}
+}
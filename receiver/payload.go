@@ -0,0 +1,259 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/connector"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/mapping"
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+// mediaType returns the media type of a content type, without its parameters
+func mediaType(contentType string) string {
+	if contentType == "" {
+		return "application/json"
+	}
+
+	media, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	return media
+}
+
+// isAvro reports whether the content type indicates an Avro payload
+func isAvro(contentType string) bool {
+	switch mediaType(contentType) {
+	case schema.ContentTypeAvroBinary, schema.ContentTypeAvro, schema.ContentTypeAvroSingleObject, schema.ContentTypeAvroOCF:
+		return true
+	}
+
+	return false
+}
+
+// isProtobuf reports whether the content type indicates a protobuf binary payload
+func isProtobuf(contentType string) bool {
+	switch mediaType(contentType) {
+	case schema.ContentTypeProtobuf, schema.ContentTypeProtobufAlt:
+		return true
+	}
+
+	return false
+}
+
+// isBinary reports whether the content type indicates a binary payload, which is base64 encoded
+// when carried by a text message
+func isBinary(contentType string) bool {
+	return isAvro(contentType) || isProtobuf(contentType)
+}
+
+// decodeBase64 decodes the text payloads that carry binary content, like the Avro payloads sent
+// through the API Gateway or a queue
+func decodeBase64(body string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 body: %v", err)
+	}
+
+	return data, nil
+}
+
+// decodePayload converts the body received into the records described by the schema of the
+// resource, according to its content type: JSON (default), Avro binary, with or without the
+// single-object encoding or the wire format of a schema registry, an Avro Object Container File
+// or a protobuf message. JSON bodies of resources described by a protobuf message follow the
+// protobuf JSON mapping. Only the JSON arrays and the container files carry a batch of records.
+func decodePayload(ctx context.Context, body []byte, contentType string, res *config.ResourceItem) (records []map[string]interface{}, batch bool, err error) {
+	media := mediaType(contentType)
+
+	var values []interface{}
+
+	switch {
+	case isProtobuf(media):
+		values, err = decodeProtobuf(body, res, false)
+	case !isAvro(media):
+		if len(bytes.TrimSpace(body)) == 0 {
+			return []map[string]interface{}{{}}, false, nil
+		}
+
+		if s, schemaErr := res.Schema(); schemaErr == nil && s.Format() == schema.FormatProtobuf {
+			values, err = decodeProtobuf(body, res, true)
+			break
+		}
+
+		// arrays carry a batch of records
+		if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+			if err := decodeJSON(body, &values); err != nil {
+				return nil, false, err
+			}
+			batch = true
+			break
+		}
+
+		data := map[string]interface{}{}
+		if err := decodeJSON(body, &data); err != nil {
+			return nil, false, err
+		}
+
+		return []map[string]interface{}{data}, false, nil
+	case media == schema.ContentTypeAvroOCF:
+		values, err = decodeOCF(body, res)
+		batch = true
+	default:
+		var value interface{}
+		if value, err = res.DecodeAvro(ctx, body); err != nil {
+			err = fmt.Errorf("invalid avro body: %v", err)
+		}
+		values = []interface{}{value}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, value := range values {
+		record, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf("invalid body: %T is not a record", value)
+		}
+		records = append(records, record)
+	}
+
+	return records, batch, nil
+}
+
+// decodeJSON decodes a JSON body keeping its numbers as json.Number, so the longs beyond 2^53 reach
+// the schema without passing through float64
+func decodeJSON(body []byte, value interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(value); err != nil {
+		return fmt.Errorf("invalid json body: %v", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("invalid json body: unexpected data after the value")
+	}
+
+	return nil
+}
+
+// decodeOCF decodes the records of an Avro Object Container File
+func decodeOCF(body []byte, res *config.ResourceItem) ([]interface{}, error) {
+	s, err := res.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := s.Element().DecodeOCF(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid avro body: %v", err)
+	}
+
+	return values, nil
+}
+
+// decodeProtobuf decodes a protobuf message, encoded with the binary format or the JSON mapping
+func decodeProtobuf(body []byte, res *config.ResourceItem, mapping bool) ([]interface{}, error) {
+	s, err := res.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if mapping {
+		value, err = s.Element().DecodeProtoJSON(body)
+	} else {
+		value, err = s.Element().DecodeProto(body)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid protobuf body: %v", err)
+	}
+
+	return []interface{}{value}, nil
+}
+
+// encodeRecord generates the server attributes of a record and encodes it with the receiver
+// schema. Only the creation and update of items require the whole record.
+func encodeRecord(ctx context.Context, action ActionRequested, record map[string]interface{}, conf *config.Config, conn connector.Connector) (interface{}, error) {
+	if err := generate(ctx, action, record, conf, conn); err != nil {
+		return nil, err
+	}
+
+	switch action {
+	case Create, Update:
+		return conf.Resources.Receiver.EncodeJSON(record)
+	default:
+		return conf.Resources.Receiver.EncodePartialJSON(record)
+	}
+}
+
+// processRecords executes the action requested for each record of a batch, returning the result of
+// each one of them. Connectors able to handle batches receive the records together, except the
+// ones routed to other actions, which are executed on their own like on the other connectors.
+func processRecords(ctx context.Context, action ActionRequested, records []map[string]interface{}, req mapping.Request, conf *config.Config, conn connector.Connector) []lowcodeattribute.ItemResult {
+	results := make([]lowcodeattribute.ItemResult, len(records))
+
+	batcher, batchable := conn.(connector.Batcher)
+	batchable = batchable && len(records) > 1 && (action == Create || action == Read || action == Delete)
+
+	var (
+		pending []interface{}
+		indexes []int
+	)
+
+	for i, record := range records {
+		data, err := encodeRecord(ctx, action, record, conf, conn)
+		if err != nil {
+			results[i] = lowcodeattribute.NewItemResult(i, failure(err))
+			continue
+		}
+
+		if !batchable {
+			results[i] = lowcodeattribute.NewItemResult(i, execute(ctx, action, data, req, conf, conn))
+			continue
+		}
+
+		routed, mapped, recordReq, response := prepare(action, data, req, conf)
+		switch {
+		case response != nil:
+			results[i] = lowcodeattribute.NewItemResult(i, response)
+		case routed != action:
+			results[i] = lowcodeattribute.NewItemResult(i, dispatch(mapping.NewContext(ctx, recordReq), routed, mapped, conf, conn))
+		default:
+			pending = append(pending, mapped)
+			indexes = append(indexes, i)
+		}
+	}
+
+	if len(pending) == 0 {
+		return results
+	}
+
+	ctx = mapping.NewContext(ctx, req)
+
+	var responses []*lowcodeattribute.ExecutionResponse
+	switch action {
+	case Create:
+		responses = batcher.CreateBatch(ctx, pending)
+	case Read:
+		responses = batcher.ReadBatch(ctx, pending)
+	case Delete:
+		responses = batcher.DeleteBatch(ctx, pending)
+	}
+
+	for i, response := range responses {
+		results[indexes[i]] = lowcodeattribute.NewItemResult(indexes[i], mapResponse(conf.Resources.Mapping, response))
+	}
+
+	return results
+}
@@ -0,0 +1,46 @@
+package receiver
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDecodePayloadKeepsTheLongs(t *testing.T) {
+	conf := loadConfig(t)
+
+	tests := []struct {
+		name  string
+		body  string
+		batch bool
+	}{
+		{name: "object", body: `{"UserID": "42", "Name": "Ana", "Age": 9007199254740993}`},
+		{name: "array", body: `[{"UserID": "42", "Name": "Ana", "Age": 9007199254740993}]`, batch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, batch, err := decodePayload(context.Background(), []byte(tt.body), "application/json", &conf.Resources.Receiver)
+			if err != nil || batch != tt.batch || len(records) != 1 {
+				t.Fatalf("decodePayload() = %v, %v, %v, want one record", records, batch, err)
+			}
+
+			record, err := conf.Resources.Receiver.EncodeJSON(records[0])
+			if err != nil {
+				t.Fatalf("EncodeJSON() error = %v", err)
+			}
+			if got := record.(map[string]interface{})["Age"]; got != int64(9007199254740993) {
+				t.Errorf("Age = %#v, want 9007199254740993", got)
+			}
+		})
+	}
+}
+
+func TestDecodePayloadRefusesInvalidJSON(t *testing.T) {
+	conf := loadConfig(t)
+
+	for _, body := range []string{`{"UserID":`, `{"UserID": "42"} {}`, `[{"UserID": "42"}] x`} {
+		if _, _, err := decodePayload(context.Background(), []byte(body), "application/json", &conf.Resources.Receiver); err == nil {
+			t.Errorf("decodePayload(%s) error = nil, want an invalid json body", body)
+		}
+	}
+}
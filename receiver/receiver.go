@@ -0,0 +1,23 @@
+package receiver
+
+import (
+	"context"
+
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/connector"
+)
+
+// Receiver is the contract implemented by custom receivers registered on the function. Custom
+// receivers are consulted before the built-in ones, and must report handled as false when the
+// event received is not supported by them.
+type Receiver interface {
+	Handle(ctx context.Context, event interface{}, conf *config.Config, conn connector.Connector) (response interface{}, handled bool, err error)
+}
+
+// ReceiverFunc allows the use of ordinary functions as receivers.
+type ReceiverFunc func(ctx context.Context, event interface{}, conf *config.Config, conn connector.Connector) (interface{}, bool, error)
+
+// Handle calls f(ctx, event, conf, conn).
+func (f ReceiverFunc) Handle(ctx context.Context, event interface{}, conf *config.Config, conn connector.Connector) (interface{}, bool, error) {
+	return f(ctx, event, conf, conn)
+}
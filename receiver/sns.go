@@ -1,10 +1,49 @@
 package receiver
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/connector"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
 )
 
-func HandleSNSEvent(event events.SNSEvent, client *dynamodb.DynamoDB) string {
-	return "SNS event received"
+// HandleSNSEvent creates, using the connector, the records carried by each notification. The content
+// type of a notification is read from its 'Content-Type' (or 'contentType') attribute, and Avro
+// payloads must be encoded in base64. An error is returned, so the invocation is retried, when any of
+// the notifications failed with an error that can succeed when retried; the other failures are
+// logged.
+func HandleSNSEvent(ctx context.Context, event events.SNSEvent, conf *config.Config, conn connector.Connector) error {
+	failures := []error{}
+
+	for _, record := range event.Records {
+		err := handleMessage(ctx, record.SNS.Message, snsContentType(record.SNS), conf, conn)
+		if err != nil && !lowcodeattribute.IsRetryable(err) {
+			log.Printf("dropping message %s: %v", record.SNS.MessageID, err)
+			continue
+		}
+		if err != nil {
+			failures = append(failures, fmt.Errorf("message %s: %w", record.SNS.MessageID, err))
+		}
+	}
+
+	return errors.Join(failures...)
+}
+
+func snsContentType(entity events.SNSEntity) string {
+	for name, attribute := range entity.MessageAttributes {
+		if !isContentTypeAttribute(name) {
+			continue
+		}
+
+		if value, ok := attribute.(map[string]interface{})["Value"].(string); ok {
+			return value
+		}
+	}
+
+	return ""
 }
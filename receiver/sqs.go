@@ -1,10 +1,49 @@
 package receiver
 
 import (
+	"context"
+	"log"
+
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+	"github.com/raywall/aws-lowcode-lambda-go/connector"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
 )
 
-func HandleSQSEvent(event events.SQSEvent, client *dynamodb.DynamoDB) string {
-	return "SQS event received"
+// HandleSQSEvent creates, using the connector, the records carried by each message of the queue. The
+// content type of a message is read from its 'Content-Type' (or 'contentType') attribute, and Avro
+// payloads must be encoded in base64.
+//
+// The messages that failed are reported as batch item failures, so only them return to the queue
+// when the event source mapping reports batch item failures. Messages refused for their content,
+// like invalid records or conflicts, would fail again, so they are logged and dropped instead.
+func HandleSQSEvent(ctx context.Context, event events.SQSEvent, conf *config.Config, conn connector.Connector) events.SQSEventResponse {
+	response := events.SQSEventResponse{
+		BatchItemFailures: []events.SQSBatchItemFailure{},
+	}
+
+	for _, message := range event.Records {
+		err := handleMessage(ctx, message.Body, sqsContentType(message), conf, conn)
+		if err != nil && !lowcodeattribute.IsRetryable(err) {
+			log.Printf("dropping message %s: %v", message.MessageId, err)
+			continue
+		}
+		if err != nil {
+			response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+				ItemIdentifier: message.MessageId,
+			})
+		}
+	}
+
+	return response
+}
+
+func sqsContentType(message events.SQSMessage) string {
+	for name, attribute := range message.MessageAttributes {
+		if isContentTypeAttribute(name) && attribute.StringValue != nil {
+			return *attribute.StringValue
+		}
+	}
+
+	return ""
 }
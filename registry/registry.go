@@ -0,0 +1,213 @@
+// Package registry implements a client of the REST API of the Confluent Schema Registry, and of the
+// registries compatible with it, used to recover the schemas of the resources by subject and version
+// and the schemas that wrote the messages by their ID.
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+// contentType is the media type of the requests and responses of the registry API
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+// Latest identifies the last version registered for a subject.
+const Latest = "latest"
+
+// Client is a client of a Confluent-compatible schema registry. The schemas recovered by ID are
+// immutable on the registry, so they are kept in memory after the first request.
+type Client struct {
+	URL        string
+	Username   string
+	Password   string
+	HTTPClient *http.Client
+
+	mu  sync.RWMutex
+	ids map[int]*schema.Schema
+}
+
+// Subject is a version of the schema registered for a subject.
+type Subject struct {
+	Subject string
+	ID      int
+	Version int
+	Schema  *schema.Schema
+}
+
+// Error is the error returned by the registry API.
+type Error struct {
+	StatusCode int
+	Code       int    `json:"error_code"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("schema registry error %d: %s", e.Code, e.Message)
+}
+
+// schemaResponse is the document of a schema returned by the registry
+type schemaResponse struct {
+	Subject    string `json:"subject"`
+	ID         int    `json:"id"`
+	Version    int    `json:"version"`
+	SchemaType string `json:"schemaType"`
+	Schema     string `json:"schema"`
+}
+
+// NewClient creates a client of the registry available on the URL received.
+func NewClient(registryURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		URL:        strings.TrimSuffix(registryURL, "/"),
+		HTTPClient: httpClient,
+	}
+}
+
+// SchemaByID returns the schema registered with the ID received, as carried by the header of the
+// messages written using the registry.
+func (c *Client) SchemaByID(ctx context.Context, id int) (*schema.Schema, error) {
+	c.mu.RLock()
+	cached, ok := c.ids[id]
+	c.mu.RUnlock()
+
+	if ok {
+		return cached, nil
+	}
+
+	var doc schemaResponse
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &doc); err != nil {
+		return nil, err
+	}
+
+	s, err := parse(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %d: %v", id, err)
+	}
+
+	c.mu.Lock()
+	if c.ids == nil {
+		c.ids = make(map[int]*schema.Schema)
+	}
+	c.ids[id] = s
+	c.mu.Unlock()
+
+	return s, nil
+}
+
+// Version returns a version of the schema registered for the subject. The version is a number or
+// Latest, also used when it is empty.
+func (c *Client) Version(ctx context.Context, subject string, version string) (*Subject, error) {
+	var doc schemaResponse
+	if err := c.do(ctx, http.MethodGet, versionPath("/subjects", subject, version), nil, &doc); err != nil {
+		return nil, err
+	}
+
+	s, err := parse(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %s version %d: %v", subject, doc.Version, err)
+	}
+
+	return &Subject{
+		Subject: doc.Subject,
+		ID:      doc.ID,
+		Version: doc.Version,
+		Schema:  s,
+	}, nil
+}
+
+// CheckCompatibility asks the registry whether the schema is compatible with a version of the schema
+// registered for the subject, according to the compatibility level configured on the registry.
+func (c *Client) CheckCompatibility(ctx context.Context, subject string, version string, s *schema.Schema) (bool, error) {
+	request := map[string]string{"schema": string(s.Document())}
+	if s.Format() == schema.FormatJSONSchema {
+		request["schemaType"] = "JSON"
+	}
+
+	var result struct {
+		IsCompatible bool `json:"is_compatible"`
+	}
+	if err := c.do(ctx, http.MethodPost, versionPath("/compatibility/subjects", subject, version), request, &result); err != nil {
+		return false, err
+	}
+
+	return result.IsCompatible, nil
+}
+
+// do executes a request on the registry API, decoding its response into result
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.URL+path, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", contentType)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed calling the schema registry: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		regErr := &Error{StatusCode: resp.StatusCode}
+		if json.Unmarshal(data, regErr) != nil || regErr.Message == "" {
+			regErr.Code, regErr.Message = resp.StatusCode, strings.TrimSpace(string(data))
+		}
+		return regErr
+	}
+
+	return json.Unmarshal(data, result)
+}
+
+// versionPath builds the path of a version of a subject
+func versionPath(prefix, subject, version string) string {
+	if version == "" {
+		version = Latest
+	}
+
+	return fmt.Sprintf("%s/%s/versions/%s", prefix, url.PathEscape(subject), url.PathEscape(version))
+}
+
+// parse compiles the schema returned by the registry, which is an Avro schema unless stated otherwise
+func parse(doc schemaResponse) (*schema.Schema, error) {
+	switch doc.SchemaType {
+	case "", "AVRO":
+		return schema.ParseAvro([]byte(doc.Schema))
+	case "JSON":
+		return schema.ParseJSONSchema([]byte(doc.Schema))
+	default:
+		return nil, fmt.Errorf("unsupported schema type %s", doc.SchemaType)
+	}
+}
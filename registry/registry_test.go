@@ -0,0 +1,228 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+const userSchema = `{
+  "type": "record",
+  "name": "User",
+  "fields": [
+    { "name": "UserID", "type": "string" },
+    { "name": "Age", "type": "int" }
+  ]
+}`
+
+const (
+	testUsername = "registry"
+	testPassword = "secret"
+)
+
+// fakeRegistry serves the subject 'users-value', whose versions 1 and 2 are the schemas 7 and 8,
+// counting the requests for the schemas by ID
+type fakeRegistry struct {
+	byID atomic.Int32
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+
+	if user, password, ok := r.BasicAuth(); !ok || user != testUsername || password != testPassword {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error_code":401,"message":"Unauthorized"}`))
+		return
+	}
+
+	var doc schemaResponse
+	switch r.Method + " " + r.URL.EscapedPath() {
+	case "GET /subjects/users-value/versions/latest", "GET /subjects/users-value/versions/2":
+		doc = schemaResponse{Subject: "users-value", ID: 8, Version: 2, Schema: userSchema}
+	case "GET /subjects/users-value/versions/1":
+		doc = schemaResponse{Subject: "users-value", ID: 7, Version: 1, Schema: userSchema}
+	case "GET /schemas/ids/7":
+		f.byID.Add(1)
+		doc = schemaResponse{Schema: userSchema}
+	case "POST /compatibility/subjects/users-value/versions/latest":
+		json.NewEncoder(w).Encode(map[string]bool{"is_compatible": true})
+		return
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error_code":40401,"message":"Subject not found."}`))
+		return
+	}
+
+	json.NewEncoder(w).Encode(doc)
+}
+
+func newTestClient(t *testing.T) (*Client, *fakeRegistry) {
+	t.Helper()
+
+	fake := &fakeRegistry{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client := NewClient(server.URL+"/", server.Client())
+	client.Username, client.Password = testUsername, testPassword
+
+	return client, fake
+}
+
+func TestVersion(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	tests := []struct {
+		version     string
+		wantID      int
+		wantVersion int
+	}{
+		{version: "", wantID: 8, wantVersion: 2},
+		{version: Latest, wantID: 8, wantVersion: 2},
+		{version: "1", wantID: 7, wantVersion: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run("version "+tt.version, func(t *testing.T) {
+			subject, err := client.Version(context.Background(), "users-value", tt.version)
+			if err != nil {
+				t.Fatalf("Version() error = %v", err)
+			}
+
+			if subject.Subject != "users-value" || subject.ID != tt.wantID || subject.Version != tt.wantVersion {
+				t.Errorf("Version() = %s, %d, %d, want users-value, %d, %d", subject.Subject, subject.ID, subject.Version, tt.wantID, tt.wantVersion)
+			}
+			if subject.Schema.Element().Field("Age") == nil {
+				t.Errorf("the schema of the subject doesn't declare the field Age")
+			}
+		})
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	client, _ := newTestClient(t)
+	client.Password = "wrong"
+
+	_, err := client.Version(context.Background(), "users-value", Latest)
+
+	var regErr *Error
+	if !errors.As(err, &regErr) {
+		t.Fatalf("Version() error = %v, want *Error", err)
+	}
+	if regErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("StatusCode = %d, want 401", regErr.StatusCode)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	_, err := client.Version(context.Background(), "orders-value", Latest)
+
+	var regErr *Error
+	if !errors.As(err, &regErr) {
+		t.Fatalf("Version() error = %v, want *Error", err)
+	}
+	if regErr.StatusCode != http.StatusNotFound || regErr.Code != 40401 || regErr.Message != "Subject not found." {
+		t.Errorf("Error = %d, %d, %q, want 404, 40401, Subject not found.", regErr.StatusCode, regErr.Code, regErr.Message)
+	}
+
+	if _, err := client.SchemaByID(context.Background(), 99); !errors.As(err, &regErr) || regErr.StatusCode != http.StatusNotFound {
+		t.Errorf("SchemaByID() error = %v, want a 404 *Error", err)
+	}
+}
+
+func TestSchemaByIDIsCached(t *testing.T) {
+	client, fake := newTestClient(t)
+
+	for i := 0; i < 3; i++ {
+		s, err := client.SchemaByID(context.Background(), 7)
+		if err != nil {
+			t.Fatalf("SchemaByID() error = %v", err)
+		}
+		if s.Element().Field("UserID") == nil {
+			t.Errorf("the schema 7 doesn't declare the field UserID")
+		}
+	}
+
+	if got := fake.byID.Load(); got != 1 {
+		t.Errorf("the schema was requested %d times, want 1", got)
+	}
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	s, err := client.SchemaByID(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("SchemaByID() error = %v", err)
+	}
+
+	compatible, err := client.CheckCompatibility(context.Background(), "users-value", "", s)
+	if err != nil || !compatible {
+		t.Errorf("CheckCompatibility() = %v, %v, want true", compatible, err)
+	}
+}
+
+func TestWireFormat(t *testing.T) {
+	data := EncodeWire(7, []byte{0x0a, 0x0b})
+
+	if want := []byte{magicByte, 0, 0, 0, 7, 0x0a, 0x0b}; !reflect.DeepEqual(data, want) {
+		t.Fatalf("EncodeWire() = %v, want %v", data, want)
+	}
+	if !IsWireFormat(data) {
+		t.Errorf("IsWireFormat() = false, want true")
+	}
+
+	id, payload, err := DecodeWire(data)
+	if err != nil || id != 7 || !reflect.DeepEqual(payload, []byte{0x0a, 0x0b}) {
+		t.Errorf("DecodeWire() = %d, %v, %v, want 7, [10 11]", id, payload, err)
+	}
+
+	for _, invalid := range [][]byte{nil, {magicByte, 0, 0, 7}, {0x01, 0, 0, 0, 7}} {
+		if IsWireFormat(invalid) {
+			t.Errorf("IsWireFormat(%v) = true, want false", invalid)
+		}
+		if _, _, err := DecodeWire(invalid); err == nil {
+			t.Errorf("DecodeWire(%v) error = nil, want an error", invalid)
+		}
+	}
+}
+
+func TestDecodeWithTheSchemaOfTheHeader(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	writer, err := client.SchemaByID(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("SchemaByID() error = %v", err)
+	}
+
+	record := map[string]interface{}{"UserID": "42", "Age": int32(30)}
+	payload, err := writer.Element().EncodeBinary(record)
+	if err != nil {
+		t.Fatalf("EncodeBinary() error = %v", err)
+	}
+
+	id, body, err := DecodeWire(EncodeWire(7, payload))
+	if err != nil {
+		t.Fatalf("DecodeWire() error = %v", err)
+	}
+
+	s, err := client.SchemaByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("SchemaByID() error = %v", err)
+	}
+
+	value, err := s.Element().DecodeBinary(body)
+	if err != nil {
+		t.Fatalf("DecodeBinary() error = %v", err)
+	}
+	if decoded := value.(map[string]interface{}); decoded["UserID"] != "42" {
+		t.Errorf("DecodeBinary() = %v, want the record encoded", decoded)
+	}
+}
@@ -0,0 +1,37 @@
+package registry
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// magicByte starts the messages written with the wire format of the registry
+const magicByte = 0x00
+
+// headerSize is the size of the header of the wire format: the magic byte and the schema ID
+const headerSize = 5
+
+// IsWireFormat reports whether data starts with the header of the wire format of the registry.
+func IsWireFormat(data []byte) bool {
+	return len(data) >= headerSize && data[0] == magicByte
+}
+
+// DecodeWire splits a message written with the wire format of the registry into the ID of the
+// schema that wrote it and its payload.
+func DecodeWire(data []byte) (id int, payload []byte, err error) {
+	if !IsWireFormat(data) {
+		return 0, nil, fmt.Errorf("payload does not start with the schema registry header")
+	}
+
+	return int(binary.BigEndian.Uint32(data[1:headerSize])), data[headerSize:], nil
+}
+
+// EncodeWire prepends the header of the wire format of the registry, carrying the schema ID, to
+// the payload.
+func EncodeWire(id int, payload []byte) []byte {
+	data := make([]byte, headerSize, headerSize+len(payload))
+	data[0] = magicByte
+	binary.BigEndian.PutUint32(data[1:headerSize], uint32(id))
+
+	return append(data, payload...)
+}
@@ -0,0 +1,130 @@
+package lowcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+)
+
+// reloader checks, at most once per interval, whether the configuration or the schema files
+// changed on their source, loading the new configuration when they did.
+type reloader struct {
+	opts     *options
+	interval time.Duration
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	version   string
+}
+
+func newReloader(o *options, interval time.Duration, settings *config.Config) (*reloader, error) {
+	if o.configSource == nil {
+		return nil, errors.New("hot reload requires a configuration source able to be read again")
+	}
+
+	r := &reloader{
+		opts:      o,
+		interval:  interval,
+		checkedAt: time.Now(),
+	}
+
+	version, err := r.currentVersion(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting configuration version: %v", err)
+	}
+
+	r.version = version
+	return r, nil
+}
+
+// currentVersion combines the versions of the configuration file and of the schema files
+// referenced by the configuration in use
+func (r *reloader) currentVersion(settings *config.Config) (string, error) {
+	schemaSource := r.opts.schemaSource
+	if schemaSource == nil {
+		schemaSource = r.opts.configSource
+	}
+
+	type file struct {
+		src  config.Source
+		name string
+	}
+
+	files := []file{{r.opts.configSource, r.opts.configName}}
+	for _, name := range settings.Resources.Receiver.SchemaFiles() {
+		files = append(files, file{schemaSource, name})
+	}
+	for _, name := range settings.Resources.Connector.SchemaFiles() {
+		files = append(files, file{schemaSource, name})
+	}
+
+	versions := []string{}
+	for _, file := range files {
+		versioner, ok := file.src.(config.Versioner)
+		if !ok {
+			return "", fmt.Errorf("source %T cannot inform the version of its files", file.src)
+		}
+
+		version, err := versioner.Version(file.name)
+		if err != nil {
+			return "", err
+		}
+
+		versions = append(versions, version)
+	}
+
+	return strings.Join(versions, "|"), nil
+}
+
+// reload swaps the configuration of the function when its source changed since the last check.
+// A new configuration that cannot be loaded or validated is discarded, keeping the current one.
+// The invocations received while another one reloads keep using the current configuration.
+func (r *reloader) reload(function *LowcodeFunction) {
+	if !r.mu.TryLock() {
+		return
+	}
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) < r.interval {
+		return
+	}
+	r.checkedAt = time.Now()
+
+	version, err := r.currentVersion(function.Settings())
+	if err != nil {
+		function.logger.Printf("failed checking configuration version: %v", err)
+		return
+	}
+
+	if version == r.version {
+		return
+	}
+
+	// the version is only kept once the configuration is loaded, so the failures are retried on
+	// the next check
+	settings, err := r.opts.loadSettings()
+	if err != nil {
+		function.logger.Printf("keeping current configuration: %v", err)
+		return
+	}
+
+	conn, err := function.connector(settings)
+	if err != nil {
+		function.logger.Printf("keeping current configuration: %v", err)
+		return
+	}
+
+	function.state.Store(&state{settings: settings, conn: conn})
+
+	// the new configuration may reference other schema files than the previous one
+	if loaded, err := r.currentVersion(settings); err == nil {
+		version = loaded
+	}
+	r.version = version
+
+	function.debugf("configuration reloaded, version %s", version)
+}
@@ -0,0 +1,139 @@
+package lowcode
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/raywall/aws-lowcode-lambda-go/config"
+)
+
+// reloadedFunction creates a function checking the configuration of the source on every invocation
+func reloadedFunction(t *testing.T, src *config.MemorySource, factory *memoryFactory) *LowcodeFunction {
+	t.Helper()
+
+	function, err := New(
+		WithConfigSource(src, "config.yaml"),
+		WithConnector("Memory", factory.create),
+		WithHotReload(time.Nanosecond),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	return function
+}
+
+func invoke(t *testing.T, function *LowcodeFunction) {
+	t.Helper()
+
+	event := events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/users/42", PathParameters: map[string]string{"UserID": "42"}}
+	if _, err := function.HandleRequest(context.Background(), event); err != nil {
+		t.Fatalf("HandleRequest() error = %v", err)
+	}
+}
+
+func TestReloadSwapsTheConfiguration(t *testing.T) {
+	src, factory := memorySource(memoryConfig), &memoryFactory{}
+	function := reloadedFunction(t, src, factory)
+
+	// an unchanged source keeps the configuration and the connector
+	invoke(t, function)
+	if got := factory.count(); got != 1 {
+		t.Errorf("the factory created %d connectors, want 1", got)
+	}
+
+	src.Set("config.yaml", []byte(strings.Replace(memoryConfig, "UserID: EQ", "Email: EQ", 1)))
+	invoke(t, function)
+
+	current := function.state.Load()
+	if _, ok := current.settings.Resources.Connector.Properties.Keys["Email"]; !ok {
+		t.Errorf("Settings() keys = %v, want the configuration reloaded", current.settings.Resources.Connector.Properties.Keys)
+	}
+	if conn := current.conn.(*memoryConnector); conn.conf != current.settings || factory.count() != 2 {
+		t.Errorf("the connector wasn't created with the configuration reloaded")
+	}
+
+	// the schema files are watched as well
+	src.Set("user.json", []byte(strings.Replace(userSchema, `"Name"`, `"FullName"`, 1)))
+	invoke(t, function)
+
+	s, err := function.Settings().Resources.Receiver.Schema()
+	if err != nil || s.Element().Field("FullName") == nil {
+		t.Errorf("Receiver schema = %v, %v, want the schema reloaded", s, err)
+	}
+}
+
+func TestReloadKeepsTheConfigurationOnFailures(t *testing.T) {
+	src, factory := memorySource(memoryConfig), &memoryFactory{}
+	function := reloadedFunction(t, src, factory)
+	loaded := function.Settings()
+
+	tests := []struct {
+		name     string
+		document string
+	}{
+		{name: "invalid yaml", document: "Resources: ["},
+		{name: "unsupported connector", document: strings.Replace(memoryConfig, "ResourceType: Memory", "ResourceType: Unknown", 1)},
+		{name: "missing schema", document: strings.Replace(memoryConfig, "user.json", "missing.json", 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src.Set("config.yaml", []byte(tt.document))
+			invoke(t, function)
+
+			if function.Settings() != loaded {
+				t.Errorf("Settings() changed, want the configuration kept")
+			}
+		})
+	}
+
+	// the failures are retried, so a fixed configuration is loaded
+	src.Set("config.yaml", []byte(memoryConfig))
+	invoke(t, function)
+
+	if function.Settings() == loaded || factory.count() != 2 {
+		t.Errorf("Settings() kept, want the configuration fixed loaded")
+	}
+}
+
+func TestReloadDuringConcurrentInvocations(t *testing.T) {
+	src, factory := memorySource(memoryConfig), &memoryFactory{}
+	function := reloadedFunction(t, src, factory)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 50; j++ {
+				if _, err := function.HandleRequest(context.Background(), events.SQSEvent{}); err != nil {
+					t.Errorf("HandleRequest() error = %v", err)
+					return
+				}
+
+				current := function.state.Load()
+				if conn := current.conn.(*memoryConnector); conn.conf != current.settings {
+					t.Errorf("the connector doesn't match the configuration in use")
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		keys := "UserID: EQ"
+		if i%2 == 0 {
+			keys = "Email: EQ"
+		}
+		src.Set("config.yaml", []byte(strings.Replace(memoryConfig, "UserID: EQ", keys, 1)))
+		time.Sleep(time.Millisecond)
+	}
+
+	wg.Wait()
+}
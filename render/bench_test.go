@@ -0,0 +1,23 @@
+package render
+
+import "testing"
+
+func BenchmarkRender(b *testing.B) {
+	r := &Response{Envelope: true}
+	if err := r.Compile(); err != nil {
+		b.Fatal(err)
+	}
+
+	list := make([]interface{}, 100)
+	for i := range list {
+		list[i] = map[string]interface{}{"UserID": "1", "Name": "Ana", "Address": map[string]interface{}{"City": "Lisbon"}}
+	}
+	req := Request{Accept: "application/json", Fields: []string{"UserID", "Address.City"}}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.Render(req, ok(list), nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+// encodeJSON encodes the data of a successful response, wrapped in the envelope when it is enabled
+func (r *Response) encodeJSON(response *lowcodeattribute.ExecutionResponse, data interface{}) ([]byte, error) {
+	if r == nil || !r.Envelope {
+		if data == nil {
+			return nil, nil
+		}
+		return json.Marshal(data)
+	}
+
+	meta := map[string]interface{}{}
+	for key, value := range response.Meta {
+		meta[key] = value
+	}
+	if list, ok := records(data); ok {
+		if _, single := data.(map[string]interface{}); !single {
+			meta["count"] = len(list)
+		}
+	}
+
+	return json.Marshal(Envelope{Data: data, Meta: meta})
+}
+
+// encodeError encodes the problem details of a failed response, listed in the errors of the
+// envelope when it is enabled
+func (r *Response) encodeError(response *lowcodeattribute.ExecutionResponse, instance string) ([]byte, error) {
+	problem := lowcodeattribute.ProblemOf(response)
+	if problem.Instance == "" {
+		problem.Instance = instance
+	}
+
+	if r == nil || !r.Envelope {
+		return json.Marshal(problem)
+	}
+
+	return json.Marshal(Envelope{Errors: []*lowcodeattribute.Problem{problem}})
+}
+
+// records returns the records of the data, which is a record or a list of records
+func records(data interface{}) ([]map[string]interface{}, bool) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{v}, true
+	case []map[string]interface{}:
+		return v, true
+	case []interface{}:
+		list := make([]map[string]interface{}, len(v))
+		for i, item := range v {
+			record, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			list[i] = record
+		}
+		return list, true
+	default:
+		return nil, false
+	}
+}
+
+// selectFields keeps only the fields selected on the records of the data
+func selectFields(data interface{}, fields []string) interface{} {
+	if len(fields) == 0 {
+		return data
+	}
+
+	switch v := data.(type) {
+	case map[string]interface{}:
+		return selectRecord(v, fields)
+	case []map[string]interface{}, []interface{}:
+		list, ok := records(v)
+		if !ok {
+			return data
+		}
+
+		selected := make([]interface{}, len(list))
+		for i, record := range list {
+			selected[i] = selectRecord(record, fields)
+		}
+		return selected
+	default:
+		return data
+	}
+}
+
+// selectRecord copies the fields found on the dotted paths received
+func selectRecord(record map[string]interface{}, fields []string) map[string]interface{} {
+	selected := map[string]interface{}{}
+
+	for _, field := range fields {
+		path := strings.Split(field, ".")
+		if value, found := lookup(record, path); found {
+			assign(selected, path, value)
+		}
+	}
+
+	return selected
+}
+
+// lookup returns the value found on the path of the record
+func lookup(record map[string]interface{}, path []string) (interface{}, bool) {
+	var current interface{} = record
+
+	for _, name := range path {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		if current, ok = object[name]; !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// assign writes the value on the path of the record, creating the missing parent objects
+func assign(record map[string]interface{}, path []string, value interface{}) {
+	current := record
+	for _, name := range path[:len(path)-1] {
+		child, ok := current[name].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			current[name] = child
+		}
+		current = child
+	}
+
+	current[path[len(path)-1]] = value
+}
+
+// encodeNDJSON encodes each record as a JSON document on its own line
+func encodeNDJSON(data interface{}) ([]byte, error) {
+	list, _ := records(data)
+
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	for _, record := range list {
+		if err := encoder.Encode(record); err != nil {
+			return nil, err
+		}
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// encodeCSV encodes the records as the rows of a CSV document, whose header lists the fields
+// selected or, when there is no selection, the fields of the records in the order of the schema
+func encodeCSV(data interface{}, fields []string, s *schema.Schema) ([]byte, error) {
+	list, _ := records(data)
+
+	columns := fields
+	if len(columns) == 0 {
+		columns = csvColumns(list, s)
+	}
+
+	buffer := &bytes.Buffer{}
+	writer := csv.NewWriter(buffer)
+
+	if err := writer.Write(columns); err != nil {
+		return nil, err
+	}
+
+	for _, record := range list {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			value, _ := lookup(record, strings.Split(column, "."))
+			row[i] = csvValue(value)
+		}
+
+		if err := writer.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
+
+// csvColumns returns the fields found on the records, the ones of the schema first
+func csvColumns(list []map[string]interface{}, s *schema.Schema) []string {
+	found := map[string]bool{}
+	for _, record := range list {
+		for key := range record {
+			found[key] = true
+		}
+	}
+
+	var columns []string
+	if s != nil {
+		for _, field := range s.Element().Fields {
+			if found[field.Name] {
+				columns = append(columns, field.Name)
+				delete(found, field.Name)
+			}
+		}
+	}
+
+	others := make([]string, 0, len(found))
+	for key := range found {
+		others = append(others, key)
+	}
+	sort.Strings(others)
+
+	return append(columns, others...)
+}
+
+// csvValue returns the text of a cell: the strings as they are and the other values, like numbers
+// and nested objects, as JSON
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
+
+// encodeAvro encodes a record with the Avro binary encoding or the records as an Avro Object
+// Container File, according to the content type negotiated
+func encodeAvro(data interface{}, contentType string, s *schema.Schema) ([]byte, error) {
+	s = s.Element()
+
+	if contentType != schema.ContentTypeAvroOCF {
+		return s.EncodeBinary(data)
+	}
+
+	list, _ := records(data)
+
+	values := make([]interface{}, len(list))
+	for i, record := range list {
+		values[i] = record
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := s.EncodeOCF(buffer, values); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,360 @@
+// Package render writes the responses of the function for the clients of the API Gateway, following
+// the 'Response' section of the configuration: the records are optionally wrapped in an envelope,
+// reduced to the fields requested, encoded in the format negotiated with the Accept header and
+// compressed when the client accepts it.
+package render
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+// Formats of the responses.
+const (
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+	FormatAvro   = "avro"
+)
+
+// Content types of the formats, besides the Avro ones declared by the schema package.
+const (
+	ContentTypeJSON      = "application/json"
+	ContentTypeNDJSON    = "application/x-ndjson"
+	ContentTypeNDJSONAlt = "application/ndjson"
+	ContentTypeCSV       = "text/csv"
+)
+
+// DefaultCompressionMinSize is the size, in bytes, from which the bodies are compressed when
+// 'CompressionMinSize' is not informed.
+const DefaultCompressionMinSize = 1024
+
+type (
+	// Response is the 'Response' section of the configuration.
+	Response struct {
+		// Envelope wraps the JSON bodies in an object with the 'data', 'meta' and 'errors' members
+		Envelope bool `yaml:"Envelope"`
+		// Formats lists the formats the clients can negotiate: json, ndjson, csv and avro. All of
+		// them are available when it is not informed, avro only for receivers with a schema
+		Formats []string `yaml:"Formats"`
+		// Compression enables the gzip compression of the bodies of at least 'CompressionMinSize'
+		// bytes, when accepted by the client. The API Gateway must treat */* as a binary media type
+		Compression        bool `yaml:"Compression"`
+		CompressionMinSize int  `yaml:"CompressionMinSize"`
+		// Headers are added to every response
+		Headers map[string]string `yaml:"Headers"`
+
+		formats map[string]bool
+	}
+
+	// Request carries the preferences of the client.
+	Request struct {
+		Accept         string
+		AcceptEncoding string
+		// Fields are the dotted paths of the fields selected by the client, all of them when empty
+		Fields []string
+		// Instance is the path of the request, identifying the occurrence of the failures
+		Instance string
+	}
+
+	// Envelope is the body of the responses when 'Envelope' is enabled.
+	Envelope struct {
+		Data   interface{}                 `json:"data"`
+		Meta   map[string]interface{}      `json:"meta,omitempty"`
+		Errors []*lowcodeattribute.Problem `json:"errors,omitempty"`
+	}
+)
+
+// Compile validates the formats of the section. A nil section is valid and renders plain JSON
+// bodies, negotiating every format.
+func (r *Response) Compile() error {
+	if r == nil {
+		return nil
+	}
+
+	r.formats = map[string]bool{}
+	for _, format := range r.Formats {
+		format = strings.ToLower(format)
+		switch format {
+		case FormatJSON, FormatNDJSON, FormatCSV, FormatAvro:
+			r.formats[format] = true
+		default:
+			return fmt.Errorf("unsupported response format %s", format)
+		}
+	}
+
+	if r.CompressionMinSize < 0 {
+		return fmt.Errorf("invalid CompressionMinSize %d", r.CompressionMinSize)
+	}
+
+	return nil
+}
+
+// allows reports whether the format can be negotiated
+func (r *Response) allows(format string) bool {
+	return r == nil || len(r.formats) == 0 || r.formats[format]
+}
+
+// ParseFields parses the value of the 'fields' query parameter, a list of dotted paths separated
+// by commas.
+func ParseFields(value string) []string {
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// Render creates the response sent to the API Gateway from the execution response. The records of
+// the successful responses are encoded in the format negotiated with the client; the Avro formats
+// require the schema of the records, which may be nil. The failures are described by problem
+// details (RFC 7807), inside the envelope when it is enabled.
+func (r *Response) Render(req Request, response *lowcodeattribute.ExecutionResponse, s *schema.Schema) (events.APIGatewayProxyResponse, error) {
+	headers := map[string]string{"Vary": "Accept"}
+	if r != nil {
+		if r.Compression {
+			headers["Vary"] = "Accept, Accept-Encoding"
+		}
+		for name, value := range r.Headers {
+			headers[name] = value
+		}
+	}
+	for name, value := range response.Headers {
+		headers[name] = value
+	}
+
+	var (
+		body        []byte
+		contentType string
+		binary      bool
+		err         error
+	)
+
+	switch {
+	case response.StatusCode == http.StatusNoContent:
+	case response.StatusCode >= 300:
+		body, err = r.encodeError(response, req.Instance)
+		contentType = lowcodeattribute.ContentTypeProblem
+		if r != nil && r.Envelope {
+			contentType = ContentTypeJSON
+		}
+	default:
+		data := selectFields(response.Message, req.Fields)
+
+		format, media := r.negotiate(req.Accept, data, s)
+		if format == "" {
+			return r.Render(req, notAcceptable(fmt.Sprintf("none of the formats accepted is available: %s", req.Accept)), s)
+		}
+
+		contentType = media
+		switch format {
+		case FormatNDJSON:
+			body, err = encodeNDJSON(data)
+		case FormatCSV:
+			body, err = encodeCSV(data, req.Fields, s)
+		case FormatAvro:
+			// the records must follow the schema, so the fields are not selected
+			if body, err = encodeAvro(response.Message, media, s); err != nil {
+				return r.Render(req, notAcceptable(fmt.Sprintf("the records cannot be encoded with the schema: %v", err)), s)
+			}
+			binary = true
+		default:
+			body, err = r.encodeJSON(response, data)
+		}
+	}
+	if err != nil {
+		return r.Render(req, lowcodeattribute.NewErrorResponse(fmt.Errorf("failed rendering response: %v", err)), s)
+	}
+
+	if len(body) > 0 {
+		headers["Content-Type"] = contentType
+
+		if r.compresses(req.AcceptEncoding, len(body)) {
+			if body, err = compress(body); err != nil {
+				return events.APIGatewayProxyResponse{}, err
+			}
+			headers["Content-Encoding"] = "gzip"
+			binary = true
+		}
+	}
+
+	gateway := events.APIGatewayProxyResponse{
+		StatusCode:      response.StatusCode,
+		Headers:         headers,
+		IsBase64Encoded: binary,
+		Body:            string(body),
+	}
+	if binary {
+		gateway.Body = base64.StdEncoding.EncodeToString(body)
+	}
+
+	// the failures are described by the body, so the invocation succeeds
+	return gateway, nil
+}
+
+// notAcceptable creates the response of the requests whose formats can't be produced
+func notAcceptable(message string) *lowcodeattribute.ExecutionResponse {
+	err := lowcodeattribute.Errorf(lowcodeattribute.KindValidation, "%s", message)
+
+	problem := lowcodeattribute.NewProblem(http.StatusNotAcceptable, err)
+	problem.Code = "not_acceptable"
+
+	return &lowcodeattribute.ExecutionResponse{
+		StatusCode: http.StatusNotAcceptable,
+		Message:    problem,
+		Error:      err,
+	}
+}
+
+// negotiate chooses the format and content type of the response among the ones accepted by the
+// client, in order of preference. JSON is used when the client accepts any format, and for data
+// that is not made of records.
+func (r *Response) negotiate(accept string, data interface{}, s *schema.Schema) (string, string) {
+	if _, ok := records(data); !ok {
+		return FormatJSON, ContentTypeJSON
+	}
+
+	candidates := []struct{ format, media string }{
+		{FormatJSON, ContentTypeJSON},
+		{FormatNDJSON, ContentTypeNDJSON},
+		{FormatNDJSON, ContentTypeNDJSONAlt},
+		{FormatCSV, ContentTypeCSV},
+		{FormatAvro, schema.ContentTypeAvroOCF},
+		{FormatAvro, schema.ContentTypeAvroBinary},
+		{FormatAvro, schema.ContentTypeAvro},
+	}
+
+	for _, media := range parseAccept(accept) {
+		for _, candidate := range candidates {
+			if !r.allows(candidate.format) || !matches(media, candidate.media) {
+				continue
+			}
+
+			if candidate.format == FormatAvro {
+				// a single record is encoded per binary message, lists use container files
+				if s == nil {
+					continue
+				}
+				if _, single := data.(map[string]interface{}); !single && candidate.media != schema.ContentTypeAvroOCF {
+					continue
+				}
+			}
+
+			return candidate.format, candidate.media
+		}
+	}
+
+	return "", ""
+}
+
+// parseAccept returns the media ranges of the Accept header by order of preference. An empty
+// header accepts any format.
+func parseAccept(accept string) []string {
+	if strings.TrimSpace(accept) == "" {
+		return []string{"*/*"}
+	}
+
+	type mediaRange struct {
+		media   string
+		quality float64
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		media, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			ranges = append(ranges, mediaRange{media, quality})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	medias := make([]string, len(ranges))
+	for i, r := range ranges {
+		medias[i] = r.media
+	}
+
+	return medias
+}
+
+// matches reports whether the media range accepts the content type
+func matches(mediaRange, contentType string) bool {
+	if mediaRange == "*/*" || mediaRange == contentType {
+		return true
+	}
+
+	kind, _, _ := strings.Cut(contentType, "/")
+	return mediaRange == kind+"/*"
+}
+
+// compresses reports whether a body of the size received must be compressed for the client
+func (r *Response) compresses(acceptEncoding string, size int) bool {
+	if r == nil || !r.Compression {
+		return false
+	}
+
+	min := r.CompressionMinSize
+	if min == 0 {
+		min = DefaultCompressionMinSize
+	}
+	if size < min {
+		return false
+	}
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		encoding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(encoding), "gzip") && strings.TrimSpace(encoding) != "*" {
+			continue
+		}
+
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if quality, err := strconv.ParseFloat(q, 64); err == nil && quality == 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// compress compresses the body with gzip
+func compress(body []byte) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+
+	writer := gzip.NewWriter(buffer)
+	if _, err := writer.Write(body); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
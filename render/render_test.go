@@ -0,0 +1,200 @@
+package render
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/raywall/aws-lowcode-lambda-go/lowcodeattribute"
+	"github.com/raywall/aws-lowcode-lambda-go/schema"
+)
+
+const userAvro = `{
+  "type": "record",
+  "name": "User",
+  "fields": [
+    { "name": "UserID", "type": "string" },
+    { "name": "Name", "type": "string" }
+  ]
+}`
+
+var users = []interface{}{
+	map[string]interface{}{"UserID": "1", "Name": "Ana", "Address": map[string]interface{}{"City": "Lisbon"}},
+	map[string]interface{}{"UserID": "2", "Name": "Rui", "Address": map[string]interface{}{"City": "Porto"}},
+}
+
+func ok(message interface{}) *lowcodeattribute.ExecutionResponse {
+	return &lowcodeattribute.ExecutionResponse{StatusCode: http.StatusOK, Message: message}
+}
+
+func TestCompile(t *testing.T) {
+	if err := (&Response{Formats: []string{"JSON", "csv"}}).Compile(); err != nil {
+		t.Errorf("Compile() error = %v", err)
+	}
+	if err := (&Response{Formats: []string{"xml"}}).Compile(); err == nil {
+		t.Errorf("Compile() error = nil, want the unsupported format")
+	}
+	if err := (&Response{CompressionMinSize: -1}).Compile(); err == nil {
+		t.Errorf("Compile() error = nil, want the invalid CompressionMinSize")
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	if got := ParseFields(" UserID, ,Address.City "); !reflect.DeepEqual(got, []string{"UserID", "Address.City"}) {
+		t.Errorf("ParseFields() = %v, want [UserID Address.City]", got)
+	}
+}
+
+func TestRenderNegotiatesTheFormat(t *testing.T) {
+	s, err := schema.Parse([]byte(userAvro))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		response   *Response
+		accept     string
+		message    interface{}
+		wantStatus int
+		wantType   string
+		wantBinary bool
+	}{
+		{name: "any", accept: "", message: users, wantStatus: 200, wantType: ContentTypeJSON},
+		{name: "preference", accept: "text/csv;q=0.5, application/x-ndjson", message: users, wantStatus: 200, wantType: ContentTypeNDJSON},
+		{name: "wildcard subtype", accept: "text/*", message: users, wantStatus: 200, wantType: ContentTypeCSV},
+		{name: "avro list", accept: schema.ContentTypeAvroOCF, message: users, wantStatus: 200, wantType: schema.ContentTypeAvroOCF, wantBinary: true},
+		{name: "avro binary needs a record", accept: schema.ContentTypeAvroBinary, message: users, wantStatus: 406, wantType: lowcodeattribute.ContentTypeProblem},
+		{name: "avro record", accept: schema.ContentTypeAvroBinary, message: users[0], wantStatus: 200, wantType: schema.ContentTypeAvroBinary, wantBinary: true},
+		{name: "not records", accept: "text/csv", message: "done", wantStatus: 200, wantType: ContentTypeJSON},
+		{name: "format not allowed", response: &Response{Formats: []string{"json"}}, accept: "text/csv", message: users, wantStatus: 406, wantType: lowcodeattribute.ContentTypeProblem},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.response.Compile(); err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+
+			got, err := tt.response.Render(Request{Accept: tt.accept}, ok(tt.message), s)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if got.StatusCode != tt.wantStatus || got.Headers["Content-Type"] != tt.wantType || got.IsBase64Encoded != tt.wantBinary {
+				t.Errorf("Render() = %d %s binary %v, want %d %s binary %v", got.StatusCode, got.Headers["Content-Type"],
+					got.IsBase64Encoded, tt.wantStatus, tt.wantType, tt.wantBinary)
+			}
+		})
+	}
+}
+
+func TestRenderSelectsTheFields(t *testing.T) {
+	var r *Response
+
+	got, err := r.Render(Request{Accept: "text/csv", Fields: []string{"UserID", "Address.City"}}, ok(users), nil)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "UserID,Address.City\n1,Lisbon\n2,Porto\n"; got.Body != want {
+		t.Errorf("Render() body = %q, want %q", got.Body, want)
+	}
+
+	got, _ = r.Render(Request{Fields: []string{"Address.City"}}, ok(users[0]), nil)
+	if want := `{"Address":{"City":"Lisbon"}}`; got.Body != want {
+		t.Errorf("Render() body = %s, want %s", got.Body, want)
+	}
+}
+
+func TestRenderCSVFollowsTheSchema(t *testing.T) {
+	s, _ := schema.Parse([]byte(userAvro))
+
+	var r *Response
+	got, _ := r.Render(Request{Accept: "text/csv"}, ok(users[:1]), s)
+
+	if header, _, _ := strings.Cut(got.Body, "\n"); header != "UserID,Name,Address" {
+		t.Errorf("Render() header = %q, want UserID,Name,Address", header)
+	}
+}
+
+func TestRenderEnvelope(t *testing.T) {
+	r := &Response{Envelope: true}
+	response := ok(users)
+	response.Meta = map[string]interface{}{"cursor": "abc"}
+
+	got, _ := r.Render(Request{}, response, nil)
+
+	var envelope struct {
+		Data []interface{}          `json:"data"`
+		Meta map[string]interface{} `json:"meta"`
+	}
+	if err := json.Unmarshal([]byte(got.Body), &envelope); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(envelope.Data) != 2 || envelope.Meta["count"] != 2.0 || envelope.Meta["cursor"] != "abc" {
+		t.Errorf("Render() body = %s, want the records, the count and the cursor", got.Body)
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	failure := lowcodeattribute.NewErrorResponse(lowcodeattribute.Errorf(lowcodeattribute.KindNotFound, "user not found"))
+
+	var r *Response
+	got, _ := r.Render(Request{Instance: "/users/1"}, failure, nil)
+
+	var problem lowcodeattribute.Problem
+	if err := json.Unmarshal([]byte(got.Body), &problem); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got.StatusCode != 404 || got.Headers["Content-Type"] != lowcodeattribute.ContentTypeProblem ||
+		problem.Instance != "/users/1" || problem.Detail != "user not found" {
+		t.Errorf("Render() = %d %v %s, want the problem details", got.StatusCode, got.Headers, got.Body)
+	}
+
+	got, _ = (&Response{Envelope: true}).Render(Request{}, failure, nil)
+	if got.Headers["Content-Type"] != ContentTypeJSON || !strings.Contains(got.Body, `"errors":[`) {
+		t.Errorf("Render() = %v %s, want the problem inside the envelope", got.Headers, got.Body)
+	}
+}
+
+func TestRenderCompresses(t *testing.T) {
+	r := &Response{Compression: true, CompressionMinSize: 10}
+
+	got, _ := r.Render(Request{AcceptEncoding: "br, gzip"}, ok(users), nil)
+	if got.Headers["Content-Encoding"] != "gzip" || !got.IsBase64Encoded {
+		t.Fatalf("Render() headers = %v, want the gzip encoding", got.Headers)
+	}
+
+	compressed, _ := base64.StdEncoding.DecodeString(got.Body)
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+	body, _ := io.ReadAll(reader)
+	if !strings.HasPrefix(string(body), `[{"Address"`) {
+		t.Errorf("Render() body = %s, want the records", body)
+	}
+
+	for _, acceptEncoding := range []string{"", "gzip;q=0", "br"} {
+		if got, _ := r.Render(Request{AcceptEncoding: acceptEncoding}, ok(users), nil); got.IsBase64Encoded {
+			t.Errorf("Render() compressed the body with Accept-Encoding %q", acceptEncoding)
+		}
+	}
+	if got, _ := r.Render(Request{AcceptEncoding: "gzip"}, ok("small"), nil); got.IsBase64Encoded {
+		t.Errorf("Render() compressed a body smaller than CompressionMinSize")
+	}
+}
+
+func TestRenderNoContent(t *testing.T) {
+	var r *Response
+
+	got, _ := r.Render(Request{}, &lowcodeattribute.ExecutionResponse{StatusCode: http.StatusNoContent}, nil)
+	if got.Body != "" || got.Headers["Content-Type"] != "" {
+		t.Errorf("Render() = %v %q, want an empty body", got.Headers, got.Body)
+	}
+}
@@ -0,0 +1,279 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+var primitives = map[string]Type{
+	"null":    Null,
+	"boolean": Boolean,
+	"int":     Int,
+	"long":    Long,
+	"float":   Float,
+	"double":  Double,
+	"bytes":   Bytes,
+	"string":  String,
+}
+
+// avroParser keeps the named types already declared, allowing them to be referenced by name
+type avroParser struct {
+	named map[string]*Schema
+}
+
+// ParseAvro builds a schema from an Avro schema document.
+func ParseAvro(data []byte) (*Schema, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal avro schema: %v", err)
+	}
+
+	p := &avroParser{named: make(map[string]*Schema)}
+
+	s, err := p.parse(doc, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.format = FormatAvro
+	s.raw = data
+
+	// the document must also be accepted by the avro codec
+	if _, err := s.Codec(); err != nil {
+		return nil, fmt.Errorf("invalid avro schema: %v", err)
+	}
+
+	return s, nil
+}
+
+func (p *avroParser) parse(doc interface{}, namespace string) (*Schema, error) {
+	switch v := doc.(type) {
+	case string:
+		if t, ok := primitives[v]; ok {
+			return &Schema{Type: t}, nil
+		}
+
+		if s, ok := p.lookup(v, namespace); ok {
+			return s, nil
+		}
+
+		return nil, fmt.Errorf("unknown avro type: %s", v)
+	case []interface{}:
+		s := &Schema{Type: Union}
+		for _, item := range v {
+			t, err := p.parse(item, namespace)
+			if err != nil {
+				return nil, err
+			}
+			s.Types = append(s.Types, t)
+		}
+		return s, nil
+	case map[string]interface{}:
+		return p.parseComplex(v, namespace)
+	default:
+		return nil, fmt.Errorf("invalid avro schema: %v", doc)
+	}
+}
+
+func (p *avroParser) lookup(name, namespace string) (*Schema, bool) {
+	if s, ok := p.named[name]; ok {
+		return s, true
+	}
+
+	if namespace != "" && !strings.Contains(name, ".") {
+		s, ok := p.named[namespace+"."+name]
+		return s, ok
+	}
+
+	return nil, false
+}
+
+func (p *avroParser) register(s *Schema, doc map[string]interface{}, namespace string) error {
+	s.Name, _ = doc["name"].(string)
+	if s.Name == "" {
+		return fmt.Errorf("avro %s without name", s.Type)
+	}
+
+	s.Namespace = namespace
+	if ns, ok := doc["namespace"].(string); ok {
+		s.Namespace = ns
+	}
+	if i := strings.LastIndex(s.Name, "."); i >= 0 {
+		s.Namespace, s.Name = s.Name[:i], s.Name[i+1:]
+	}
+
+	s.Aliases = stringList(doc["aliases"])
+
+	p.named[s.FullName()] = s
+	return nil
+}
+
+func (p *avroParser) parseComplex(doc map[string]interface{}, namespace string) (*Schema, error) {
+	typeName, ok := doc["type"].(string)
+	if !ok {
+		// the type itself is a complex type or an union
+		return p.parse(doc["type"], namespace)
+	}
+
+	logicalType, _ := doc["logicalType"].(string)
+
+	switch typeName {
+	case "record", "error":
+		s := &Schema{Type: Record}
+		if err := p.register(s, doc, namespace); err != nil {
+			return nil, err
+		}
+
+		fields, ok := doc["fields"].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("avro record %s without fields", s.Name)
+		}
+
+		for _, item := range fields {
+			raw, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid field on avro record %s", s.Name)
+			}
+
+			field := &Field{Aliases: stringList(raw["aliases"])}
+			field.Name, _ = raw["name"].(string)
+			if field.Name == "" {
+				return nil, fmt.Errorf("avro record %s has a field without name", s.Name)
+			}
+
+			t, err := p.parse(raw["type"], s.Namespace)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %v", field.Name, err)
+			}
+			field.Schema = t
+			field.Default, field.HasDefault = raw["default"]
+
+			s.Fields = append(s.Fields, field)
+		}
+		return s, nil
+	case "enum":
+		s := &Schema{Type: Enum, Symbols: stringList(doc["symbols"])}
+		if err := p.register(s, doc, namespace); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "fixed":
+		s := &Schema{Type: Fixed, LogicalType: logicalType}
+		if err := p.register(s, doc, namespace); err != nil {
+			return nil, err
+		}
+		size, _ := doc["size"].(float64)
+		s.Size = int(size)
+		return s, nil
+	case "array":
+		items, err := p.parse(doc["items"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: Array, Items: items}, nil
+	case "map":
+		values, err := p.parse(doc["values"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: Map, Values: values}, nil
+	default:
+		s, err := p.parse(typeName, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		if logicalType != "" {
+			// named types are shared, so the logical type requires a copy
+			copied := &Schema{}
+			copied.assign(s)
+			copied.LogicalType = logicalType
+			return copied, nil
+		}
+		return s, nil
+	}
+}
+
+func stringList(v interface{}) []string {
+	items, _ := v.([]interface{})
+
+	list := []string{}
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+
+	return list
+}
+
+// AvroJSON returns the Avro schema document equivalent to the schema.
+func (s *Schema) AvroJSON() []byte {
+	data, _ := json.Marshal(s.avro(make(map[*Schema]bool)))
+	return data
+}
+
+func (s *Schema) avro(declared map[*Schema]bool) interface{} {
+	switch s.Type {
+	case Record, Enum, Fixed:
+		if declared[s] {
+			return s.FullName()
+		}
+		declared[s] = true
+	}
+
+	doc := map[string]interface{}{"type": string(s.Type)}
+	if s.LogicalType != "" {
+		doc["logicalType"] = s.LogicalType
+	}
+
+	switch s.Type {
+	case Union:
+		types := make([]interface{}, len(s.Types))
+		for i, t := range s.Types {
+			types[i] = t.avro(declared)
+		}
+		return types
+	case Record:
+		doc["name"] = s.Name
+		if s.Namespace != "" {
+			doc["namespace"] = s.Namespace
+		}
+
+		fields := make([]interface{}, len(s.Fields))
+		for i, f := range s.Fields {
+			field := map[string]interface{}{"name": f.Name}
+
+			switch {
+			case f.Optional && !f.HasDefault && !f.Schema.Nullable():
+				field["type"] = []interface{}{"null", f.Schema.avro(declared)}
+				field["default"] = nil
+			default:
+				field["type"] = f.Schema.avro(declared)
+				if f.HasDefault {
+					field["default"] = f.Default
+				}
+			}
+
+			fields[i] = field
+		}
+		doc["fields"] = fields
+	case Enum:
+		doc["name"] = s.Name
+		doc["symbols"] = s.Symbols
+	case Fixed:
+		doc["name"] = s.Name
+		doc["size"] = s.Size
+	case Array:
+		doc["items"] = s.Items.avro(declared)
+	case Map:
+		doc["values"] = s.Values.avro(declared)
+	default:
+		if s.LogicalType == "" {
+			return string(s.Type)
+		}
+	}
+
+	return doc
+}
@@ -0,0 +1,53 @@
+package schema
+
+import "testing"
+
+func BenchmarkDecodeBinary(b *testing.B) {
+	s := mustParse(b, userAvro).Element()
+	data, err := s.EncodeBinary(map[string]interface{}{
+		"UserID":  "42",
+		"Age":     30.0,
+		"Tags":    []interface{}{"a", "b"},
+		"Address": map[string]interface{}{"Street": "Main", "Number": 10.0},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.DecodeBinary(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkResolve(b *testing.B) {
+	v1, v2 := mustParse(b, userV1).Element(), mustParse(b, userV2).Element()
+	item := map[string]interface{}{"UserID": "42", "Name": "Ana", "Age": 30.0, "Legacy": "x"}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := v2.ResolveCompatible(v1, item, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCoerce(b *testing.B) {
+	s := mustParse(b, userAvro).Element()
+	record := map[string]interface{}{
+		"UserID":  "42",
+		"Age":     30.0,
+		"Balance": "1000",
+		"Tags":    []interface{}{"a", "b"},
+		"Address": map[string]interface{}{"Street": "Main", "Number": 10.0},
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Coerce(record); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package schema
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// DynamoDBType returns the DynamoDB attribute type used to store the values of the schema: S, N,
+// B, BOOL, NULL, M or L. Unions of several non-null types have no fixed type, so an empty string
+// is returned for them.
+func (s *Schema) DynamoDBType() string {
+	t := s.NonNull()
+
+	switch t.Type {
+	case Null:
+		return "NULL"
+	case Boolean:
+		return "BOOL"
+	case Int, Long, Float, Double:
+		return dynamodb.ScalarAttributeTypeN
+	case String, Enum:
+		return dynamodb.ScalarAttributeTypeS
+	case Bytes, Fixed:
+		return dynamodb.ScalarAttributeTypeB
+	case Record, Map:
+		return "M"
+	case Array:
+		return "L"
+	default:
+		return ""
+	}
+}
+
+// AttributeValue converts a value into the DynamoDB attribute of the type mapped from the schema,
+// so a numeric field received as a string, like a path parameter, is stored as a number.
+func (s *Schema) AttributeValue(value interface{}) (*dynamodb.AttributeValue, error) {
+	coerced, err := s.CoercePartial(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return dynamodbattribute.Marshal(coerced)
+}
+
+// AttributeValues converts the attributes of a record into DynamoDB attributes using the types
+// mapped from the fields of the schema. Attributes not declared by the schema are kept as received.
+func (s *Schema) AttributeValues(values map[string]interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	record := s.Element()
+
+	result := make(map[string]*dynamodb.AttributeValue, len(values))
+	for name, value := range values {
+		var (
+			attribute *dynamodb.AttributeValue
+			err       error
+		)
+
+		if field := record.Field(name); field != nil {
+			attribute, err = field.Schema.AttributeValue(value)
+			if verr, ok := err.(*ValidationError); ok {
+				err = verr.prefix(name)
+			}
+		} else {
+			attribute, err = dynamodbattribute.Marshal(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result[name] = attribute
+	}
+
+	return result, nil
+}
@@ -0,0 +1,231 @@
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	goavro "github.com/linkedin/goavro/v2"
+)
+
+// Content types of the payloads encoded with Avro.
+const (
+	// ContentTypeAvroBinary identifies an Avro binary payload, using the single-object encoding
+	// when it starts with its marker
+	ContentTypeAvroBinary = "avro/binary"
+	// ContentTypeAvro is an alias of ContentTypeAvroBinary
+	ContentTypeAvro = "application/avro"
+	// ContentTypeAvroOCF identifies an Avro Object Container File, used for batches of records
+	ContentTypeAvroOCF = "application/vnd.apache.avro+ocf"
+	// ContentTypeAvroSingleObject identifies an Avro single-object encoded payload
+	ContentTypeAvroSingleObject = "application/vnd.apache.avro+single"
+)
+
+// singleObjectMarker is the header of the Avro single-object encoding
+var singleObjectMarker = []byte{0xC3, 0x01}
+
+// IsSingleObject reports whether data uses the Avro single-object encoding.
+func IsSingleObject(data []byte) bool {
+	return bytes.HasPrefix(data, singleObjectMarker)
+}
+
+// Fingerprint returns the CRC-64-AVRO (Rabin) fingerprint of the canonical form of the schema, as
+// used by the single-object encoding.
+func (s *Schema) Fingerprint() (uint64, error) {
+	codec, err := s.Codec()
+	if err != nil {
+		return 0, err
+	}
+
+	return codec.Rabin, nil
+}
+
+// EncodeBinary encodes the value, which is coerced to the schema first, with the Avro binary encoding.
+func (s *Schema) EncodeBinary(value interface{}) ([]byte, error) {
+	codec, native, err := s.prepare(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return codec.BinaryFromNative(nil, native)
+}
+
+// EncodeSingle encodes the value with the Avro single-object encoding, whose header carries the
+// fingerprint of the schema.
+func (s *Schema) EncodeSingle(value interface{}) ([]byte, error) {
+	codec, native, err := s.prepare(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return codec.SingleFromNative(nil, native)
+}
+
+// EncodeOCF writes the values as an Avro Object Container File.
+func (s *Schema) EncodeOCF(w io.Writer, values []interface{}) error {
+	codec, err := s.Codec()
+	if err != nil {
+		return err
+	}
+
+	writer, err := goavro.NewOCFWriter(goavro.OCFConfig{W: w, Codec: codec})
+	if err != nil {
+		return err
+	}
+
+	natives := make([]interface{}, len(values))
+	for i, value := range values {
+		if _, natives[i], err = s.prepare(value); err != nil {
+			return err
+		}
+	}
+
+	return writer.Append(natives)
+}
+
+// DecodeBinary decodes a payload encoded with the Avro binary encoding. Payloads starting with the
+// single-object marker must carry the fingerprint of the schema.
+func (s *Schema) DecodeBinary(data []byte) (interface{}, error) {
+	codec, err := s.Codec()
+	if err != nil {
+		return nil, err
+	}
+
+	var native interface{}
+	if IsSingleObject(data) {
+		native, _, err = codec.NativeFromSingle(data)
+
+		var wrongCodec goavro.ErrWrongCodec
+		if errors.As(err, &wrongCodec) {
+			return nil, fmt.Errorf("payload written with an unknown schema, fingerprint %d", uint64(wrongCodec))
+		}
+	} else {
+		native, _, err = codec.NativeFromBinary(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Coerce(native)
+}
+
+// DecodeOCF decodes all the records of an Avro Object Container File. The records are written with
+// the schema found on the header of the file, so they are resolved to the schema afterwards.
+func (s *Schema) DecodeOCF(r io.Reader) ([]interface{}, error) {
+	reader, err := goavro.NewOCFReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := ParseAvro([]byte(reader.Codec().Schema()))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CheckCompatibility(s, writer); err != nil {
+		return nil, err
+	}
+
+	values := []interface{}{}
+	for reader.Scan() {
+		native, err := reader.Read()
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := s.resolve(writer, native)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, reader.Err()
+}
+
+// prepare coerces the value to the schema and converts it into the native form of the avro codec
+func (s *Schema) prepare(value interface{}) (*goavro.Codec, interface{}, error) {
+	codec, err := s.Codec()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	coerced, err := s.Coerce(value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return codec, avroNative(s, coerced), nil
+}
+
+// avroNative wraps the values of the unions with their type name, as expected by the avro codec
+func avroNative(s *Schema, value interface{}) interface{} {
+	switch s.Type {
+	case Union:
+		if value == nil {
+			return nil
+		}
+
+		for _, t := range s.Types {
+			if t.Type == Null {
+				continue
+			}
+
+			attempt := &coercion{}
+			if attempt.coerce(t, value, ""); len(attempt.errors) == 0 {
+				return goavro.Union(branchName(t), avroNative(t, value))
+			}
+		}
+		return value
+	case Record:
+		values, _ := value.(map[string]interface{})
+
+		native := make(map[string]interface{}, len(s.Fields))
+		for _, field := range s.Fields {
+			v := values[field.Name]
+
+			// optional fields are encoded as an union with null, see AvroJSON
+			if field.Optional && !field.HasDefault && !field.Schema.Nullable() {
+				if v != nil {
+					native[field.Name] = goavro.Union(branchName(field.Schema), avroNative(field.Schema, v))
+				} else {
+					native[field.Name] = nil
+				}
+				continue
+			}
+
+			native[field.Name] = avroNative(field.Schema, v)
+		}
+		return native
+	case Array:
+		items, _ := value.([]interface{})
+
+		native := make([]interface{}, len(items))
+		for i, item := range items {
+			native[i] = avroNative(s.Items, item)
+		}
+		return native
+	case Map:
+		values, _ := value.(map[string]interface{})
+
+		native := make(map[string]interface{}, len(values))
+		for key, v := range values {
+			native[key] = avroNative(s.Values, v)
+		}
+		return native
+	default:
+		return value
+	}
+}
+
+// branchName is the name used by the avro codec to identify a type of an union
+func branchName(s *Schema) string {
+	switch s.Type {
+	case Record, Enum, Fixed:
+		return s.FullName()
+	default:
+		return string(s.Type)
+	}
+}
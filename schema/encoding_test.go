@@ -0,0 +1,71 @@
+package schema
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	s := mustParse(t, userAvro).Element()
+
+	record, err := s.Coerce(map[string]interface{}{"UserID": "42", "Age": 30.0, "Tags": []interface{}{"a"}})
+	if err != nil {
+		t.Fatalf("Coerce() error = %v", err)
+	}
+
+	data, err := s.EncodeBinary(record)
+	if err != nil {
+		t.Fatalf("EncodeBinary() error = %v", err)
+	}
+
+	got, err := s.DecodeBinary(data)
+	if err != nil {
+		t.Fatalf("DecodeBinary() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, record) {
+		t.Errorf("DecodeBinary() = %#v, want %#v", got, record)
+	}
+}
+
+func TestSingleObjectRoundTrip(t *testing.T) {
+	s := mustParse(t, userAvro).Element()
+	record := map[string]interface{}{"UserID": "42", "Age": 30.0}
+
+	data, err := s.EncodeSingle(record)
+	if err != nil {
+		t.Fatalf("EncodeSingle() error = %v", err)
+	}
+	if !IsSingleObject(data) {
+		t.Fatalf("IsSingleObject() = false, want true")
+	}
+
+	got, err := s.DecodeBinary(data)
+	if err != nil {
+		t.Fatalf("DecodeBinary() error = %v", err)
+	}
+	if got.(map[string]interface{})["UserID"] != "42" {
+		t.Errorf("DecodeBinary() = %#v, want the record encoded", got)
+	}
+}
+
+func TestOCFRoundTrip(t *testing.T) {
+	s := mustParse(t, userAvro).Element()
+	records := []interface{}{
+		map[string]interface{}{"UserID": "1", "Age": 1.0},
+		map[string]interface{}{"UserID": "2", "Age": 2.0},
+	}
+
+	var buf bytes.Buffer
+	if err := s.EncodeOCF(&buf, records); err != nil {
+		t.Fatalf("EncodeOCF() error = %v", err)
+	}
+
+	got, err := s.DecodeOCF(&buf)
+	if err != nil {
+		t.Fatalf("DecodeOCF() error = %v", err)
+	}
+	if len(got) != 2 || got[1].(map[string]interface{})["UserID"] != "2" {
+		t.Errorf("DecodeOCF() = %#v, want the records encoded", got)
+	}
+}
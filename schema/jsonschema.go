@@ -0,0 +1,416 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// object is a JSON object that keeps the order of its keys, as the order of the properties defines
+// the order of the fields of the record
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *object) get(key string) (interface{}, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// jsonSchemaParser keeps the definitions already resolved, allowing recursive references
+type jsonSchemaParser struct {
+	root *object
+	refs map[string]*Schema
+}
+
+// ParseJSONSchema builds a schema from a JSON Schema document. Besides the types, it supports the
+// keywords required, default, enum, const, additionalProperties, oneOf, anyOf, local $ref and the
+// numeric, string and array constraints.
+func ParseJSONSchema(data []byte) (*Schema, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	doc, err := decodeOrdered(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal json schema: %v", err)
+	}
+
+	root, ok := doc.(*object)
+	if !ok {
+		return nil, fmt.Errorf("json schema must be an object")
+	}
+
+	p := &jsonSchemaParser{root: root, refs: make(map[string]*Schema)}
+
+	s, err := p.parse(root, "Root")
+	if err != nil {
+		return nil, err
+	}
+
+	s.format = FormatJSONSchema
+	s.raw = data
+
+	return s, nil
+}
+
+func decodeOrdered(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := &object{values: make(map[string]interface{})}
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				value, err := decodeOrdered(decoder)
+				if err != nil {
+					return nil, err
+				}
+
+				obj.keys = append(obj.keys, key.(string))
+				obj.values[key.(string)] = value
+			}
+			_, err := decoder.Token()
+			return obj, err
+		case '[':
+			list := []interface{}{}
+			for decoder.More() {
+				value, err := decodeOrdered(decoder)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+			_, err := decoder.Token()
+			return list, err
+		}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return float64(i), nil
+		}
+		return t.Float64()
+	}
+
+	return token, nil
+}
+
+// plain converts the ordered objects back to maps, as used on default values
+func plain(v interface{}) interface{} {
+	switch t := v.(type) {
+	case *object:
+		m := make(map[string]interface{}, len(t.keys))
+		for _, key := range t.keys {
+			m[key] = plain(t.values[key])
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(t))
+		for i, item := range t {
+			list[i] = plain(item)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+func (p *jsonSchemaParser) parse(doc *object, name string) (*Schema, error) {
+	for _, keyword := range []string{"allOf", "not", "if"} {
+		if _, ok := doc.get(keyword); ok {
+			return nil, fmt.Errorf("json schema keyword %s is not supported", keyword)
+		}
+	}
+
+	if ref, ok := doc.get("$ref"); ok {
+		return p.resolve(fmt.Sprint(ref))
+	}
+
+	if title, ok := doc.get("title"); ok {
+		name = avroName(fmt.Sprint(title))
+	}
+
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if options, ok := doc.get(keyword); ok {
+			return p.union(options, name)
+		}
+	}
+
+	if c, ok := doc.get("const"); ok {
+		doc.values["enum"] = []interface{}{c}
+	}
+
+	types := []string{}
+	switch t := doc.values["type"].(type) {
+	case string:
+		types = append(types, t)
+	case []interface{}:
+		for _, item := range t {
+			types = append(types, fmt.Sprint(item))
+		}
+	case nil:
+		switch {
+		case has(doc, "properties"):
+			types = append(types, "object")
+		case has(doc, "items"):
+			types = append(types, "array")
+		case has(doc, "enum"):
+			types = append(types, "string")
+		default:
+			// schemas without type accept any scalar value
+			types = append(types, "null", "boolean", "integer", "number", "string")
+		}
+	}
+
+	if len(types) == 1 {
+		return p.parseType(doc, types[0], name)
+	}
+
+	s := &Schema{Type: Union}
+	for _, t := range types {
+		option, err := p.parseType(doc, t, name)
+		if err != nil {
+			return nil, err
+		}
+		s.Types = append(s.Types, option)
+	}
+
+	return s, nil
+}
+
+func (p *jsonSchemaParser) union(options interface{}, name string) (*Schema, error) {
+	list, ok := options.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("json schema %s has invalid options", name)
+	}
+
+	s := &Schema{Type: Union}
+	for i, item := range list {
+		doc, ok := item.(*object)
+		if !ok {
+			return nil, fmt.Errorf("json schema %s has invalid options", name)
+		}
+
+		option, err := p.parse(doc, fmt.Sprintf("%s%d", name, i))
+		if err != nil {
+			return nil, err
+		}
+		s.Types = append(s.Types, option)
+	}
+
+	return s, nil
+}
+
+func (p *jsonSchemaParser) resolve(ref string) (*Schema, error) {
+	if s, ok := p.refs[ref]; ok {
+		return s, nil
+	}
+
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("json schema reference %s is not supported", ref)
+	}
+
+	var current interface{} = p.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		obj, ok := current.(*object)
+		if !ok {
+			return nil, fmt.Errorf("json schema reference %s not found", ref)
+		}
+
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		if current, ok = obj.get(part); !ok {
+			return nil, fmt.Errorf("json schema reference %s not found", ref)
+		}
+	}
+
+	doc, ok := current.(*object)
+	if !ok {
+		return nil, fmt.Errorf("json schema reference %s is not a schema", ref)
+	}
+
+	// the schema is registered before being parsed, so recursive references point to it
+	s := &Schema{}
+	p.refs[ref] = s
+
+	parts := strings.Split(ref, "/")
+	parsed, err := p.parse(doc, avroName(parts[len(parts)-1]))
+	if err != nil {
+		return nil, err
+	}
+
+	s.assign(parsed)
+	return s, nil
+}
+
+func (p *jsonSchemaParser) parseType(doc *object, typeName, name string) (*Schema, error) {
+	var s *Schema
+
+	switch typeName {
+	case "null":
+		s = &Schema{Type: Null}
+	case "boolean":
+		s = &Schema{Type: Boolean}
+	case "integer":
+		s = &Schema{Type: Long}
+	case "number":
+		s = &Schema{Type: Double}
+	case "string":
+		s = &Schema{Type: String}
+		if symbols, ok := doc.get("enum"); ok {
+			s = &Schema{Type: Enum, Name: name, Symbols: stringList(symbols)}
+		}
+	case "array":
+		items := &object{values: map[string]interface{}{}}
+		if v, ok := doc.get("items"); ok {
+			if items, ok = v.(*object); !ok {
+				return nil, fmt.Errorf("json schema %s: tuple items are not supported", name)
+			}
+		}
+
+		itemSchema, err := p.parse(items, name+"Item")
+		if err != nil {
+			return nil, err
+		}
+		s = &Schema{Type: Array, Items: itemSchema}
+	case "object":
+		var err error
+		if s, err = p.parseObject(doc, name); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("json schema %s has unknown type %s", name, typeName)
+	}
+
+	constraints, err := parseConstraints(doc)
+	if err != nil {
+		return nil, fmt.Errorf("json schema %s: %v", name, err)
+	}
+	s.Constraints = constraints
+
+	return s, nil
+}
+
+func (p *jsonSchemaParser) parseObject(doc *object, name string) (*Schema, error) {
+	properties, hasProperties := doc.values["properties"].(*object)
+	additional, hasAdditional := doc.get("additionalProperties")
+
+	// objects without properties are maps of the additional properties
+	if !hasProperties {
+		values := &Schema{Type: Union, Types: []*Schema{
+			{Type: Null}, {Type: Boolean}, {Type: Long}, {Type: Double}, {Type: String},
+		}}
+
+		if additionalSchema, ok := additional.(*object); ok {
+			var err error
+			if values, err = p.parse(additionalSchema, name+"Value"); err != nil {
+				return nil, err
+			}
+		}
+
+		return &Schema{Type: Map, Values: values}, nil
+	}
+
+	s := &Schema{Type: Record, Name: name}
+	s.Closed = hasAdditional && additional == false
+
+	required := make(map[string]bool)
+	for _, field := range stringList(doc.values["required"]) {
+		required[field] = true
+	}
+
+	for _, key := range properties.keys {
+		property, ok := properties.values[key].(*object)
+		if !ok {
+			return nil, fmt.Errorf("json schema %s has an invalid property %s", name, key)
+		}
+
+		fieldSchema, err := p.parse(property, s.Name+avroName(key))
+		if err != nil {
+			return nil, err
+		}
+
+		field := &Field{Name: key, Schema: fieldSchema, Optional: !required[key]}
+		if v, ok := property.get("default"); ok {
+			field.Default, field.HasDefault = plain(v), true
+		}
+
+		s.Fields = append(s.Fields, field)
+	}
+
+	return s, nil
+}
+
+func parseConstraints(doc *object) (*Constraints, error) {
+	c := &Constraints{}
+	found := false
+
+	numbers := map[string]**float64{
+		"minimum":          &c.Minimum,
+		"maximum":          &c.Maximum,
+		"exclusiveMinimum": &c.ExclusiveMinimum,
+		"exclusiveMaximum": &c.ExclusiveMaximum,
+	}
+	for keyword, target := range numbers {
+		if v, ok := doc.values[keyword].(float64); ok {
+			value := v
+			*target = &value
+			found = true
+		}
+	}
+
+	lengths := map[string]**int{
+		"minLength": &c.MinLength,
+		"maxLength": &c.MaxLength,
+		"minItems":  &c.MinItems,
+		"maxItems":  &c.MaxItems,
+	}
+	for keyword, target := range lengths {
+		if v, ok := doc.values[keyword].(float64); ok {
+			value := int(v)
+			*target = &value
+			found = true
+		}
+	}
+
+	if pattern, ok := doc.values["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %v", pattern, err)
+		}
+		c.Pattern = re
+		found = true
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	return c, nil
+}
+
+func has(doc *object, key string) bool {
+	_, ok := doc.get(key)
+	return ok
+}
+
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// avroName converts a title or property name into a valid avro name
+func avroName(name string) string {
+	name = invalidNameChars.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,299 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Content types of the payloads encoded with protobuf.
+const (
+	// ContentTypeProtobuf identifies a message encoded with the protobuf binary format
+	ContentTypeProtobuf = "application/x-protobuf"
+	// ContentTypeProtobufAlt is an alias of ContentTypeProtobuf
+	ContentTypeProtobufAlt = "application/protobuf"
+)
+
+// ParseProto builds a schema from a message of a protobuf descriptor set (FileDescriptorSet), as
+// generated by 'protoc --include_imports --descriptor_set_out'. The message is identified by its
+// full name, like 'users.v1.User'.
+func ParseProto(data []byte, message string) (*Schema, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %v", err)
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %v", err)
+	}
+
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(message))
+	if err != nil {
+		return nil, fmt.Errorf("message %s not found: %v", message, err)
+	}
+
+	md, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message", message)
+	}
+
+	p := &protoParser{named: make(map[protoreflect.FullName]*Schema)}
+
+	s := p.message(md)
+	if p.err != nil {
+		return nil, fmt.Errorf("message %s: %v", message, p.err)
+	}
+	s.format = FormatProtobuf
+	s.raw = data
+	s.message = md
+
+	if _, err := s.Codec(); err != nil {
+		return nil, fmt.Errorf("message %s cannot be represented as avro: %v", message, err)
+	}
+
+	return s, nil
+}
+
+// protoParser keeps the messages and enums already converted, so recursive messages are declared
+// once, and the first error found
+type protoParser struct {
+	named map[protoreflect.FullName]*Schema
+	err   error
+}
+
+func (p *protoParser) message(md protoreflect.MessageDescriptor) *Schema {
+	if s, ok := p.named[md.FullName()]; ok {
+		return s
+	}
+
+	s := &Schema{
+		Type:      Record,
+		Name:      string(md.Name()),
+		Namespace: namespace(md.FullName()),
+	}
+	p.named[md.FullName()] = s
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		s.Fields = append(s.Fields, p.field(fields.Get(i)))
+	}
+
+	return s
+}
+
+func (p *protoParser) field(fd protoreflect.FieldDescriptor) *Field {
+	field := &Field{
+		Name:    string(fd.Name()),
+		Aliases: []string{fd.JSONName()},
+		Schema:  p.value(fd),
+	}
+	if fd.JSONName() == field.Name {
+		field.Aliases = nil
+	}
+
+	switch {
+	case fd.Cardinality() == protoreflect.Required:
+		// proto2 required fields must be informed
+	case fd.IsList():
+		field.Default, field.HasDefault = []interface{}{}, true
+	case fd.IsMap():
+		field.Default, field.HasDefault = map[string]interface{}{}, true
+	case fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind || fd.HasPresence():
+		// unset messages and fields with presence tracking have no value
+		field.Optional = true
+	default:
+		// unset scalar fields take the default value of their type
+		value, err := protoNative(fd, fd.Default())
+		if err != nil && p.err == nil {
+			p.err = err
+		}
+		field.Default, field.HasDefault = value, true
+	}
+
+	return field
+}
+
+// value converts the type of the values of a field, which are a list or a map when repeated
+func (p *protoParser) value(fd protoreflect.FieldDescriptor) *Schema {
+	switch {
+	case fd.IsMap():
+		return &Schema{Type: Map, Values: p.scalar(fd.MapValue())}
+	case fd.IsList():
+		return &Schema{Type: Array, Items: p.scalar(fd)}
+	default:
+		return p.scalar(fd)
+	}
+}
+
+func (p *protoParser) scalar(fd protoreflect.FieldDescriptor) *Schema {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return &Schema{Type: Boolean}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &Schema{Type: Int}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &Schema{Type: Long}
+	case protoreflect.FloatKind:
+		return &Schema{Type: Float}
+	case protoreflect.DoubleKind:
+		return &Schema{Type: Double}
+	case protoreflect.StringKind:
+		return &Schema{Type: String}
+	case protoreflect.BytesKind:
+		return &Schema{Type: Bytes}
+	case protoreflect.EnumKind:
+		return p.enum(fd.Enum())
+	default:
+		return p.message(fd.Message())
+	}
+}
+
+func (p *protoParser) enum(ed protoreflect.EnumDescriptor) *Schema {
+	if s, ok := p.named[ed.FullName()]; ok {
+		return s
+	}
+
+	s := &Schema{
+		Type:      Enum,
+		Name:      string(ed.Name()),
+		Namespace: namespace(ed.FullName()),
+	}
+
+	values := ed.Values()
+	for i := 0; i < values.Len(); i++ {
+		s.Symbols = append(s.Symbols, string(values.Get(i).Name()))
+	}
+
+	p.named[ed.FullName()] = s
+	return s
+}
+
+// namespace returns the package, or the enclosing message, of a protobuf type
+func namespace(name protoreflect.FullName) string {
+	if i := strings.LastIndex(string(name), "."); i >= 0 {
+		return string(name[:i])
+	}
+
+	return ""
+}
+
+// DecodeProto decodes a message encoded with the protobuf binary format. Only the schemas built
+// from a protobuf descriptor can decode them. Like DecodeProtoJSON, the fields not populated are
+// left out, so the default values are only filled by Coerce.
+func (s *Schema) DecodeProto(data []byte) (interface{}, error) {
+	if s.message == nil {
+		return nil, fmt.Errorf("schema %s is not a protobuf message", s.FullName())
+	}
+
+	msg := dynamicpb.NewMessage(s.message)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+
+	values, err := protoMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.CoercePartial(values)
+}
+
+// DecodeProtoJSON decodes a message encoded with the protobuf JSON mapping, accepting the original
+// and the JSON names of the fields. Unknown fields are ignored and, like on the JSON bodies, the
+// fields not informed are left out, so the default values are only filled by Coerce.
+func (s *Schema) DecodeProtoJSON(data []byte) (interface{}, error) {
+	if s.message == nil {
+		return nil, fmt.Errorf("schema %s is not a protobuf message", s.FullName())
+	}
+
+	msg := dynamicpb.NewMessage(s.message)
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+
+	values, err := protoMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.CoercePartial(values)
+}
+
+// protoMessage converts a message into the native values used by the schemas, keyed by the
+// original names of the fields. The fields not populated are left out.
+func protoMessage(msg protoreflect.Message) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if !msg.Has(fd) {
+			continue
+		}
+
+		value := msg.Get(fd)
+
+		var (
+			native interface{}
+			err    error
+		)
+
+		switch {
+		case fd.IsList():
+			list := value.List()
+			items := make([]interface{}, list.Len())
+			for j := range items {
+				if items[j], err = protoNative(fd, list.Get(j)); err != nil {
+					break
+				}
+			}
+			native = items
+		case fd.IsMap():
+			entries := map[string]interface{}{}
+			value.Map().Range(func(key protoreflect.MapKey, v protoreflect.Value) bool {
+				entries[key.String()], err = protoNative(fd.MapValue(), v)
+				return err == nil
+			})
+			native = entries
+		default:
+			native, err = protoNative(fd, value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", fd.Name(), err)
+		}
+
+		values[string(fd.Name())] = native
+	}
+
+	return values, nil
+}
+
+// protoNative converts a single protobuf value of the field into its native value. The unsigned
+// integers are stored as longs, so the values above math.MaxInt64 are refused.
+func protoNative(fd protoreflect.FieldDescriptor, value protoreflect.Value) (interface{}, error) {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(value.Enum()); ev != nil {
+			return string(ev.Name()), nil
+		}
+		return int32(value.Enum()), nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return protoMessage(value.Message())
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if value.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("the value %d doesn't fit a long", value.Uint())
+		}
+		return int64(value.Uint()), nil
+	default:
+		return value.Interface(), nil
+	}
+}
@@ -0,0 +1,155 @@
+package schema
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// userDescriptor returns the descriptor set of the message users.v1.User
+func userDescriptor(t *testing.T) []byte {
+	t.Helper()
+
+	field := func(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     kind.Enum(),
+			Label:    label.Enum(),
+		}
+	}
+
+	status := field("status", 5, descriptorpb.FieldDescriptorProto_TYPE_ENUM, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL)
+	status.TypeName = proto.String(".users.v1.Status")
+
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:    proto.String("users/v1/user.proto"),
+		Package: proto.String("users.v1"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("UNKNOWN"), Number: proto.Int32(0)},
+				{Name: proto.String("ACTIVE"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("User"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+				field("visits", 2, descriptorpb.FieldDescriptorProto_TYPE_UINT64, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+				field("age", 3, descriptorpb.FieldDescriptorProto_TYPE_INT32, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+				field("tags", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_REPEATED),
+				status,
+			},
+		}},
+	}}}
+
+	data, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestParseProto(t *testing.T) {
+	s, err := ParseProto(userDescriptor(t), "users.v1.User")
+	if err != nil {
+		t.Fatalf("ParseProto() error = %v", err)
+	}
+
+	if s.Format() != FormatProtobuf || s.FullName() != "users.v1.User" {
+		t.Errorf("ParseProto() = %s %s, want the protobuf message users.v1.User", s.Format(), s.FullName())
+	}
+
+	tests := []struct {
+		field   string
+		want    Type
+		initial interface{}
+	}{
+		{field: "name", want: String, initial: ""},
+		{field: "visits", want: Long, initial: int64(0)},
+		{field: "age", want: Int, initial: int32(0)},
+		{field: "tags", want: Array, initial: []interface{}{}},
+		{field: "status", want: Enum, initial: "UNKNOWN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			field := s.Field(tt.field)
+			if field == nil || field.Schema.Type != tt.want {
+				t.Fatalf("Field(%s) = %+v, want %s", tt.field, field, tt.want)
+			}
+			if !field.HasDefault || !reflect.DeepEqual(field.Default, tt.initial) {
+				t.Errorf("Field(%s) default = %#v, want %#v", tt.field, field.Default, tt.initial)
+			}
+		})
+	}
+
+	if _, err := ParseProto(userDescriptor(t), "users.v1.Account"); err == nil {
+		t.Errorf("ParseProto() error = nil, want the message not found")
+	}
+}
+
+func TestDecodeProto(t *testing.T) {
+	s, err := ParseProto(userDescriptor(t), "users.v1.User")
+	if err != nil {
+		t.Fatalf("ParseProto() error = %v", err)
+	}
+
+	// encode builds the binary message with the fields informed
+	encode := func(visits uint64) []byte {
+		msg := dynamicpb.NewMessage(s.message)
+		fields := s.message.Fields()
+		msg.Set(fields.ByName("name"), protoreflect.ValueOfString("Ana"))
+		msg.Set(fields.ByName("visits"), protoreflect.ValueOfUint64(visits))
+		msg.Set(fields.ByName("status"), protoreflect.ValueOfEnum(1))
+
+		data, err := proto.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	// the fields not informed are left out by both formats
+	want := map[string]interface{}{"name": "Ana", "visits": int64(math.MaxInt64), "status": "ACTIVE"}
+
+	tests := []struct {
+		name    string
+		decode  func([]byte) (interface{}, error)
+		data    []byte
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "binary", decode: s.DecodeProto, data: encode(math.MaxInt64), want: want},
+		{name: "json", decode: s.DecodeProtoJSON, data: []byte(`{"name": "Ana", "visits": "9223372036854775807", "status": "ACTIVE"}`), want: want},
+		{name: "binary unsigned beyond the longs", decode: s.DecodeProto, data: encode(math.MaxInt64 + 1), wantErr: true},
+		{name: "json unsigned beyond the longs", decode: s.DecodeProtoJSON, data: []byte(`{"visits": "18446744073709551615"}`), wantErr: true},
+		{name: "invalid binary", decode: s.DecodeProto, data: []byte{0xff}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.decode(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decode() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	plain := &Schema{Type: Record, Name: "User"}
+	if _, err := plain.DecodeProto(nil); err == nil {
+		t.Errorf("DecodeProto() error = nil, want the schema refused")
+	}
+}
@@ -0,0 +1,250 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CompatibilityError lists the reasons why data written with a schema cannot be read with another.
+type CompatibilityError struct {
+	Reasons []string
+}
+
+func (e *CompatibilityError) Error() string {
+	return fmt.Sprintf("incompatible schemas: %s", strings.Join(e.Reasons, "; "))
+}
+
+type pair struct {
+	reader, writer *Schema
+}
+
+// compatibility keeps the state of a compatibility check, including the pairs of schemas already
+// visited, so recursive schemas don't loop forever
+type compatibility struct {
+	visited map[pair]bool
+	reasons []string
+}
+
+// CheckCompatibility verifies, following the Avro schema resolution rules, whether the data written
+// with the writer schema can be read with the reader schema. A reader able to read the data written
+// with the previous version of a schema is backward compatible with it.
+func CheckCompatibility(reader, writer *Schema) error {
+	c := &compatibility{visited: make(map[pair]bool)}
+	c.check(reader, writer, "")
+
+	if len(c.reasons) > 0 {
+		return &CompatibilityError{Reasons: c.reasons}
+	}
+
+	return nil
+}
+
+func (c *compatibility) fail(path string, format string, args ...interface{}) {
+	reason := fmt.Sprintf(format, args...)
+	if path != "" {
+		reason = fmt.Sprintf("%s: %s", path, reason)
+	}
+
+	c.reasons = append(c.reasons, reason)
+}
+
+func (c *compatibility) check(reader, writer *Schema, path string) {
+	key := pair{reader, writer}
+	if c.visited[key] {
+		return
+	}
+	c.visited[key] = true
+
+	// every type of the writer union must be readable
+	if writer.Type == Union {
+		for _, t := range writer.Types {
+			c.check(reader, t, path)
+		}
+		return
+	}
+
+	if reader.Type == Union {
+		for _, t := range reader.Types {
+			attempt := &compatibility{visited: c.visited}
+			if attempt.check(t, writer, path); len(attempt.reasons) == 0 {
+				return
+			}
+		}
+
+		c.fail(path, "%s is not accepted by %s", writer, reader)
+		return
+	}
+
+	if !promotable(writer.Type, reader.Type) {
+		c.fail(path, "%s cannot be read as %s", writer, reader)
+		return
+	}
+
+	switch reader.Type {
+	case Record:
+		for _, field := range reader.Fields {
+			fieldPath := join(path, field.Name)
+
+			if writerField := writer.findField(field); writerField != nil {
+				c.check(field.Schema, writerField.Schema, fieldPath)
+				continue
+			}
+
+			if !field.HasDefault && !field.Optional {
+				c.fail(fieldPath, "field is missing on the writer schema and has no default value")
+			}
+		}
+	case Enum:
+		for _, symbol := range writer.Symbols {
+			if !slices.Contains(reader.Symbols, symbol) {
+				c.fail(path, "symbol %s is unknown by the reader", symbol)
+			}
+		}
+	case Fixed:
+		if reader.Size != writer.Size {
+			c.fail(path, "fixed size %d cannot be read as %d", writer.Size, reader.Size)
+		}
+	case Array:
+		c.check(reader.Items, writer.Items, path+"[]")
+	case Map:
+		c.check(reader.Values, writer.Values, join(path, "*"))
+	}
+}
+
+// findField returns the field of the writer record matching the reader field by name or alias
+func (s *Schema) findField(readerField *Field) *Field {
+	if field := s.Field(readerField.Name); field != nil {
+		return field
+	}
+
+	for _, alias := range readerField.Aliases {
+		if field := s.Field(alias); field != nil {
+			return field
+		}
+	}
+
+	return nil
+}
+
+// promotable reports whether a value written as from can be read as to
+func promotable(from, to Type) bool {
+	if from == to {
+		return true
+	}
+
+	switch from {
+	case Int:
+		return to == Long || to == Float || to == Double
+	case Long:
+		return to == Float || to == Double
+	case Float:
+		return to == Double
+	case String:
+		return to == Bytes
+	case Bytes:
+		return to == String
+	}
+
+	return false
+}
+
+// Resolve converts a value written with the writer schema into the shape of the reader schema,
+// following the Avro schema resolution rules: fields are matched by name or by the aliases of the
+// reader, fields unknown by the reader are dropped, new fields receive their default values and
+// numbers are promoted. An error is returned when the schemas are not compatible.
+func (s *Schema) Resolve(writer *Schema, value interface{}) (interface{}, error) {
+	if err := CheckCompatibility(s, writer); err != nil {
+		return nil, err
+	}
+
+	return s.resolve(writer, value)
+}
+
+// ResolveCompatible converts a value like Resolve, for writer schemas whose compatibility with the
+// reader was already checked. When partial, the fields missing from the value, like the ones left
+// out by a projection, are ignored instead of failing or receiving their default values.
+func (s *Schema) ResolveCompatible(writer *Schema, value interface{}, partial bool) (interface{}, error) {
+	if partial {
+		return s.resolvePartial(writer, value)
+	}
+
+	return s.resolve(writer, value)
+}
+
+// resolve converts the value without checking the compatibility of the schemas
+func (s *Schema) resolve(writer *Schema, value interface{}) (interface{}, error) {
+	written, err := writer.Coerce(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Coerce(rename(s, writer, written))
+}
+
+// resolvePartial converts the value without checking the compatibility of the schemas, ignoring
+// the missing fields
+func (s *Schema) resolvePartial(writer *Schema, value interface{}) (interface{}, error) {
+	written, err := writer.CoercePartial(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.CoercePartial(rename(s, writer, written))
+}
+
+// rename moves the values of the writer fields matched by an alias of the reader to the name of
+// the reader field, and drops the fields unknown by the reader
+func rename(reader, writer *Schema, value interface{}) interface{} {
+	reader, writer = reader.NonNull(), writer.NonNull()
+
+	switch reader.Type {
+	case Record:
+		values, ok := value.(map[string]interface{})
+		if !ok || writer.Type != Record {
+			return value
+		}
+
+		renamed := make(map[string]interface{}, len(values))
+		for _, field := range reader.Fields {
+			writerField := writer.findField(field)
+			if writerField == nil {
+				continue
+			}
+
+			if v, ok := values[writerField.Name]; ok {
+				renamed[field.Name] = rename(field.Schema, writerField.Schema, v)
+			}
+		}
+		return renamed
+	case Array:
+		items, ok := value.([]interface{})
+		if !ok || writer.Type != Array {
+			return value
+		}
+
+		renamed := make([]interface{}, len(items))
+		for i, item := range items {
+			renamed[i] = rename(reader.Items, writer.Items, item)
+		}
+		return renamed
+	case Map:
+		values, ok := value.(map[string]interface{})
+		if !ok || writer.Type != Map {
+			return value
+		}
+
+		renamed := make(map[string]interface{}, len(values))
+		for k, v := range values {
+			renamed[k] = rename(reader.Values, writer.Values, v)
+		}
+		return renamed
+	case String:
+		// bytes are promoted to strings
+		if data, ok := value.([]byte); ok {
+			return string(data)
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,83 @@
+package schema
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const userV1 = `{
+  "type": "record",
+  "name": "User",
+  "fields": [
+    { "name": "UserID", "type": "string" },
+    { "name": "Name", "type": "string" },
+    { "name": "Age", "type": "int" },
+    { "name": "Legacy", "type": "string" }
+  ]
+}`
+
+const userV2 = `{
+  "type": "record",
+  "name": "User",
+  "fields": [
+    { "name": "UserID", "type": "string" },
+    { "name": "FullName", "type": "string", "aliases": ["Name"] },
+    { "name": "Age", "type": "long" },
+    { "name": "Country", "type": "string", "default": "PT" }
+  ]
+}`
+
+func TestCheckCompatibility(t *testing.T) {
+	v1, v2 := mustParse(t, userV1).Element(), mustParse(t, userV2).Element()
+
+	if err := CheckCompatibility(v2, v1); err != nil {
+		t.Errorf("CheckCompatibility(v2, v1) error = %v, want nil", err)
+	}
+
+	// v1 can't read the data of v2, which has no Name nor Legacy
+	var compatibilityErr *CompatibilityError
+	if err := CheckCompatibility(v1, v2); !errors.As(err, &compatibilityErr) {
+		t.Fatalf("CheckCompatibility(v1, v2) error = %v, want *CompatibilityError", err)
+	}
+	if len(compatibilityErr.Reasons) != 3 {
+		t.Errorf("Reasons = %v, want Name, Age and Legacy", compatibilityErr.Reasons)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	v1, v2 := mustParse(t, userV1).Element(), mustParse(t, userV2).Element()
+
+	got, err := v2.Resolve(v1, map[string]interface{}{"UserID": "42", "Name": "Ana", "Age": 30.0, "Legacy": "x"})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	want := map[string]interface{}{"UserID": "42", "FullName": "Ana", "Age": int64(30), "Country": "PT"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve() = %#v, want %#v", got, want)
+	}
+
+	if _, err := v1.Resolve(v2, want); err == nil {
+		t.Errorf("Resolve() error = nil, want the incompatibility of the schemas")
+	}
+}
+
+func TestResolveCompatiblePartial(t *testing.T) {
+	v1, v2 := mustParse(t, userV1).Element(), mustParse(t, userV2).Element()
+
+	// a projection reading only some fields of the item
+	item := map[string]interface{}{"UserID": "42", "Name": "Ana"}
+
+	got, err := v2.ResolveCompatible(v1, item, true)
+	if err != nil {
+		t.Fatalf("ResolveCompatible() error = %v", err)
+	}
+	if want := map[string]interface{}{"UserID": "42", "FullName": "Ana"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveCompatible() = %#v, want %#v", got, want)
+	}
+
+	if _, err := v2.ResolveCompatible(v1, item, false); err == nil {
+		t.Errorf("ResolveCompatible() error = nil, want the missing fields of the whole item")
+	}
+}
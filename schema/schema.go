@@ -0,0 +1,294 @@
+// Package schema provides the schema model shared by receivers and connectors. A Schema can be
+// built from an Avro schema, a JSON Schema document or a protobuf message, and is used to validate,
+// coerce and fill the defaults of the data received, to encode it as Avro and to map its attributes
+// to the DynamoDB types.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	goavro "github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Type is the type of the values described by a schema, following the Avro type system.
+type Type string
+
+const (
+	Null    Type = "null"
+	Boolean Type = "boolean"
+	Int     Type = "int"
+	Long    Type = "long"
+	Float   Type = "float"
+	Double  Type = "double"
+	Bytes   Type = "bytes"
+	String  Type = "string"
+	Record  Type = "record"
+	Enum    Type = "enum"
+	Array   Type = "array"
+	Map     Type = "map"
+	Fixed   Type = "fixed"
+	Union   Type = "union"
+)
+
+// Format identifies the kind of document a schema was built from.
+type Format string
+
+const (
+	FormatAvro       Format = "avro"
+	FormatJSONSchema Format = "jsonschema"
+	FormatProtobuf   Format = "protobuf"
+)
+
+type (
+	// Schema describes the values accepted by a resource.
+	Schema struct {
+		Type        Type
+		Name        string
+		Namespace   string
+		Aliases     []string
+		LogicalType string
+
+		// Fields of a record, ordered as declared
+		Fields []*Field
+		// Items of an array
+		Items *Schema
+		// Values of a map
+		Values *Schema
+		// Symbols of an enum
+		Symbols []string
+		// Size of a fixed
+		Size int
+		// Types of an union
+		Types []*Schema
+
+		// Closed records reject the fields that were not declared
+		Closed bool
+		// Constraints are the JSON Schema validation keywords of the value
+		Constraints *Constraints
+
+		format    Format
+		raw       []byte
+		message   protoreflect.MessageDescriptor
+		codecOnce sync.Once
+		codec     *goavro.Codec
+		codecErr  error
+	}
+
+	// Field is a field of a record.
+	Field struct {
+		Name       string
+		Aliases    []string
+		Schema     *Schema
+		Default    interface{}
+		HasDefault bool
+		// Optional fields may be omitted even without a default value
+		Optional bool
+	}
+
+	// Constraints are the validation keywords supported from JSON Schema.
+	Constraints struct {
+		Minimum          *float64
+		Maximum          *float64
+		ExclusiveMinimum *float64
+		ExclusiveMaximum *float64
+		MinLength        *int
+		MaxLength        *int
+		MinItems         *int
+		MaxItems         *int
+		Pattern          *regexp.Regexp
+	}
+)
+
+// Parse builds a schema from an Avro or JSON Schema document, detecting its format.
+func Parse(data []byte) (*Schema, error) {
+	if isJSONSchema(data) {
+		return ParseJSONSchema(data)
+	}
+
+	return ParseAvro(data)
+}
+
+// isJSONSchema reports whether the document uses JSON Schema keywords or types
+func isJSONSchema(data []byte) bool {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &doc); err != nil {
+		return false
+	}
+
+	for _, keyword := range []string{"$schema", "properties", "$ref", "$defs", "definitions"} {
+		if _, ok := doc[keyword]; ok {
+			return true
+		}
+	}
+
+	switch doc["type"] {
+	case "object", "integer", "number":
+		return true
+	}
+
+	return false
+}
+
+// assign copies the definition of another schema, which was not used yet
+func (s *Schema) assign(o *Schema) {
+	s.Type, s.Name, s.Namespace, s.Aliases, s.LogicalType = o.Type, o.Name, o.Namespace, o.Aliases, o.LogicalType
+	s.Fields, s.Items, s.Values, s.Symbols, s.Size, s.Types = o.Fields, o.Items, o.Values, o.Symbols, o.Size, o.Types
+	s.Closed, s.Constraints = o.Closed, o.Constraints
+	s.format, s.raw, s.message = o.format, o.raw, o.message
+}
+
+// Format returns the format of the document the schema was built from
+func (s *Schema) Format() Format {
+	return s.format
+}
+
+// Document returns the content of the document the schema was built from
+func (s *Schema) Document() []byte {
+	return s.raw
+}
+
+// FullName returns the name of a named type qualified by its namespace
+func (s *Schema) FullName() string {
+	if s.Namespace == "" || strings.Contains(s.Name, ".") {
+		return s.Name
+	}
+
+	return s.Namespace + "." + s.Name
+}
+
+// Field returns the field of a record called name, or nil when it doesn't exist
+func (s *Schema) Field(name string) *Field {
+	for _, field := range s.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+
+	return nil
+}
+
+// Nullable reports whether the schema accepts null values
+func (s *Schema) Nullable() bool {
+	switch s.Type {
+	case Null:
+		return true
+	case Union:
+		for _, t := range s.Types {
+			if t.Type == Null {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// NonNull returns the only non-null type of an optional union, or the schema itself
+func (s *Schema) NonNull() *Schema {
+	if s.Type != Union {
+		return s
+	}
+
+	var found *Schema
+	for _, t := range s.Types {
+		if t.Type == Null {
+			continue
+		}
+		if found != nil {
+			return s
+		}
+		found = t
+	}
+
+	if found == nil {
+		return s
+	}
+
+	return found
+}
+
+// Element returns the schema of the items of an array, or the schema itself. It allows receivers
+// whose schema is an array to handle a single item.
+func (s *Schema) Element() *Schema {
+	if s.Type == Array && s.Items != nil {
+		return s.Items
+	}
+
+	return s
+}
+
+// At returns the schema of the value found on the path, going through the fields of the records,
+// the values of the maps and the items of the arrays, or nil when the schema doesn't declare it
+func (s *Schema) At(path []string) *Schema {
+	current := s
+
+	for _, segment := range path {
+		t := current.NonNull()
+
+		switch t.Type {
+		case Record:
+			field := t.Field(segment)
+			if field == nil {
+				return nil
+			}
+			current = field.Schema
+		case Map:
+			current = t.Values
+		case Array:
+			current = t.Items
+		default:
+			return nil
+		}
+
+		if current == nil {
+			return nil
+		}
+	}
+
+	return current
+}
+
+// Codec returns the Avro codec of the schema, compiled on its first use.
+func (s *Schema) Codec() (*goavro.Codec, error) {
+	s.codecOnce.Do(func() {
+		document := s.raw
+		if s.format != FormatAvro || document == nil {
+			document = s.AvroJSON()
+		}
+
+		s.codec, s.codecErr = goavro.NewCodec(string(document))
+	})
+
+	return s.codec, s.codecErr
+}
+
+// String describes the type of the schema, as used on the validation errors
+func (s *Schema) String() string {
+	switch s.Type {
+	case Record, Fixed:
+		return fmt.Sprintf("%s %s", s.Type, s.Name)
+	case Enum:
+		return fmt.Sprintf("enum [%s]", strings.Join(s.Symbols, ", "))
+	case Array:
+		return fmt.Sprintf("array of %s", s.Items)
+	case Map:
+		return fmt.Sprintf("map of %s", s.Values)
+	case Union:
+		types := make([]string, len(s.Types))
+		for i, t := range s.Types {
+			types[i] = t.String()
+		}
+		return strings.Join(types, " or ")
+	default:
+		if s.LogicalType != "" {
+			return fmt.Sprintf("%s (%s)", s.Type, s.LogicalType)
+		}
+		return string(s.Type)
+	}
+}
@@ -0,0 +1,567 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type (
+	// FieldError describes a value that doesn't fit the schema.
+	FieldError struct {
+		// Path of the value, like 'Address.Street' or 'Phones[0]', empty for the document itself
+		Path string `json:"path"`
+		// Expected type or constraint
+		Expected string `json:"expected"`
+		// Received kind of value, like 'string', 'number' or 'missing'
+		Received string `json:"received"`
+		Message  string `json:"message"`
+	}
+
+	// ValidationError lists all the values of a document that don't fit the schema.
+	ValidationError struct {
+		Errors []FieldError `json:"errors"`
+	}
+)
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldError := range e.Errors {
+		messages[i] = fieldError.Message
+		if fieldError.Path != "" {
+			messages[i] = fmt.Sprintf("%s: %s", fieldError.Path, fieldError.Message)
+		}
+	}
+
+	return fmt.Sprintf("invalid data: %s", strings.Join(messages, "; "))
+}
+
+// prefix returns the errors with their paths inside the field called name
+func (e *ValidationError) prefix(name string) *ValidationError {
+	prefixed := &ValidationError{Errors: make([]FieldError, len(e.Errors))}
+	for i, fieldError := range e.Errors {
+		fieldError.Path = strings.TrimSuffix(join(name, fieldError.Path), ".")
+		prefixed.Errors[i] = fieldError
+	}
+
+	return prefixed
+}
+
+// coercion keeps the state of the conversion of a document
+type coercion struct {
+	partial bool
+	errors  []FieldError
+}
+
+func (c *coercion) fail(path string, expected string, value interface{}, format string, args ...interface{}) {
+	c.errors = append(c.errors, FieldError{
+		Path:     path,
+		Expected: expected,
+		Received: Kind(value),
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *coercion) result(value interface{}) (interface{}, error) {
+	if len(c.errors) > 0 {
+		return nil, &ValidationError{Errors: c.errors}
+	}
+
+	return value, nil
+}
+
+// Coerce converts a value decoded from JSON, or from other formats, into the native value described
+// by the schema: numbers receive the type of the field, numeric and boolean strings are parsed,
+// missing fields receive their default values and undeclared fields are dropped. When the value
+// doesn't fit the schema, a *ValidationError listing every violation is returned.
+func (s *Schema) Coerce(value interface{}) (interface{}, error) {
+	c := &coercion{}
+	return c.result(c.coerce(s, value, ""))
+}
+
+// CoercePartial converts a value like Coerce, but ignores the missing fields instead of failing or
+// filling their defaults. It is used on the data that identifies items, like keys and filters.
+func (s *Schema) CoercePartial(value interface{}) (interface{}, error) {
+	c := &coercion{partial: true}
+	return c.result(c.coerce(s, value, ""))
+}
+
+// Validate reports whether the value fits the schema, returning a *ValidationError otherwise.
+func (s *Schema) Validate(value interface{}) error {
+	_, err := s.Coerce(value)
+	return err
+}
+
+func (c *coercion) coerce(s *Schema, value interface{}, path string) interface{} {
+	if n, ok := value.(json.Number); ok {
+		value = numberValue(n)
+	}
+
+	var result interface{}
+
+	switch s.Type {
+	case Null:
+		if value != nil {
+			c.fail(path, s.String(), value, "expected null, received %s", Kind(value))
+		}
+		return nil
+	case Boolean:
+		result = c.coerceBoolean(s, value, path)
+	case Int, Long, Float, Double:
+		result = c.coerceNumber(s, value, path)
+	case String:
+		str, ok := value.(string)
+		if !ok {
+			c.fail(path, s.String(), value, "expected %s, received %s", s, Kind(value))
+			return nil
+		}
+		result = str
+	case Bytes, Fixed:
+		result = c.coerceBytes(s, value, path)
+	case Enum:
+		str, ok := value.(string)
+		if !ok || !slices.Contains(s.Symbols, str) {
+			c.fail(path, s.String(), value, "expected one of %s", strings.Join(s.Symbols, ", "))
+			return nil
+		}
+		result = str
+	case Array:
+		result = c.coerceArray(s, value, path)
+	case Map:
+		result = c.coerceMap(s, value, path)
+	case Record:
+		result = c.coerceRecord(s, value, path)
+	case Union:
+		return c.coerceUnion(s, value, path)
+	}
+
+	if result != nil {
+		c.check(s, result, path)
+	}
+
+	return result
+}
+
+func (c *coercion) coerceBoolean(s *Schema, value interface{}, path string) interface{} {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+
+	c.fail(path, s.String(), value, "expected boolean, received %s", Kind(value))
+	return nil
+}
+
+func (c *coercion) coerceNumber(s *Schema, value interface{}, path string) interface{} {
+	if s.Type == Int || s.Type == Long {
+		return c.coerceInteger(s, value, path)
+	}
+
+	var number float64
+
+	switch v := value.(type) {
+	case float64:
+		number = v
+	case float32:
+		number = float64(v)
+	case int:
+		number = float64(v)
+	case int32:
+		number = float64(v)
+	case int64:
+		number = float64(v)
+	case string:
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			c.fail(path, s.String(), value, "expected %s, received %s", s.Type, Kind(value))
+			return nil
+		}
+		number = parsed
+	default:
+		c.fail(path, s.String(), value, "expected %s, received %s", s.Type, Kind(value))
+		return nil
+	}
+
+	if s.Type == Float {
+		return float32(number)
+	}
+
+	return number
+}
+
+// coerceInteger converts the value into an int or a long. The integers and their strings are
+// converted without passing through float64, which can't represent every long.
+func (c *coercion) coerceInteger(s *Schema, value interface{}, path string) interface{} {
+	var number int64
+
+	switch v := value.(type) {
+	case int:
+		number = int64(v)
+	case int32:
+		number = int64(v)
+	case int64:
+		number = v
+	case float64, float32, string:
+		parsed, ok := integerValue(v)
+		if !ok {
+			c.fail(path, s.String(), value, "expected %s, received %v", s.Type, value)
+			return nil
+		}
+		number = parsed
+	default:
+		c.fail(path, s.String(), value, "expected %s, received %s", s.Type, Kind(value))
+		return nil
+	}
+
+	if s.Type == Long {
+		return number
+	}
+
+	if number < math.MinInt32 || number > math.MaxInt32 {
+		c.fail(path, s.String(), value, "expected int, received %v", number)
+		return nil
+	}
+
+	return int32(number)
+}
+
+// integerValue converts a float or a string into an int64, failing when it is not integral or
+// doesn't fit one. The strings may also hold integral floats, like '1e3'.
+func integerValue(value interface{}) (int64, bool) {
+	var number float64
+
+	switch v := value.(type) {
+	case float64:
+		number = v
+	case float32:
+		number = float64(v)
+	case string:
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return parsed, true
+		} else if errors.Is(err, strconv.ErrRange) {
+			return 0, false
+		}
+
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, false
+		}
+		number = parsed
+	}
+
+	// 2^63 is the first float64 beyond the longs, as MaxInt64 itself rounds up to it
+	if number != math.Trunc(number) || number < math.MinInt64 || number >= math.MaxInt64 {
+		return 0, false
+	}
+
+	return int64(number), true
+}
+
+func (c *coercion) coerceBytes(s *Schema, value interface{}, path string) interface{} {
+	var data []byte
+
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		c.fail(path, s.String(), value, "expected %s, received %s", s.Type, Kind(value))
+		return nil
+	}
+
+	if s.Type == Fixed && len(data) != s.Size {
+		c.fail(path, s.String(), value, "expected %d bytes, received %d", s.Size, len(data))
+		return nil
+	}
+
+	return data
+}
+
+func (c *coercion) coerceArray(s *Schema, value interface{}, path string) interface{} {
+	items, ok := value.([]interface{})
+	if !ok {
+		c.fail(path, s.String(), value, "expected array, received %s", Kind(value))
+		return nil
+	}
+
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		result[i] = c.coerce(s.Items, item, fmt.Sprintf("%s[%d]", path, i))
+	}
+
+	return result
+}
+
+func (c *coercion) coerceMap(s *Schema, value interface{}, path string) interface{} {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		c.fail(path, s.String(), value, "expected map, received %s", Kind(value))
+		return nil
+	}
+
+	result := make(map[string]interface{}, len(values))
+	for _, key := range sortedKeys(values) {
+		result[key] = c.coerce(s.Values, values[key], join(path, key))
+	}
+
+	return result
+}
+
+func (c *coercion) coerceRecord(s *Schema, value interface{}, path string) interface{} {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		c.fail(path, s.String(), value, "expected object, received %s", Kind(value))
+		return nil
+	}
+
+	result := make(map[string]interface{}, len(s.Fields))
+	declared := make(map[string]bool, len(s.Fields))
+
+	for _, field := range s.Fields {
+		declared[field.Name] = true
+		fieldPath := join(path, field.Name)
+
+		raw, found := values[field.Name]
+		for _, alias := range field.Aliases {
+			if found {
+				break
+			}
+			raw, found = values[alias]
+			declared[alias] = true
+		}
+
+		switch {
+		case found && raw == nil && field.Optional && !field.Schema.Nullable():
+			continue
+		case found:
+			result[field.Name] = c.coerce(field.Schema, raw, fieldPath)
+		case c.partial:
+			continue
+		case field.HasDefault:
+			result[field.Name] = c.coerce(field.Schema, field.Default, fieldPath)
+		case field.Optional:
+			continue
+		case field.Schema.Nullable():
+			result[field.Name] = nil
+		default:
+			c.errors = append(c.errors, FieldError{
+				Path:     fieldPath,
+				Expected: field.Schema.String(),
+				Received: "missing",
+				Message:  "required field is missing",
+			})
+		}
+	}
+
+	if s.Closed {
+		for _, key := range sortedKeys(values) {
+			if !declared[key] {
+				c.fail(join(path, key), "no value", values[key], "field is not allowed")
+			}
+		}
+	}
+
+	return result
+}
+
+func (c *coercion) coerceUnion(s *Schema, value interface{}, path string) interface{} {
+	// values wrapped by their type name, as encoded by avro json, unless a map or a record of the
+	// union accepts the object itself, like the map {"string": "x"}
+	if wrapped, ok := value.(map[string]interface{}); ok && len(wrapped) == 1 && !c.acceptsObject(s, value, path) {
+		for name, inner := range wrapped {
+			for _, t := range s.Types {
+				if name == string(t.Type) || (t.Name != "" && (name == t.Name || name == t.FullName())) {
+					return c.coerce(t, inner, path)
+				}
+			}
+		}
+	}
+
+	if value == nil {
+		if !s.Nullable() {
+			c.fail(path, s.String(), value, "expected %s, received null", s)
+		}
+		return nil
+	}
+
+	// the first type accepting the value is used, preferring the ones that don't require a conversion
+	for _, strict := range []bool{true, false} {
+		for _, t := range s.Types {
+			if t.Type == Null || (strict && !matchesKind(t, value)) {
+				continue
+			}
+
+			attempt := &coercion{partial: c.partial}
+			result := attempt.coerce(t, value, path)
+			if len(attempt.errors) == 0 {
+				return result
+			}
+		}
+	}
+
+	c.fail(path, s.String(), value, "expected %s, received %s", s, Kind(value))
+	return nil
+}
+
+// acceptsObject reports whether a map or a record type of the union accepts the object as it is
+func (c *coercion) acceptsObject(s *Schema, value interface{}, path string) bool {
+	for _, t := range s.Types {
+		if t.Type != Map && t.Type != Record {
+			continue
+		}
+
+		attempt := &coercion{partial: c.partial}
+		if attempt.coerce(t, value, path); len(attempt.errors) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// check applies the constraints of the schema to a converted value
+func (c *coercion) check(s *Schema, value interface{}, path string) {
+	limits := s.Constraints
+	if limits == nil {
+		return
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if limits.MinLength != nil && length < *limits.MinLength {
+			c.fail(path, fmt.Sprintf("minLength %d", *limits.MinLength), value, "must have at least %d characters", *limits.MinLength)
+		}
+		if limits.MaxLength != nil && length > *limits.MaxLength {
+			c.fail(path, fmt.Sprintf("maxLength %d", *limits.MaxLength), value, "must have at most %d characters", *limits.MaxLength)
+		}
+		if limits.Pattern != nil && !limits.Pattern.MatchString(v) {
+			c.fail(path, fmt.Sprintf("pattern %s", limits.Pattern), value, "must match the pattern %s", limits.Pattern)
+		}
+	case []interface{}:
+		if limits.MinItems != nil && len(v) < *limits.MinItems {
+			c.fail(path, fmt.Sprintf("minItems %d", *limits.MinItems), value, "must have at least %d items", *limits.MinItems)
+		}
+		if limits.MaxItems != nil && len(v) > *limits.MaxItems {
+			c.fail(path, fmt.Sprintf("maxItems %d", *limits.MaxItems), value, "must have at most %d items", *limits.MaxItems)
+		}
+	default:
+		number, ok := toFloat(value)
+		if !ok {
+			return
+		}
+
+		if limits.Minimum != nil && number < *limits.Minimum {
+			c.fail(path, fmt.Sprintf("minimum %v", *limits.Minimum), value, "must be greater than or equal to %v", *limits.Minimum)
+		}
+		if limits.Maximum != nil && number > *limits.Maximum {
+			c.fail(path, fmt.Sprintf("maximum %v", *limits.Maximum), value, "must be less than or equal to %v", *limits.Maximum)
+		}
+		if limits.ExclusiveMinimum != nil && number <= *limits.ExclusiveMinimum {
+			c.fail(path, fmt.Sprintf("exclusiveMinimum %v", *limits.ExclusiveMinimum), value, "must be greater than %v", *limits.ExclusiveMinimum)
+		}
+		if limits.ExclusiveMaximum != nil && number >= *limits.ExclusiveMaximum {
+			c.fail(path, fmt.Sprintf("exclusiveMaximum %v", *limits.ExclusiveMaximum), value, "must be less than %v", *limits.ExclusiveMaximum)
+		}
+	}
+}
+
+// Kind returns the kind of a value, using the JSON names: null, boolean, number, string, array
+// or object.
+func Kind(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64, float32, int, int32, int64, json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []byte:
+		return "bytes"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// matchesKind reports whether the value already has the kind of the schema type
+func matchesKind(s *Schema, value interface{}) bool {
+	kind := Kind(value)
+
+	switch s.Type {
+	case Boolean:
+		return kind == "boolean"
+	case Int, Long, Float, Double:
+		return kind == "number"
+	case String, Enum:
+		return kind == "string"
+	case Bytes, Fixed:
+		return kind == "bytes" || kind == "string"
+	case Array:
+		return kind == "array"
+	case Map, Record:
+		return kind == "object"
+	}
+
+	return false
+}
+
+func numberValue(n json.Number) interface{} {
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+
+	f, _ := n.Float64()
+	return f
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+
+	return 0, false
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,252 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const userAvro = `{
+  "type": "record",
+  "name": "User",
+  "namespace": "com.example",
+  "fields": [
+    { "name": "UserID", "type": "string" },
+    { "name": "Age", "type": "int" },
+    { "name": "Balance", "type": "long", "default": 0 },
+    { "name": "Active", "type": "boolean", "default": true },
+    { "name": "Nickname", "type": ["null", "string"], "default": null },
+    { "name": "Status", "type": { "type": "enum", "name": "Status", "symbols": ["active", "blocked"] }, "default": "active" },
+    { "name": "Tags", "type": { "type": "array", "items": "string" }, "default": [] },
+    { "name": "Address", "type": ["null", {
+      "type": "record",
+      "name": "Address",
+      "fields": [
+        { "name": "Street", "type": "string" },
+        { "name": "Number", "type": "int" }
+      ]
+    }], "default": null }
+  ]
+}`
+
+const userJSONSchema = `{
+  "type": "object",
+  "properties": {
+    "UserID": { "type": "string", "minLength": 1 },
+    "Age": { "type": "integer", "minimum": 0 },
+    "Email": { "type": "string", "pattern": "^[^@]+@[^@]+$" }
+  },
+  "required": ["UserID", "Age"]
+}`
+
+func mustParse(t testing.TB, doc string) *Schema {
+	t.Helper()
+
+	s, err := Parse([]byte(doc))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	return s
+}
+
+func TestParseDetectsTheFormat(t *testing.T) {
+	if got := mustParse(t, userAvro).Format(); got != FormatAvro {
+		t.Errorf("Format() = %v, want %v", got, FormatAvro)
+	}
+	if got := mustParse(t, userJSONSchema).Format(); got != FormatJSONSchema {
+		t.Errorf("Format() = %v, want %v", got, FormatJSONSchema)
+	}
+
+	if _, err := Parse([]byte(`{"type": "record", "name": "Broken", "fields": [{"name": "A"}]}`)); err == nil {
+		t.Errorf("Parse() error = nil, want an error for a field without type")
+	}
+}
+
+func TestCoerce(t *testing.T) {
+	s := mustParse(t, userAvro).Element()
+
+	got, err := s.Coerce(map[string]interface{}{
+		"UserID":  "42",
+		"Age":     "30",
+		"Balance": 9007199254740993.0,
+		"Active":  "false",
+		"Address": map[string]interface{}{"Street": "Main", "Number": 10.0},
+		"Unknown": "dropped",
+	})
+	if err != nil {
+		t.Fatalf("Coerce() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"UserID":   "42",
+		"Age":      int32(30),
+		"Balance":  int64(9007199254740992),
+		"Active":   false,
+		"Nickname": nil,
+		"Status":   "active",
+		"Tags":     []interface{}{},
+		"Address":  map[string]interface{}{"Street": "Main", "Number": int32(10)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Coerce() = %#v, want %#v", got, want)
+	}
+}
+
+func TestCoerceIntegers(t *testing.T) {
+	long := mustParse(t, `"long"`)
+	int32Schema := mustParse(t, `"int"`)
+
+	tests := []struct {
+		name    string
+		schema  *Schema
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "long string keeps precision", schema: long, value: "9007199254740993", want: int64(9007199254740993)},
+		{name: "json number keeps precision", schema: long, value: json.Number("9007199254740993"), want: int64(9007199254740993)},
+		{name: "integral float string", schema: long, value: "1e3", want: int64(1000)},
+		{name: "fraction", schema: long, value: 1.5, wantErr: true},
+		{name: "beyond long", schema: long, value: "9223372036854775808", wantErr: true},
+		{name: "float beyond long", schema: long, value: 9223372036854775807.0, wantErr: true},
+		{name: "int", schema: int32Schema, value: 7.0, want: int32(7)},
+		{name: "beyond int", schema: int32Schema, value: int64(1 << 31), wantErr: true},
+		{name: "not a number", schema: int32Schema, value: "seven", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.schema.Coerce(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Coerce() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Coerce() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoerceUnions(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		value  interface{}
+		want   interface{}
+	}{
+		{name: "wrapped string", schema: `["null", "string"]`, value: map[string]interface{}{"string": "x"}, want: "x"},
+		{name: "wrapped long", schema: `["null", "string", "long"]`, value: map[string]interface{}{"long": 5.0}, want: int64(5)},
+		{
+			name:   "wrapped record",
+			schema: `["null", {"type": "record", "name": "Point", "fields": [{"name": "X", "type": "int"}]}]`,
+			value:  map[string]interface{}{"Point": map[string]interface{}{"X": 1.0}},
+			want:   map[string]interface{}{"X": int32(1)},
+		},
+		{
+			name:   "map keyed by a type name",
+			schema: `["null", "string", {"type": "map", "values": "string"}]`,
+			value:  map[string]interface{}{"string": "x"},
+			want:   map[string]interface{}{"string": "x"},
+		},
+		{
+			name:   "record with a field named as a type",
+			schema: `["null", "string", {"type": "record", "name": "Tag", "fields": [{"name": "string", "type": "string"}]}]`,
+			value:  map[string]interface{}{"string": "x"},
+			want:   map[string]interface{}{"string": "x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mustParse(t, tt.schema).Coerce(tt.value)
+			if err != nil {
+				t.Fatalf("Coerce() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Coerce() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoerceListsEveryViolation(t *testing.T) {
+	s := mustParse(t, userAvro).Element()
+
+	_, err := s.Coerce(map[string]interface{}{
+		"Age":     "old",
+		"Status":  "deleted",
+		"Address": map[string]interface{}{"Street": 1},
+	})
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Coerce() error = %v, want *ValidationError", err)
+	}
+
+	paths := map[string]bool{}
+	for _, fieldError := range validationErr.Errors {
+		paths[fieldError.Path] = true
+	}
+	for _, path := range []string{"UserID", "Age", "Status", "Address"} {
+		if !paths[path] {
+			t.Errorf("the errors %v don't report the path %s", validationErr.Errors, path)
+		}
+	}
+}
+
+func TestCoercePartialIgnoresMissingFields(t *testing.T) {
+	s := mustParse(t, userAvro).Element()
+
+	got, err := s.CoercePartial(map[string]interface{}{"UserID": "42"})
+	if err != nil {
+		t.Fatalf("CoercePartial() error = %v", err)
+	}
+	if want := map[string]interface{}{"UserID": "42"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CoercePartial() = %#v, want %#v", got, want)
+	}
+}
+
+func TestJSONSchemaConstraints(t *testing.T) {
+	s := mustParse(t, userJSONSchema).Element()
+
+	tests := []struct {
+		name    string
+		value   map[string]interface{}
+		wantErr bool
+	}{
+		{name: "valid", value: map[string]interface{}{"UserID": "1", "Age": 3.0, "Email": "a@b"}},
+		{name: "empty id", value: map[string]interface{}{"UserID": "", "Age": 3.0}, wantErr: true},
+		{name: "negative age", value: map[string]interface{}{"UserID": "1", "Age": -1.0}, wantErr: true},
+		{name: "invalid email", value: map[string]interface{}{"UserID": "1", "Age": 3.0, "Email": "nope"}, wantErr: true},
+		{name: "missing age", value: map[string]interface{}{"UserID": "1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Validate(tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAttributeValues(t *testing.T) {
+	s := mustParse(t, userAvro)
+
+	values, err := s.AttributeValues(map[string]interface{}{"UserID": "42", "Age": int32(30), "Tags": []interface{}{"a"}})
+	if err != nil {
+		t.Fatalf("AttributeValues() error = %v", err)
+	}
+
+	if values["UserID"].S == nil || *values["UserID"].S != "42" {
+		t.Errorf("UserID = %v, want S 42", values["UserID"])
+	}
+	if values["Age"].N == nil || *values["Age"].N != "30" {
+		t.Errorf("Age = %v, want N 30", values["Age"])
+	}
+	if len(values["Tags"].L) != 1 {
+		t.Errorf("Tags = %v, want a list", values["Tags"])
+	}
+}